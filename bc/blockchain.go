@@ -13,6 +13,7 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/regnull/easyecc/v2"
 	cnt "github.com/regnull/ubchain/gocontract"
+	"github.com/regnull/ubikom/pb"
 )
 
 var ErrNotFound = fmt.Errorf("not found")
@@ -25,6 +26,7 @@ type Blockchain interface {
 	PublicKeyP256(ctx context.Context, name string) (*easyecc.PublicKey, error)
 	PublicKeyByCurve(ctx context.Context, name string,
 		curve easyecc.EllipticCurve) (*easyecc.PublicKey, error)
+	KeyStatus(ctx context.Context, key []byte) (*pb.LookupKeyResponse, error)
 }
 type blockchainImpl struct {
 	caller          NameRegistryCaller
@@ -105,3 +107,10 @@ func (b *blockchainImpl) PublicKeyByCurve(ctx context.Context, name string,
 	}
 	return nil, fmt.Errorf("unsupported curve")
 }
+
+// KeyStatus always reports the key as active, since the name registry contract doesn't
+// track key revocation. Use NewBlockchainWithKeyRegistry to get the key status from
+// the identity registry.
+func (b *blockchainImpl) KeyStatus(ctx context.Context, key []byte) (*pb.LookupKeyResponse, error) {
+	return &pb.LookupKeyResponse{}, nil
+}
@@ -0,0 +1,217 @@
+package bc
+
+import (
+	"container/list"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/regnull/easyecc/v2"
+	"github.com/regnull/ubikom/pb"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultCacheTTL           = 5 * time.Minute
+	defaultCacheNegativeTTL   = 30 * time.Second
+	defaultCacheKeyStatusTTL  = 30 * time.Second
+	defaultCacheMaxEntries    = 10000
+	defaultCacheLookupTimeout = 30 * time.Second
+)
+
+// CachingOptions control the behavior of the caching blockchain.
+type CachingOptions struct {
+	// TTL is how long the successful lookups are cached.
+	TTL time.Duration
+
+	// NegativeTTL is how long ErrNotFound results are cached. It should be shorter than
+	// TTL, so that newly registered names become visible soon.
+	NegativeTTL time.Duration
+
+	// KeyStatusTTL is how long the key status is cached. It should be short, so that
+	// a disabled key is rejected soon.
+	KeyStatusTTL time.Duration
+
+	// MaxEntries limits the number of cached results. The least recently used ones
+	// are evicted first.
+	MaxEntries int
+
+	// LookupTimeout limits the lookups, which are not cancelled together with the
+	// request that started them, since other requests might be waiting for the result.
+	LookupTimeout time.Duration
+}
+
+type cacheEntry struct {
+	key     string
+	value   interface{}
+	err     error
+	expires time.Time
+}
+
+type cachingBlockchain struct {
+	bchain  Blockchain
+	opts    CachingOptions
+	now     func() time.Time
+	group   singleflight.Group
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// lru keeps the entries, the most recently used first.
+	lru *list.List
+}
+
+// NewCachingBlockchain returns Blockchain which caches the results of the lookups
+// made through bchain. Concurrent lookups of the same name are collapsed into
+// a single call. Errors other than ErrNotFound are not cached.
+func NewCachingBlockchain(bchain Blockchain, opts CachingOptions) Blockchain {
+	if opts.TTL == 0 {
+		opts.TTL = defaultCacheTTL
+	}
+	if opts.NegativeTTL == 0 {
+		opts.NegativeTTL = defaultCacheNegativeTTL
+	}
+	if opts.KeyStatusTTL == 0 {
+		opts.KeyStatusTTL = defaultCacheKeyStatusTTL
+	}
+	if opts.MaxEntries == 0 {
+		opts.MaxEntries = defaultCacheMaxEntries
+	}
+	if opts.LookupTimeout == 0 {
+		opts.LookupTimeout = defaultCacheLookupTimeout
+	}
+	return &cachingBlockchain{
+		bchain:  bchain,
+		opts:    opts,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (b *cachingBlockchain) PublicKey(ctx context.Context, name string) (*easyecc.PublicKey, error) {
+	v, err := b.lookup(ctx, "pubkey:"+name, b.opts.TTL, func(ctx context.Context) (interface{}, error) {
+		return b.bchain.PublicKey(ctx, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*easyecc.PublicKey), nil
+}
+
+func (b *cachingBlockchain) Endpoint(ctx context.Context, name string) (string, error) {
+	v, err := b.lookup(ctx, "endpoint:"+name, b.opts.TTL, func(ctx context.Context) (interface{}, error) {
+		return b.bchain.Endpoint(ctx, name)
+	})
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+func (b *cachingBlockchain) PublicKeyP256(ctx context.Context, name string) (*easyecc.PublicKey, error) {
+	v, err := b.lookup(ctx, "pubkey-p256:"+name, b.opts.TTL, func(ctx context.Context) (interface{}, error) {
+		return b.bchain.PublicKeyP256(ctx, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*easyecc.PublicKey), nil
+}
+
+func (b *cachingBlockchain) PublicKeyByCurve(ctx context.Context, name string,
+	curve easyecc.EllipticCurve) (*easyecc.PublicKey, error) {
+	if curve == easyecc.SECP256K1 {
+		return b.PublicKey(ctx, name)
+	} else if curve == easyecc.P256 {
+		return b.PublicKeyP256(ctx, name)
+	}
+	return nil, fmt.Errorf("unsupported curve")
+}
+
+func (b *cachingBlockchain) KeyStatus(ctx context.Context, key []byte) (*pb.LookupKeyResponse, error) {
+	v, err := b.lookup(ctx, "status:"+hex.EncodeToString(key), b.opts.KeyStatusTTL,
+		func(ctx context.Context) (interface{}, error) {
+			return b.bchain.KeyStatus(ctx, key)
+		})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*pb.LookupKeyResponse), nil
+}
+
+// lookup returns the cached result for the key, or calls f to get it. If several
+// goroutines look up the same key, only one of them calls f, and the rest share
+// its result. Successful results are cached for ttl.
+func (b *cachingBlockchain) lookup(ctx context.Context, key string, ttl time.Duration,
+	f func(context.Context) (interface{}, error)) (interface{}, error) {
+	if entry := b.get(key); entry != nil {
+		return entry.value, entry.err
+	}
+	ch := b.group.DoChan(key, func() (interface{}, error) {
+		// The caller who started the lookup might go away, while the others still
+		// wait for it.
+		lookupCtx, cancel := context.WithTimeout(detachedContext{ctx}, b.opts.LookupTimeout)
+		defer cancel()
+		v, err := f(lookupCtx)
+		if err == nil {
+			b.put(&cacheEntry{key: key, value: v, expires: b.now().Add(ttl)})
+		} else if errors.Is(err, ErrNotFound) {
+			b.put(&cacheEntry{key: key, err: err, expires: b.now().Add(b.opts.NegativeTTL)})
+		}
+		return v, err
+	})
+	select {
+	case res := <-ch:
+		return res.Val, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *cachingBlockchain) get(key string) *cacheEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	elem, ok := b.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !b.now().Before(entry.expires) {
+		b.remove(elem)
+		return nil
+	}
+	b.lru.MoveToFront(elem)
+	return entry
+}
+
+func (b *cachingBlockchain) put(entry *cacheEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if elem, ok := b.entries[entry.key]; ok {
+		b.remove(elem)
+	}
+	for len(b.entries) >= b.opts.MaxEntries {
+		b.remove(b.lru.Back())
+	}
+	b.entries[entry.key] = b.lru.PushFront(entry)
+}
+
+func (b *cachingBlockchain) remove(elem *list.Element) {
+	b.lru.Remove(elem)
+	delete(b.entries, elem.Value.(*cacheEntry).key)
+}
+
+// detachedContext keeps the values of the parent context, but is never cancelled.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
@@ -0,0 +1,189 @@
+package bc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/regnull/easyecc/v2"
+	"github.com/regnull/ubikom/bc/mocks"
+	"github.com/regnull/ubikom/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_CachingBlockchain_TTL(t *testing.T) {
+	assert := assert.New(t)
+
+	privateKey, err := easyecc.NewPrivateKey(easyecc.SECP256K1)
+	assert.NoError(err)
+
+	inner := mocks.NewMockBlockchain(t)
+	inner.EXPECT().PublicKey(mock.Anything, "foo").Return(privateKey.PublicKey(), nil).Times(2)
+	inner.EXPECT().Endpoint(mock.Anything, "foo").Return("localhost:8826", nil).Once()
+
+	now := time.Now()
+	bchain := NewCachingBlockchain(inner, CachingOptions{TTL: time.Minute}).(*cachingBlockchain)
+	bchain.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		key, err := bchain.PublicKeyByCurve(ctx, "foo", easyecc.SECP256K1)
+		assert.NoError(err)
+		assert.True(key.Equal(privateKey.PublicKey()))
+		endpoint, err := bchain.Endpoint(ctx, "foo")
+		assert.NoError(err)
+		assert.Equal("localhost:8826", endpoint)
+	}
+
+	// The key expires, and the endpoint is still cached.
+	now = now.Add(time.Minute)
+	key, err := bchain.PublicKey(ctx, "foo")
+	assert.NoError(err)
+	assert.True(key.Equal(privateKey.PublicKey()))
+}
+
+func Test_CachingBlockchain_Errors(t *testing.T) {
+	assert := assert.New(t)
+
+	inner := mocks.NewMockBlockchain(t)
+	inner.EXPECT().PublicKeyP256(mock.Anything, "foo").Return(nil, ErrNotFound).Times(2)
+	inner.EXPECT().PublicKeyP256(mock.Anything, "bar").Return(nil, fmt.Errorf("node is down")).Times(2)
+
+	now := time.Now()
+	bchain := NewCachingBlockchain(inner, CachingOptions{TTL: time.Hour, NegativeTTL: time.Minute}).(*cachingBlockchain)
+	bchain.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	// Not found is cached for NegativeTTL.
+	for i := 0; i < 2; i++ {
+		_, err := bchain.PublicKeyP256(ctx, "foo")
+		assert.ErrorIs(err, ErrNotFound)
+	}
+	now = now.Add(time.Minute)
+	_, err := bchain.PublicKeyP256(ctx, "foo")
+	assert.ErrorIs(err, ErrNotFound)
+
+	// Other errors are not cached.
+	for i := 0; i < 2; i++ {
+		_, err := bchain.PublicKeyP256(ctx, "bar")
+		assert.Error(err)
+	}
+}
+
+func Test_CachingBlockchain_ConcurrentLookups(t *testing.T) {
+	assert := assert.New(t)
+
+	release := make(chan struct{})
+	inner := mocks.NewMockBlockchain(t)
+	inner.EXPECT().Endpoint(mock.Anything, "foo").RunAndReturn(func(ctx context.Context, name string) (string, error) {
+		<-release
+		return "localhost:8826", nil
+	}).Once()
+
+	bchain := NewCachingBlockchain(inner, CachingOptions{})
+
+	const n = 10
+	var started, done sync.WaitGroup
+	started.Add(n)
+	done.Add(n)
+	results := make([]string, n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer done.Done()
+			started.Done()
+			results[i], _ = bchain.Endpoint(context.Background(), "foo")
+		}(i)
+	}
+	started.Wait()
+	// Give the goroutines a chance to join the pending lookup.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	done.Wait()
+
+	for _, res := range results {
+		assert.Equal("localhost:8826", res)
+	}
+}
+
+func Test_CachingBlockchain_MaxEntries(t *testing.T) {
+	assert := assert.New(t)
+
+	lookups := make(map[string]int)
+	inner := mocks.NewMockBlockchain(t)
+	inner.EXPECT().Endpoint(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, name string) (string, error) {
+		lookups[name]++
+		return "localhost:8826", nil
+	})
+
+	bchain := NewCachingBlockchain(inner, CachingOptions{MaxEntries: 5}).(*cachingBlockchain)
+	ctx := context.Background()
+	for i := 0; i < 20; i++ {
+		_, err := bchain.Endpoint(ctx, fmt.Sprintf("name%d", i))
+		assert.NoError(err)
+		assert.LessOrEqual(len(bchain.entries), 5)
+		// The recently used entry is not evicted.
+		_, err = bchain.Endpoint(ctx, "name0")
+		assert.NoError(err)
+	}
+	assert.Len(bchain.entries, 5)
+	assert.Equal(1, lookups["name0"])
+}
+
+func Test_CachingBlockchain_KeyStatusTTL(t *testing.T) {
+	assert := assert.New(t)
+
+	inner := mocks.NewMockBlockchain(t)
+	inner.EXPECT().KeyStatus(mock.Anything, []byte("key")).Return(&pb.LookupKeyResponse{}, nil).Times(2)
+
+	now := time.Now()
+	bchain := NewCachingBlockchain(inner, CachingOptions{TTL: time.Hour, KeyStatusTTL: time.Second}).(*cachingBlockchain)
+	bchain.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	_, err := bchain.KeyStatus(ctx, []byte("key"))
+	assert.NoError(err)
+	_, err = bchain.KeyStatus(ctx, []byte("key"))
+	assert.NoError(err)
+	now = now.Add(time.Second)
+	_, err = bchain.KeyStatus(ctx, []byte("key"))
+	assert.NoError(err)
+}
+
+func Test_CachingBlockchain_CancelledCaller(t *testing.T) {
+	assert := assert.New(t)
+
+	release := make(chan struct{})
+	inner := mocks.NewMockBlockchain(t)
+	inner.EXPECT().Endpoint(mock.Anything, "foo").RunAndReturn(func(ctx context.Context, name string) (string, error) {
+		select {
+		case <-release:
+			return "localhost:8826", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}).Once()
+
+	bchain := NewCachingBlockchain(inner, CachingOptions{})
+
+	// The first caller gives up, the lookup it started goes on for the second one.
+	ctx, cancel := context.WithCancel(context.Background())
+	firstDone := make(chan error)
+	go func() {
+		_, err := bchain.Endpoint(ctx, "foo")
+		firstDone <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	secondDone := make(chan string)
+	go func() {
+		endpoint, _ := bchain.Endpoint(context.Background(), "foo")
+		secondDone <- endpoint
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	assert.ErrorIs(<-firstDone, context.Canceled)
+	close(release)
+	assert.Equal("localhost:8826", <-secondDone)
+}
@@ -0,0 +1,71 @@
+package bc
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/regnull/ubikom/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxKeyDelegationDepth limits how far up the parent chain CheckKey goes.
+const maxKeyDelegationDepth = 8
+
+var ErrKeyDisabled = errors.New("key is disabled")
+
+// CheckKey returns ErrKeyDisabled if the key, or any of its parents, is disabled.
+// The key is compressed public key.
+func CheckKey(ctx context.Context, bchain Blockchain, key []byte) error {
+	visited := make(map[string]bool)
+	keys := [][]byte{key}
+	for depth := 0; len(keys) > 0; depth++ {
+		if depth > maxKeyDelegationDepth {
+			return fmt.Errorf("key delegation chain is too long")
+		}
+		var parents [][]byte
+		for _, k := range keys {
+			if visited[string(k)] {
+				continue
+			}
+			visited[string(k)] = true
+			keyStatus, err := bchain.KeyStatus(ctx, k)
+			if err != nil {
+				return err
+			}
+			if keyStatus.GetDisabled() {
+				return ErrKeyDisabled
+			}
+			// Parent keys can do everything their children can, including using them.
+			// Disabling a parent disables its children.
+			parents = append(parents, keyStatus.GetParentKey()...)
+		}
+		keys = parents
+	}
+	return nil
+}
+
+type keyRegistryBlockchain struct {
+	Blockchain
+	client pb.LookupServiceClient
+}
+
+// NewBlockchainWithKeyRegistry returns Blockchain which gets the key status from the
+// identity registry server, the rest of the lookups go to bchain. The keys unknown
+// to the registry are considered active.
+func NewBlockchainWithKeyRegistry(bchain Blockchain, client pb.LookupServiceClient) Blockchain {
+	return &keyRegistryBlockchain{Blockchain: bchain, client: client}
+}
+
+func (b *keyRegistryBlockchain) KeyStatus(ctx context.Context, key []byte) (*pb.LookupKeyResponse, error) {
+	res, err := b.client.LookupKey(ctx, &pb.LookupKeyRequest{Key: key})
+	if status.Code(err) == codes.NotFound {
+		return &pb.LookupKeyResponse{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup key %s: %w", hex.EncodeToString(key), err)
+	}
+	return res, nil
+}
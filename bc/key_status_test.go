@@ -0,0 +1,72 @@
+package bc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/regnull/ubikom/bc/mocks"
+	"github.com/regnull/ubikom/pb"
+	pbmocks "github.com/regnull/ubikom/pb/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_CheckKey(t *testing.T) {
+	assert := assert.New(t)
+
+	child := []byte("child")
+	parent := []byte("parent")
+	grandparent := []byte("grandparent")
+
+	ctx := context.Background()
+	bchain := mocks.NewMockBlockchain(t)
+	bchain.EXPECT().KeyStatus(ctx, child).Return(&pb.LookupKeyResponse{ParentKey: [][]byte{parent}}, nil)
+	bchain.EXPECT().KeyStatus(ctx, parent).Return(&pb.LookupKeyResponse{ParentKey: [][]byte{grandparent}}, nil)
+	bchain.EXPECT().KeyStatus(ctx, grandparent).Return(&pb.LookupKeyResponse{}, nil).Once()
+	assert.NoError(CheckKey(ctx, bchain, child))
+
+	// The grandparent is revoked, and it takes the whole family down.
+	bchain.EXPECT().KeyStatus(ctx, grandparent).Return(&pb.LookupKeyResponse{Disabled: true}, nil)
+	assert.ErrorIs(CheckKey(ctx, bchain, child), ErrKeyDisabled)
+	assert.ErrorIs(CheckKey(ctx, bchain, grandparent), ErrKeyDisabled)
+}
+
+func Test_CheckKey_Cycle(t *testing.T) {
+	ctx := context.Background()
+	bchain := mocks.NewMockBlockchain(t)
+	bchain.EXPECT().KeyStatus(ctx, []byte("a")).Return(&pb.LookupKeyResponse{ParentKey: [][]byte{[]byte("b")}}, nil).Once()
+	bchain.EXPECT().KeyStatus(ctx, []byte("b")).Return(&pb.LookupKeyResponse{ParentKey: [][]byte{[]byte("a")}}, nil).Once()
+	assert.NoError(t, CheckKey(ctx, bchain, []byte("a")))
+}
+
+func Test_CheckKey_Error(t *testing.T) {
+	ctx := context.Background()
+	bchain := mocks.NewMockBlockchain(t)
+	bchain.EXPECT().KeyStatus(ctx, mock.Anything).Return(nil, fmt.Errorf("registry is down"))
+	err := CheckKey(ctx, bchain, []byte("a"))
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrKeyDisabled)
+}
+
+func Test_BlockchainWithKeyRegistry(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	client := new(pbmocks.LookupServiceClient)
+	client.On("LookupKey", ctx, &pb.LookupKeyRequest{Key: []byte("known")}).Return(
+		&pb.LookupKeyResponse{Disabled: true, DisabledTimestamp: 1000}, nil)
+	client.On("LookupKey", ctx, &pb.LookupKeyRequest{Key: []byte("unknown")}).Return(
+		nil, status.Error(codes.NotFound, "not found"))
+
+	bchain := NewBlockchainWithKeyRegistry(mocks.NewMockBlockchain(t), client)
+	res, err := bchain.KeyStatus(ctx, []byte("known"))
+	assert.NoError(err)
+	assert.True(res.GetDisabled())
+
+	res, err = bchain.KeyStatus(ctx, []byte("unknown"))
+	assert.NoError(err)
+	assert.False(res.GetDisabled())
+}
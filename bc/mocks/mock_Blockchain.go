@@ -7,6 +7,8 @@ import (
 
 	easyecc "github.com/regnull/easyecc/v2"
 	mock "github.com/stretchr/testify/mock"
+
+	pb "github.com/regnull/ubikom/pb"
 )
 
 // MockBlockchain is an autogenerated mock type for the Blockchain type
@@ -75,6 +77,61 @@ func (_c *MockBlockchain_Endpoint_Call) RunAndReturn(run func(context.Context, s
 	return _c
 }
 
+// KeyStatus provides a mock function with given fields: ctx, key
+func (_m *MockBlockchain) KeyStatus(ctx context.Context, key []byte) (*pb.LookupKeyResponse, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 *pb.LookupKeyResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) (*pb.LookupKeyResponse, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) *pb.LookupKeyResponse); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*pb.LookupKeyResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []byte) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockBlockchain_KeyStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'KeyStatus'
+type MockBlockchain_KeyStatus_Call struct {
+	*mock.Call
+}
+
+// KeyStatus is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key []byte
+func (_e *MockBlockchain_Expecter) KeyStatus(ctx interface{}, key interface{}) *MockBlockchain_KeyStatus_Call {
+	return &MockBlockchain_KeyStatus_Call{Call: _e.mock.On("KeyStatus", ctx, key)}
+}
+
+func (_c *MockBlockchain_KeyStatus_Call) Run(run func(ctx context.Context, key []byte)) *MockBlockchain_KeyStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]byte))
+	})
+	return _c
+}
+
+func (_c *MockBlockchain_KeyStatus_Call) Return(_a0 *pb.LookupKeyResponse, _a1 error) *MockBlockchain_KeyStatus_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockBlockchain_KeyStatus_Call) RunAndReturn(run func(context.Context, []byte) (*pb.LookupKeyResponse, error)) *MockBlockchain_KeyStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // PublicKey provides a mock function with given fields: ctx, name
 func (_m *MockBlockchain) PublicKey(ctx context.Context, name string) (*easyecc.PublicKey, error) {
 	ret := _m.Called(ctx, name)
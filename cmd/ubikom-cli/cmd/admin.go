@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/regnull/ubikom/cmd/ubikom-cli/cmd/cmdutil"
+	"github.com/regnull/ubikom/pb"
+	"github.com/regnull/ubikom/protoutil"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	adminCmd.PersistentFlags().String("dump-service-url", "", "dump service url, use tls:// prefix for TLS")
+	adminCmd.PersistentFlags().String("tls-ca-file", "", "CA certificate used to verify the dump server, system CAs if empty")
+	adminCmd.PersistentFlags().String("tls-cert-file", "", "client certificate for mutual TLS")
+	adminCmd.PersistentFlags().String("tls-key-file", "", "client certificate key for mutual TLS")
+	addIdentityProofFlags(adminCmd)
+	adminCmd.PersistentFlags().String("key", "", "Location of the operator's private key file")
+
+	adminCmd.AddCommand(adminMailboxesCmd)
+	adminCmd.AddCommand(adminUsageCmd)
+	adminCmd.AddCommand(adminPurgeCmd)
+
+	adminDeleteMessageCmd.Flags().String("receiver-key", "", "receiver's public key, as printed by get public-key")
+	adminDeleteMessageCmd.Flags().String("hash", "", "message hash (delivery ID), hex-encoded")
+	adminCmd.AddCommand(adminDeleteMessageCmd)
+
+	adminGCCmd.Flags().Float64("discard-ratio", 0.5, "rewrite the files which have at least this fraction of removed data")
+	adminCmd.AddCommand(adminGCCmd)
+
+	rootCmd.AddCommand(adminCmd)
+}
+
+var adminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: "Dump server administration",
+	Long:  "Dump server administration, requires the operator's key",
+	Run: func(cmd *cobra.Command, args []string) {
+		log.Fatal().Msg("sub-command required (do 'ubikom-cli admin --help' to see available commands)")
+	},
+}
+
+var adminMailboxesCmd = &cobra.Command{
+	Use:   "mailboxes",
+	Short: "List mailboxes",
+	Long:  "List the number and the size of messages for each receiver",
+	Run: func(cmd *cobra.Command, args []string) {
+		runAdminCommand(cmd, protoutil.IdentityOperationAdminListMailboxes, func(ctx context.Context, client pb.DumpAdminServiceClient,
+			identityProof *pb.Signed, cryptoContext *pb.CryptoContext) error {
+			res, err := client.ListMailboxes(ctx, &pb.ListMailboxesRequest{
+				IdentityProof: identityProof,
+				CryptoContext: cryptoContext,
+			})
+			if err != nil {
+				return err
+			}
+			for _, mailbox := range res.GetMailbox() {
+				fmt.Printf("%x %d %d\n", mailbox.GetReceiverKey(), mailbox.GetCount(), mailbox.GetSize())
+			}
+			return nil
+		})
+	},
+}
+
+var adminUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show storage usage",
+	Long:  "Show storage usage",
+	Run: func(cmd *cobra.Command, args []string) {
+		runAdminCommand(cmd, protoutil.IdentityOperationAdminGetStorageUsage, func(ctx context.Context, client pb.DumpAdminServiceClient,
+			identityProof *pb.Signed, cryptoContext *pb.CryptoContext) error {
+			res, err := client.GetStorageUsage(ctx, &pb.GetStorageUsageRequest{
+				IdentityProof: identityProof,
+				CryptoContext: cryptoContext,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("mailboxes: %d\n", res.GetMailboxes())
+			fmt.Printf("messages: %d\n", res.GetMessages())
+			fmt.Printf("size: %d\n", res.GetSize())
+			fmt.Printf("disk size: %d\n", res.GetDiskSize())
+			return nil
+		})
+	},
+}
+
+var adminPurgeCmd = &cobra.Command{
+	Use:   "purge [receiver-key]",
+	Short: "Purge mailbox",
+	Long:  "Remove all messages for the receiver with the given hex-encoded public key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		receiverKey, err := decodeHex(args[0])
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid receiver key")
+		}
+		runAdminCommand(cmd, protoutil.IdentityOperationAdminPurgeMailbox, func(ctx context.Context, client pb.DumpAdminServiceClient,
+			identityProof *pb.Signed, cryptoContext *pb.CryptoContext) error {
+			res, err := client.PurgeMailbox(ctx, &pb.PurgeMailboxRequest{
+				IdentityProof: identityProof,
+				CryptoContext: cryptoContext,
+				ReceiverKey:   receiverKey,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("removed %d messages\n", res.GetCount())
+			return nil
+		})
+	},
+}
+
+var adminDeleteMessageCmd = &cobra.Command{
+	Use:   "delete-message",
+	Short: "Delete message",
+	Long:  "Delete a single message",
+	Run: func(cmd *cobra.Command, args []string) {
+		receiverKey, err := getHexFlag(cmd, "receiver-key")
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid receiver key")
+		}
+		hash, err := getHexFlag(cmd, "hash")
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid message hash")
+		}
+		runAdminCommand(cmd, protoutil.IdentityOperationAdminDeleteMessage, func(ctx context.Context, client pb.DumpAdminServiceClient,
+			identityProof *pb.Signed, cryptoContext *pb.CryptoContext) error {
+			_, err := client.DeleteMessage(ctx, &pb.DeleteMessageRequest{
+				IdentityProof: identityProof,
+				CryptoContext: cryptoContext,
+				ReceiverKey:   receiverKey,
+				MessageHash:   hash,
+			})
+			return err
+		})
+	},
+}
+
+var adminGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Run garbage collection",
+	Long:  "Reclaim the disk space used by the removed messages",
+	Run: func(cmd *cobra.Command, args []string) {
+		discardRatio, err := cmd.Flags().GetFloat64("discard-ratio")
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to get discard ratio")
+		}
+		runAdminCommand(cmd, protoutil.IdentityOperationAdminRunGC, func(ctx context.Context, client pb.DumpAdminServiceClient,
+			identityProof *pb.Signed, cryptoContext *pb.CryptoContext) error {
+			res, err := client.RunGarbageCollection(ctx, &pb.RunGarbageCollectionRequest{
+				IdentityProof: identityProof,
+				CryptoContext: cryptoContext,
+				DiscardRatio:  discardRatio,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("rewritten %d files\n", res.GetRewritten())
+			return nil
+		})
+	},
+}
+
+type adminFunc func(ctx context.Context, client pb.DumpAdminServiceClient,
+	identityProof *pb.Signed, cryptoContext *pb.CryptoContext) error
+
+// runAdminCommand connects to the dump server, creates the operator's identity proof
+// for the given operation and calls f.
+func runAdminCommand(cmd *cobra.Command, operation string, f adminFunc) {
+	privateKey, err := cmdutil.LoadKeyFromFlag(cmd, "key")
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load operator key")
+	}
+
+	dumpConn, err := dialDumpServer(cmd)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to connect to the dump server")
+	}
+	defer dumpConn.Close()
+
+	identityOpts, err := getIdentityProofOptions(cmd)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to get identity proof options")
+	}
+
+	ctx := context.Background()
+	// The admin service doesn't issue challenges, the random nonce is used instead.
+	identityProof, err := protoutil.CreateIdentityProof(privateKey, nil, identityOpts.Audience,
+		operation, time.Now())
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to create identity proof")
+	}
+	cryptoContext := &pb.CryptoContext{
+		EllipticCurve: protoutil.CurveToProto(privateKey.Curve()),
+		EcdhVersion:   2,
+		EcdsaVersion:  1,
+	}
+	err = f(ctx, pb.NewDumpAdminServiceClient(dumpConn), identityProof, cryptoContext)
+	if err != nil {
+		log.Fatal().Err(err).Msg("admin request failed")
+	}
+}
+
+func getHexFlag(cmd *cobra.Command, name string) ([]byte, error) {
+	s, err := cmd.Flags().GetString(name)
+	if err != nil {
+		return nil, err
+	}
+	if s == "" {
+		return nil, fmt.Errorf("--%s must be specified", name)
+	}
+	return decodeHex(s)
+}
+
+// decodeHex decodes the hex string, with or without 0x prefix.
+func decodeHex(s string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(s, "0x"))
+}
@@ -1,15 +1,21 @@
 package cmdutil
 
 import (
+	"crypto/tls"
 	"fmt"
 	"os"
 
 	"github.com/regnull/easyecc/v2"
+	"github.com/regnull/ubikom/bc"
 	"github.com/regnull/ubikom/globals"
+	"github.com/regnull/ubikom/pb"
+	"github.com/regnull/ubikom/protoutil"
 	"github.com/regnull/ubikom/util"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 func LoadKeyFromFlag(cmd *cobra.Command, keyFlagName string) (*easyecc.PrivateKey, error) {
@@ -97,3 +103,68 @@ func GetContractAddress(flags *pflag.FlagSet) (string, error) {
 	}
 	return "", fmt.Errorf("invalid network, must be main or sepolia")
 }
+
+// GetDumpServiceClientFactoryOptions creates the dump service connection options
+// from the TLS flags.
+func GetDumpServiceClientFactoryOptions(flags *pflag.FlagSet) (protoutil.DumpServiceClientFactoryOptions, error) {
+	var opts protoutil.DumpServiceClientFactoryOptions
+	caFile, err := flags.GetString("tls-ca-file")
+	if err != nil {
+		return opts, fmt.Errorf("failed to get CA file")
+	}
+	if caFile != "" {
+		opts.RootCAs, err = protoutil.LoadCertPool(caFile)
+		if err != nil {
+			return opts, err
+		}
+	}
+	certFile, err := flags.GetString("tls-cert-file")
+	if err != nil {
+		return opts, fmt.Errorf("failed to get certificate file")
+	}
+	keyFile, err := flags.GetString("tls-key-file")
+	if err != nil {
+		return opts, fmt.Errorf("failed to get key file")
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return opts, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		opts.Certificates = []tls.Certificate{cert}
+	}
+	return opts, nil
+}
+
+// NewBlockchain connects to the blockchain node, gets the key status from
+// --key-registry-url if it's set, and caches the lookups if --lookup-cache-ttl is set.
+func NewBlockchain(flags *pflag.FlagSet, nodeURL string, contractAddress string) (bc.Blockchain, error) {
+	bchain, err := bc.NewBlockchain(nodeURL, contractAddress)
+	if err != nil {
+		return nil, err
+	}
+	keyRegistryURL, err := flags.GetString("key-registry-url")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get key registry URL")
+	}
+	if keyRegistryURL != "" {
+		// The connection is used until the command exits.
+		conn, err := grpc.Dial(keyRegistryURL, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to key registry: %w", err)
+		}
+		bchain = bc.NewBlockchainWithKeyRegistry(bchain, pb.NewLookupServiceClient(conn))
+	}
+	ttl, err := flags.GetDuration("lookup-cache-ttl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lookup cache TTL")
+	}
+	if ttl <= 0 {
+		return bchain, nil
+	}
+	negativeTTL, err := flags.GetDuration("lookup-cache-negative-ttl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lookup cache negative TTL")
+	}
+	return bc.NewCachingBlockchain(bchain, bc.CachingOptions{TTL: ttl, NegativeTTL: negativeTTL}), nil
+}
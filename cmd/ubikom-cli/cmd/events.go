@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/regnull/ubikom/events"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func init() {
+	eventsQueryCmd.Flags().String("log", "", "event log location, file:<path> or badger:<dir> (only while the server is stopped)")
+	eventsQueryCmd.Flags().StringSlice("type", nil, "event types to return, like ET_KEY_REGISTRATION, all if empty")
+	eventsQueryCmd.Flags().String("user", "", "return only the events for this user")
+	eventsQueryCmd.Flags().String("from", "", "return the events starting at this time, RFC3339")
+	eventsQueryCmd.Flags().String("to", "", "return the events before this time, RFC3339")
+	eventsQueryCmd.Flags().Int("limit", 0, "max number of events to return, 0 for no limit")
+	eventsCmd.AddCommand(eventsQueryCmd)
+
+	rootCmd.AddCommand(eventsCmd)
+}
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Work with event logs",
+	Long:  "Work with event logs",
+	Run: func(cmd *cobra.Command, args []string) {
+		log.Fatal().Msg("sub-command required (do 'ubikom-cli events --help' to see available commands)")
+	},
+}
+
+var eventsQueryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Query events",
+	Long:  "Print the events matching the query as JSON, one per line",
+	Run: func(cmd *cobra.Command, args []string) {
+		location, err := cmd.Flags().GetString("log")
+		if err != nil || location == "" {
+			log.Fatal().Err(err).Msg("--log must be specified")
+		}
+		q, err := getEventQuery(cmd.Flags())
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid query")
+		}
+
+		source, err := events.OpenSource(location)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to open event log")
+		}
+		defer source.Close()
+
+		res, err := source.Query(q)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to query events")
+		}
+		out := events.NewJSONSink(os.Stdout)
+		for _, event := range res {
+			err = out.Write(event)
+			if err != nil {
+				log.Fatal().Err(err).Msg("failed to print event")
+			}
+		}
+	},
+}
+
+func getEventQuery(flags *pflag.FlagSet) (*events.Query, error) {
+	q := &events.Query{}
+	types, err := flags.GetStringSlice("type")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event types")
+	}
+	for _, t := range types {
+		eventType, err := events.ParseEventType(t)
+		if err != nil {
+			return nil, err
+		}
+		q.Types = append(q.Types, eventType)
+	}
+	q.User, err = flags.GetString("user")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user")
+	}
+	q.From, err = getTimeFlag(flags, "from")
+	if err != nil {
+		return nil, err
+	}
+	q.To, err = getTimeFlag(flags, "to")
+	if err != nil {
+		return nil, err
+	}
+	q.Limit, err = flags.GetInt("limit")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get limit")
+	}
+	return q, nil
+}
+
+// getTimeFlag parses RFC3339 time, the zero time is returned if the flag is not set.
+func getTimeFlag(flags *pflag.FlagSet, name string) (time.Time, error) {
+	s, err := flags.GetString(name)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get --%s", name)
+	}
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s: %w", name, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/regnull/ubikom/cmd/ubikom-cli/cmd/cmdutil"
+	"github.com/regnull/ubikom/pb"
+	"github.com/regnull/ubikom/protoutil"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	mailboxCmd.PersistentFlags().String("dump-service-url", "", "dump service url, use tls:// prefix for TLS")
+	mailboxCmd.PersistentFlags().String("tls-ca-file", "", "CA certificate used to verify the dump server, system CAs if empty")
+	mailboxCmd.PersistentFlags().String("tls-cert-file", "", "client certificate for mutual TLS")
+	mailboxCmd.PersistentFlags().String("tls-key-file", "", "client certificate key for mutual TLS")
+	addIdentityProofFlags(mailboxCmd)
+
+	mailboxSetPolicyCmd.Flags().String("key", "", "Location of the private key file")
+	mailboxSetPolicyCmd.Flags().StringSlice("allow", nil, "senders who are always accepted")
+	mailboxSetPolicyCmd.Flags().StringSlice("block", nil, "senders who are always rejected")
+	mailboxSetPolicyCmd.Flags().String("strangers", "allow", "what to do with other senders: allow, reject or pow")
+	mailboxSetPolicyCmd.Flags().Int("pow-strength", 0, "proof of work strength required from other senders, with --strangers=pow")
+	mailboxCmd.AddCommand(mailboxSetPolicyCmd)
+
+	rootCmd.AddCommand(mailboxCmd)
+}
+
+var mailboxCmd = &cobra.Command{
+	Use:   "mailbox",
+	Short: "Manage the mailbox on the dump server",
+	Long:  "Manage the mailbox on the dump server",
+	Run: func(cmd *cobra.Command, args []string) {
+		log.Fatal().Msg("sub-command required (do 'ubikom-cli mailbox --help' to see available commands)")
+	},
+}
+
+var mailboxSetPolicyCmd = &cobra.Command{
+	Use:   "set-policy",
+	Short: "Set mailbox policy",
+	Long:  "Set the policy which controls who can send messages to this mailbox, replacing the existing one",
+	Run: func(cmd *cobra.Command, args []string) {
+		privateKey, err := cmdutil.LoadKeyFromFlag(cmd, "key")
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to load encryption key")
+		}
+
+		allow, err := cmd.Flags().GetStringSlice("allow")
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to get allow list")
+		}
+		block, err := cmd.Flags().GetStringSlice("block")
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to get block list")
+		}
+		strangersStr, err := cmd.Flags().GetString("strangers")
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to get strangers policy")
+		}
+		strangers, err := parseStrangerPolicy(strangersStr)
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid strangers policy")
+		}
+		powStrength, err := cmd.Flags().GetInt("pow-strength")
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to get proof of work strength")
+		}
+
+		policy, err := protoutil.CreateMailboxPolicy(privateKey, &pb.MailboxPolicy{
+			Allow:       allow,
+			Block:       block,
+			Strangers:   strangers,
+			PowStrength: int32(powStrength),
+		}, time.Now())
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to create mailbox policy")
+		}
+
+		dumpConn, err := dialDumpServer(cmd)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to connect to the dump server")
+		}
+		defer dumpConn.Close()
+
+		identityOpts, err := getIdentityProofOptions(cmd)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to get identity proof options")
+		}
+
+		ctx := context.Background()
+		client := pb.NewDMSDumpServiceClient(dumpConn)
+		signed, err := protoutil.IdentityProofForServer(ctx, client, privateKey,
+			protoutil.IdentityOperationSetPolicy, identityOpts)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to create identity proof")
+		}
+		_, err = client.SetMailboxPolicy(ctx, &pb.SetMailboxPolicyRequest{
+			IdentityProof: signed,
+			CryptoContext: &pb.CryptoContext{
+				EllipticCurve: protoutil.CurveToProto(privateKey.Curve()),
+				EcdhVersion:   2,
+				EcdsaVersion:  1,
+			},
+			Policy: policy,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to set mailbox policy")
+		}
+		log.Info().Msg("mailbox policy updated")
+	},
+}
+
+func parseStrangerPolicy(s string) (pb.StrangerPolicy, error) {
+	switch s {
+	case "allow":
+		return pb.StrangerPolicy_SP_ALLOW, nil
+	case "reject":
+		return pb.StrangerPolicy_SP_REJECT, nil
+	case "pow":
+		return pb.StrangerPolicy_SP_REQUIRE_POW, nil
+	}
+	return pb.StrangerPolicy_SP_ALLOW, fmt.Errorf("unknown policy %q", s)
+}
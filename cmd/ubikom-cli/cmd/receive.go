@@ -5,25 +5,34 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/regnull/easyecc/v2"
 	"github.com/regnull/ubikom/bc"
 	"github.com/regnull/ubikom/cmd/ubikom-cli/cmd/cmdutil"
 	"github.com/regnull/ubikom/pb"
 	"github.com/regnull/ubikom/protoutil"
+	"github.com/regnull/ubikom/util"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 )
 
 func init() {
 	receiveCmd.PersistentFlags().String("network", "main", "mode, either live or prod")
 	receiveCmd.PersistentFlags().String("node-url", "", "blockchain node location")
 	receiveCmd.PersistentFlags().String("contract-address", "", "registry contract address")
-	receiveCmd.PersistentFlags().String("dump-service-url", "", "dump service url")
+	receiveCmd.PersistentFlags().String("dump-service-url", "", "dump service url, use tls:// prefix for TLS")
+	receiveCmd.PersistentFlags().String("tls-ca-file", "", "CA certificate used to verify the dump server, system CAs if empty")
+	receiveCmd.PersistentFlags().String("tls-cert-file", "", "client certificate for mutual TLS")
+	receiveCmd.PersistentFlags().String("tls-key-file", "", "client certificate key for mutual TLS")
+	addIdentityProofFlags(receiveCmd)
 
 	receiveMessageCmd.Flags().String("key", "", "Location of the private key file")
+	receiveMessageCmd.Flags().Bool("send-receipt", false, "send delivery receipt to the sender")
 	receiveCmd.AddCommand(receiveMessageCmd)
 
+	receiveReceiptsCmd.Flags().String("key", "", "Location of the private key file")
+	receiveCmd.AddCommand(receiveReceiptsCmd)
+
 	rootCmd.AddCommand(receiveCmd)
 }
 
@@ -40,82 +49,219 @@ var receiveMessageCmd = &cobra.Command{
 	Short: "Receive message",
 	Long:  "Receive message",
 	Run: func(cmd *cobra.Command, args []string) {
-		dumpURL, err := cmd.Flags().GetString("dump-service-url")
-		if err != nil {
-			log.Fatal().Err(err).Msg("failed to get dump server URL")
-		}
-		if dumpURL == "" {
-			log.Fatal().Msg("--dump-service-url must be specified")
-		}
-
-		nodeURL, err := cmdutil.GetNodeURL(cmd.Flags())
+		bchain, err := getBlockchain(cmd)
 		if err != nil {
-			log.Fatal().Err(err).Msg("failed to get node URL")
-		}
-		log.Debug().Str("node-url", nodeURL).Msg("using node")
-		contractAddress, err := cmdutil.GetContractAddress(cmd.Flags())
-		if err != nil {
-			log.Fatal().Err(err).Msg("failed to load contract address")
+			log.Fatal().Err(err).Msg("failed to create lookup service")
 		}
-		log.Debug().Str("contract-address", contractAddress).Msg("using contract addresss")
 
 		privateKey, err := cmdutil.LoadKeyFromFlag(cmd, "key")
 		if err != nil {
 			log.Fatal().Err(err).Msg("failed to load encryption key")
 		}
-		opts := []grpc.DialOption{
-			grpc.WithInsecure(),
-			grpc.WithBlock(),
-			grpc.WithTimeout(time.Second * 5),
+
+		sendReceipt, err := cmd.Flags().GetBool("send-receipt")
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to get send receipt flag")
 		}
 
-		dumpConn, err := grpc.Dial(dumpURL, opts...)
+		dumpConn, err := dialDumpServer(cmd)
 		if err != nil {
 			log.Fatal().Err(err).Msg("failed to connect to the dump server")
 		}
 		defer dumpConn.Close()
 
-		signed, err := protoutil.IdentityProof(privateKey, time.Now())
+		identityOpts, err := getIdentityProofOptions(cmd)
 		if err != nil {
-			log.Fatal().Err(err).Msg("failed to create identity proof")
+			log.Fatal().Err(err).Msg("failed to get identity proof options")
 		}
 
 		ctx := context.Background()
 		client := pb.NewDMSDumpServiceClient(dumpConn)
-		res, err := client.Receive(ctx, &pb.ReceiveRequest{
-			IdentityProof: signed,
-			CryptoContext: &pb.CryptoContext{
-				EllipticCurve: protoutil.CurveToProto(privateKey.Curve()),
-				EcdhVersion:   2,
-				EcdsaVersion:  1,
-			},
-		})
+		// Messages that can't be read are dropped, the next one is received instead.
+		received, err := protoutil.ReceiveMessage(ctx, client, bchain, privateKey, identityOpts)
 		if err != nil {
 			log.Fatal().Err(err).Msg("failed to receive message")
 		}
-		msg := res.GetMessage()
+		msg := received.Message
+		if received.Receipt != nil {
+			printDeliveryReceipt(received.Receipt)
+		} else {
+			fmt.Printf("%s\n", received.Content)
+		}
 
-		bchain, err := bc.NewBlockchain(nodeURL, contractAddress)
+		if received.DeliveryID != "" {
+			err = protoutil.AckMessages(ctx, client, privateKey, identityOpts, []string{received.DeliveryID})
+			if err != nil {
+				log.Fatal().Err(err).Msg("failed to acknowledge message")
+			}
+		}
+		// Old servers don't use delivery IDs, the message is already removed.
+
+		if sendReceipt && msg.GetType() != pb.MessageType_MT_DELIVERY_RECEIPT {
+			err = protoutil.SendDeliveryReceipt(ctx, protoutil.NewDumpServiceClientFactory(), bchain,
+				privateKey, msg)
+			if err != nil {
+				log.Fatal().Err(err).Msg("failed to send delivery receipt")
+			}
+		}
+	},
+}
+
+var receiveReceiptsCmd = &cobra.Command{
+	Use:   "receipts",
+	Short: "Receive delivery receipts",
+	Long:  "Receive and verify delivery receipts, other messages are left in the mailbox",
+	Run: func(cmd *cobra.Command, args []string) {
+		bchain, err := getBlockchain(cmd)
 		if err != nil {
 			log.Fatal().Err(err).Msg("failed to create lookup service")
 		}
-		curve := protoutil.CurveFromProto(msg.CryptoContext.GetEllipticCurve())
-		if curve == easyecc.INVALID_CURVE {
-			log.Fatal().Msg("invalid curve")
+
+		privateKey, err := cmdutil.LoadKeyFromFlag(cmd, "key")
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to load encryption key")
+		}
+
+		dumpConn, err := dialDumpServer(cmd)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to connect to the dump server")
 		}
-		senderKey, err := bchain.PublicKeyByCurve(ctx, msg.GetSender(), curve)
+		defer dumpConn.Close()
+
+		identityOpts, err := getIdentityProofOptions(cmd)
 		if err != nil {
-			log.Fatal().Err(err).Msg("invalid sender public key")
+			log.Fatal().Err(err).Msg("failed to get identity proof options")
+		}
+
+		ctx := context.Background()
+		client := pb.NewDMSDumpServiceClient(dumpConn)
+		cryptoContext := &pb.CryptoContext{
+			EllipticCurve: protoutil.CurveToProto(privateKey.Curve()),
+			EcdhVersion:   2,
+			EcdsaVersion:  1,
 		}
 
-		if !protoutil.VerifySignature(msg.GetSignature(), senderKey, msg.GetContent()) {
-			log.Fatal().Msg("signature verification failed")
+		// Lease all the messages, so that each one is seen once. The regular messages
+		// are released at the end.
+		var receiptIDs, otherIDs []string
+		for {
+			signed, err := protoutil.IdentityProofForServer(ctx, client, privateKey,
+				protoutil.IdentityOperationReceive, identityOpts)
+			if err != nil {
+				log.Fatal().Err(err).Msg("failed to create identity proof")
+			}
+			res, err := client.Receive(ctx, &pb.ReceiveRequest{
+				IdentityProof: signed,
+				CryptoContext: cryptoContext,
+				RequireAck:    true,
+			})
+			if util.ErrEqualCode(err, codes.NotFound) {
+				break
+			}
+			if err != nil {
+				log.Fatal().Err(err).Msg("failed to receive message")
+			}
+			if res.GetDeliveryId() == "" {
+				log.Fatal().Msg("dump server doesn't support acknowledgements")
+			}
+			if res.GetMessage().GetType() != pb.MessageType_MT_DELIVERY_RECEIPT {
+				otherIDs = append(otherIDs, res.GetDeliveryId())
+				continue
+			}
+			receipt, err := protoutil.VerifyDeliveryReceipt(ctx, bchain, res.GetMessage())
+			if err != nil {
+				log.Warn().Err(err).Msg("invalid delivery receipt")
+			} else {
+				printDeliveryReceipt(receipt)
+			}
+			receiptIDs = append(receiptIDs, res.GetDeliveryId())
 		}
 
-		content, err := privateKey.Decrypt(msg.Content, senderKey)
+		if len(otherIDs) > 0 {
+			err = protoutil.ReleaseMessages(ctx, client, privateKey, identityOpts, otherIDs)
+			if err != nil {
+				// They become available again after the visibility timeout anyway.
+				log.Warn().Err(err).Msg("failed to release messages")
+			}
+		}
+		if len(receiptIDs) == 0 {
+			fmt.Printf("no delivery receipts\n")
+			return
+		}
+		err = protoutil.AckMessages(ctx, client, privateKey, identityOpts, receiptIDs)
 		if err != nil {
-			log.Fatal().Msg("failed to decode message")
+			log.Fatal().Err(err).Msg("failed to acknowledge receipts")
 		}
-		fmt.Printf("%s\n", content)
 	},
 }
+
+func getBlockchain(cmd *cobra.Command) (bc.Blockchain, error) {
+	nodeURL, err := cmdutil.GetNodeURL(cmd.Flags())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node URL: %w", err)
+	}
+	log.Debug().Str("node-url", nodeURL).Msg("using node")
+	contractAddress, err := cmdutil.GetContractAddress(cmd.Flags())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load contract address: %w", err)
+	}
+	log.Debug().Str("contract-address", contractAddress).Msg("using contract addresss")
+	return cmdutil.NewBlockchain(cmd.Flags(), nodeURL, contractAddress)
+}
+
+func dialDumpServer(cmd *cobra.Command) (*grpc.ClientConn, error) {
+	dumpURL, err := cmd.Flags().GetString("dump-service-url")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dump server URL: %w", err)
+	}
+	if dumpURL == "" {
+		return nil, fmt.Errorf("--dump-service-url must be specified")
+	}
+	factoryOpts, err := cmdutil.GetDumpServiceClientFactoryOptions(cmd.Flags())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS configuration: %w", err)
+	}
+	target, creds, err := protoutil.DumpServiceCredentials(dumpURL, factoryOpts)
+	if err != nil {
+		return nil, err
+	}
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithBlock(),
+		grpc.WithTimeout(time.Second * 5),
+	}
+	return grpc.Dial(target, opts...)
+}
+
+// addIdentityProofFlags adds the flags used by getIdentityProofOptions.
+func addIdentityProofFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String("server-id", "", "dump server identity, the dump service url without the scheme if empty")
+	cmd.PersistentFlags().Bool("allow-legacy-identity", false, "use the legacy identity proof if the dump server doesn't issue challenges")
+}
+
+// getIdentityProofOptions returns the options for the identity proofs sent to the dump
+// server. The server identity (audience) is never taken from the server itself.
+func getIdentityProofOptions(cmd *cobra.Command) (protoutil.IdentityProofOptions, error) {
+	serverID, err := cmd.Flags().GetString("server-id")
+	if err != nil {
+		return protoutil.IdentityProofOptions{}, fmt.Errorf("failed to get server ID: %w", err)
+	}
+	if serverID == "" {
+		dumpURL, err := cmd.Flags().GetString("dump-service-url")
+		if err != nil {
+			return protoutil.IdentityProofOptions{}, fmt.Errorf("failed to get dump server URL: %w", err)
+		}
+		serverID = protoutil.EndpointAudience(dumpURL)
+	}
+	allowLegacy, err := cmd.Flags().GetBool("allow-legacy-identity")
+	if err != nil {
+		return protoutil.IdentityProofOptions{}, fmt.Errorf("failed to get allow legacy identity flag: %w", err)
+	}
+	return protoutil.IdentityProofOptions{Audience: serverID, AllowLegacy: allowLegacy}, nil
+}
+
+func printDeliveryReceipt(receipt *pb.DeliveryReceipt) {
+	fmt.Printf("message %x from %s was received by %s at %s\n", receipt.GetMessageHash(),
+		receipt.GetSender(), receipt.GetReceiver(),
+		time.Unix(receipt.GetTimestamp(), 0).Format(time.RFC3339))
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"os"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -24,6 +25,9 @@ func init() {
 	rootCmd.PersistentFlags().String("contract-address", "", "registry contract address")
 	rootCmd.PersistentFlags().Uint64("gas-price", 0, "gas price")
 	rootCmd.PersistentFlags().Uint64("gas-limit", 0, "gas limit")
+	rootCmd.PersistentFlags().String("key-registry-url", "", "identity registry server used to check if the sender's key is disabled")
+	rootCmd.PersistentFlags().Duration("lookup-cache-ttl", 0, "how long to cache name lookups, 0 to disable")
+	rootCmd.PersistentFlags().Duration("lookup-cache-negative-ttl", 30*time.Second, "how long to cache lookups of unregistered names")
 }
 
 func Execute() {
@@ -6,8 +6,8 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
-	"github.com/regnull/ubikom/bc"
 	"github.com/regnull/ubikom/cmd/ubikom-cli/cmd/cmdutil"
 	"github.com/regnull/ubikom/protoutil"
 	"github.com/rs/zerolog/log"
@@ -19,9 +19,11 @@ func init() {
 	sendCmd.PersistentFlags().String("node-url", "", "blockchain node location")
 	sendCmd.PersistentFlags().String("contract-address", "", "registry contract address")
 
-	sendMessageCmd.Flags().String("receiver", "", "receiver's address")
+	sendMessageCmd.Flags().StringSlice("receiver", nil, "receiver's address, comma-separated for multiple receivers")
 	sendMessageCmd.Flags().String("sender", "", "sender's address")
 	sendMessageCmd.Flags().String("key", "", "Location for the private key file")
+	sendMessageCmd.Flags().Duration("deliver-after", 0, "delay the delivery by this duration")
+	sendMessageCmd.Flags().Duration("expires-in", 0, "remove the message if it's not received within this duration")
 	sendCmd.AddCommand(sendMessageCmd)
 
 	rootCmd.AddCommand(sendCmd)
@@ -61,14 +63,31 @@ var sendMessageCmd = &cobra.Command{
 			log.Fatal().Err(err).Msg("sender's address must be specified")
 		}
 
-		receiver, err := cmd.Flags().GetString("receiver")
-		if err != nil || receiver == "" {
+		receivers, err := cmd.Flags().GetStringSlice("receiver")
+		if err != nil || len(receivers) == 0 {
 			log.Fatal().Err(err).Msg("receiver's address must be specified")
 		}
 
+		deliverAfter, err := cmd.Flags().GetDuration("deliver-after")
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to get deliver after flag")
+		}
+		expiresIn, err := cmd.Flags().GetDuration("expires-in")
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to get expires in flag")
+		}
+		var opts protoutil.MessageOptions
+		now := time.Now()
+		if deliverAfter > 0 {
+			opts.NotBefore = now.Add(deliverAfter)
+		}
+		if expiresIn > 0 {
+			opts.Expires = now.Add(expiresIn)
+		}
+
 		ctx := context.Background()
 
-		bchain, err := bc.NewBlockchain(nodeURL, contractAddress)
+		bchain, err := cmdutil.NewBlockchain(cmd.Flags(), nodeURL, contractAddress)
 		if err != nil {
 			log.Fatal().Err(err).Msg("failed to create lookup service")
 		}
@@ -88,7 +107,13 @@ var sendMessageCmd = &cobra.Command{
 		}
 		body := strings.Join(lines, "\n")
 
-		err = protoutil.SendMessage(ctx, privateKey, []byte(body), sender, receiver, bchain)
+		messageSender := protoutil.NewMessageSender(protoutil.NewDumpServiceClientFactory(), bchain)
+		if len(receivers) == 1 {
+			err = messageSender.SendWithOptions(ctx, privateKey, []byte(body), sender, receivers[0], opts)
+		} else {
+			// The body is encrypted once for all the receivers.
+			err = messageSender.SendToMany(ctx, privateKey, []byte(body), sender, receivers, opts)
+		}
 		if err != nil {
 			log.Fatal().Err(err).Msg("failed to send message")
 		}
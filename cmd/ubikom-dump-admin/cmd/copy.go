@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/regnull/ubikom/store"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	addStoreFlags(copyCmd.Flags(), "from-", "source")
+	addStoreFlags(copyCmd.Flags(), "to-", "destination")
+	rootCmd.AddCommand(copyCmd)
+}
+
+var copyCmd = &cobra.Command{
+	Use:   "copy",
+	Short: "Copy one store into another",
+	Long:  "Copy all the messages and the mailbox policies from one store into another, like export followed by import",
+	Run: func(cmd *cobra.Command, args []string) {
+		src, err := openStore(cmd.Flags(), "from-")
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to open source store")
+		}
+		defer src.Close()
+		dst, err := openStore(cmd.Flags(), "to-")
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to open destination store")
+		}
+		defer dst.Close()
+
+		stats, err := store.Copy(context.Background(), src, dst)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to copy store")
+		}
+		log.Info().Int("messages", stats.Messages).Int("policies", stats.Policies).
+			Msg("store copied")
+	},
+}
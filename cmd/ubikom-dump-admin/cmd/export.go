@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"os"
+
+	"github.com/regnull/ubikom/store"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	addStoreFlags(exportCmd.Flags(), "", "source")
+	exportCmd.Flags().String("out", "", "export file")
+	rootCmd.AddCommand(exportCmd)
+
+	verifyCmd.Flags().String("in", "", "export file")
+	rootCmd.AddCommand(verifyCmd)
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the store",
+	Long:  "Export all the messages and the mailbox policies to a file, which can be imported into any store",
+	Run: func(cmd *cobra.Command, args []string) {
+		fileName, err := cmd.Flags().GetString("out")
+		if err != nil || fileName == "" {
+			log.Fatal().Err(err).Msg("--out must be specified")
+		}
+		str, err := openStore(cmd.Flags(), "")
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to open store")
+		}
+		defer str.Close()
+
+		file, err := os.OpenFile(fileName, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to create export file")
+		}
+		w := bufio.NewWriter(file)
+		stats, err := store.Export(context.Background(), str, w)
+		if err == nil {
+			err = w.Flush()
+		}
+		if err == nil {
+			err = file.Close()
+		}
+		if err != nil {
+			file.Close()
+			os.Remove(fileName)
+			log.Fatal().Err(err).Msg("failed to export store")
+		}
+		log.Info().Int("messages", stats.Messages).Int("policies", stats.Policies).
+			Str("file", fileName).Msg("store exported")
+	},
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify the export file",
+	Long:  "Check the export file against its checksum, the store is not needed",
+	Run: func(cmd *cobra.Command, args []string) {
+		fileName, err := cmd.Flags().GetString("in")
+		if err != nil || fileName == "" {
+			log.Fatal().Err(err).Msg("--in must be specified")
+		}
+		stats, err := verifyExportFile(fileName)
+		if err != nil {
+			log.Fatal().Err(err).Msg("export file is invalid")
+		}
+		log.Info().Int("messages", stats.Messages).Int("policies", stats.Policies).
+			Str("file", fileName).Msg("export file is valid")
+	},
+}
+
+func verifyExportFile(fileName string) (*store.ExportStats, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return store.VerifyExport(bufio.NewReader(file))
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"os"
+
+	"github.com/regnull/ubikom/store"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	addStoreFlags(importCmd.Flags(), "", "destination")
+	importCmd.Flags().String("in", "", "export file")
+	rootCmd.AddCommand(importCmd)
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import the export file into the store",
+	Long: `Import the messages and the mailbox policies from the export file. The file is
+verified before anything is imported. The messages which are already in the store
+are skipped, and the policies are replaced.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fileName, err := cmd.Flags().GetString("in")
+		if err != nil || fileName == "" {
+			log.Fatal().Err(err).Msg("--in must be specified")
+		}
+		_, err = verifyExportFile(fileName)
+		if err != nil {
+			log.Fatal().Err(err).Msg("export file is invalid")
+		}
+
+		str, err := openStore(cmd.Flags(), "")
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to open store")
+		}
+		defer str.Close()
+
+		file, err := os.Open(fileName)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to open export file")
+		}
+		defer file.Close()
+		stats, err := store.Import(context.Background(), str, bufio.NewReader(file))
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to import store")
+		}
+		log.Info().Int("messages", stats.Messages).Int("policies", stats.Policies).
+			Msg("store imported")
+	},
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/regnull/ubikom/store"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "ubikom-dump-admin",
+	Short: "ubikom-dump-admin manages the dump server data",
+	Long: `ubikom-dump-admin exports, imports and copies the dump server message stores.
+The dump server must be stopped while its store is used.`,
+	Run: func(cmd *cobra.Command, args []string) {
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().Duration("max-message-age", 24*14*time.Hour, "max message age, 0 to never expire, must match the dump server")
+}
+
+func Execute() {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: "15:04:05"})
+
+	cmd, _, err := rootCmd.Find(os.Args[1:])
+	// If no command is given, show help.
+	if err == nil && cmd.Use == rootCmd.Use && cmd.Flags().Parse(os.Args[1:]) != pflag.ErrHelp {
+		args := append([]string{"help"}, os.Args[1:]...)
+		rootCmd.SetArgs(args)
+	}
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Error().Err(err).Msg("error executing command")
+		os.Exit(1)
+	}
+}
+
+// openStore opens the store configured by the flags with the given prefix.
+func openStore(flags *pflag.FlagSet, prefix string) (store.AdminStore, error) {
+	kind, err := flags.GetString(prefix + "store")
+	if err != nil {
+		return nil, err
+	}
+	dataDir, err := flags.GetString(prefix + "data-dir")
+	if err != nil {
+		return nil, err
+	}
+	if dataDir == "" {
+		return nil, fmt.Errorf("--%sdata-dir must be specified", prefix)
+	}
+	ttl, err := flags.GetDuration("max-message-age")
+	if err != nil {
+		return nil, err
+	}
+	return store.Open(kind, os.ExpandEnv(dataDir), ttl)
+}
+
+// addStoreFlags adds the flags used by openStore.
+func addStoreFlags(flags *pflag.FlagSet, prefix string, usage string) {
+	flags.String(prefix+"store", "badger", usage+" store: badger, sqlite or file")
+	flags.String(prefix+"data-dir", "", usage+" store data directory")
+}
@@ -0,0 +1,9 @@
+package main
+
+import (
+	"github.com/regnull/ubikom/cmd/ubikom-dump-admin/cmd"
+)
+
+func main() {
+	cmd.Execute()
+}
@@ -1,27 +1,80 @@
 package main
 
 import (
+	"context"
+	"encoding/hex"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/regnull/ubikom/bc"
 	"github.com/regnull/ubikom/cfg"
+	"github.com/regnull/ubikom/events"
+	"github.com/regnull/ubikom/metrics"
 	"github.com/regnull/ubikom/pb"
+	"github.com/regnull/ubikom/protoutil"
 	"github.com/regnull/ubikom/server"
 	"github.com/regnull/ubikom/store"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
+// dumpServiceName is the full name of DMSDumpService, as reported by the health service.
+const dumpServiceName = "Ubikom.DMSDumpService"
+
 func main() {
 	err := cfg.InitConfig([]cfg.ConfigEntry{
 		cfg.NewIntConfig("port", 8826, "port to listen to", ""),
 		cfg.NewStringConfig("data-dir", "$HOME/.ubikom/dump", "data directory", ""),
-		cfg.NewIntConfig("max-message-age-hours", 24*14, "max message age in hours", ""),
+		cfg.NewStringConfig("store", "badger", "message store: badger, sqlite, file or memory", ""),
+		cfg.NewIntConfig("memory-max-messages", 0, "max number of messages kept by the memory store, 0 for no limit", ""),
+		cfg.NewIntConfig("memory-max-bytes", 0, "max total size of messages kept by the memory store, 0 for no limit", ""),
+		cfg.NewIntConfig("memory-max-mailbox-messages", 0, "max number of messages per receiver kept by the memory store, 0 for no limit", ""),
+		cfg.NewStringConfig("master-key-file", "", "file with the hex-encoded key used to encrypt the stored data, empty to disable", ""),
+		cfg.NewIntConfig("max-message-age-hours", 24*14, "max message age in hours, 0 to never expire", ""),
+		cfg.NewIntConfig("visibility-timeout-seconds", 300, "how long unacknowledged messages stay hidden", ""),
+		cfg.NewIntConfig("dedup-window-seconds", 3600, "how long to remember message IDs to detect retried sends", ""),
+		cfg.NewIntConfig("pow-strength", 0, "proof of work strength required to send messages, 0 to disable", ""),
+		cfg.NewIntConfig("max-message-size", 0, "max message size in bytes, 0 for no limit", ""),
+		cfg.NewIntConfig("max-mailbox-messages", 0, "max number of messages per receiver, 0 for no limit", ""),
+		cfg.NewIntConfig("max-mailbox-bytes", 0, "max total size of messages per receiver, 0 for no limit", ""),
+		cfg.NewStringConfig("server-id", "", "server identity, used to verify identity proofs", ""),
+		cfg.NewStringConfig("identity-policy", "legacy", "identity proof policy: strict, legacy or legacy-metrics", ""),
+		cfg.NewIntConfig("max-clock-skew-seconds", 10, "max allowed identity proof clock skew in seconds", ""),
+		cfg.NewBoolConfig("require-identity-challenge", false, "require identity proofs to use server-issued challenges", ""),
+		cfg.NewStringConfig("tls-cert-file", "", "TLS certificate file, plaintext if empty", ""),
+		cfg.NewStringConfig("tls-key-file", "", "TLS key file", ""),
+		cfg.NewStringConfig("tls-client-ca-file", "", "CA certificate used to verify client certificates, optional", ""),
+		cfg.NewBoolConfig("relay", false, "relay messages for receivers hosted elsewhere", ""),
+		cfg.NewStringConfig("local-endpoints", "", "comma-separated endpoints served by this server, required for relay", ""),
+		cfg.NewIntConfig("relay-workers", 4, "number of messages relayed concurrently", ""),
+		cfg.NewIntConfig("relay-max-attempts", 20, "number of relay attempts before the message is dropped", ""),
+		cfg.NewIntConfig("relay-max-backoff-seconds", 3600, "max delay between relay attempts in seconds", ""),
+		cfg.NewIntConfig("relay-max-messages", 10000, "max number of messages waiting to be relayed", ""),
+		cfg.NewIntConfig("relay-max-bytes", 256*1024*1024, "max total size of messages waiting to be relayed", ""),
+		cfg.NewStringConfig("relay-data-dir", "$HOME/.ubikom/dump-relay", "data directory for the relay queue", ""),
+		cfg.NewIntConfig("metrics-port", 0, "port to serve Prometheus metrics on, 0 to disable", ""),
+		cfg.NewIntConfig("shutdown-timeout-seconds", 30, "how long to wait for the requests to finish on shutdown", ""),
+		cfg.NewStringConfig("key-registry-url", "", "identity registry server used to check if the sender's key is disabled, optional", ""),
+		cfg.NewIntConfig("lookup-cache-ttl-seconds", 0, "how long to cache name lookups in seconds, 0 to disable", ""),
+		cfg.NewIntConfig("lookup-cache-negative-ttl-seconds", 30, "how long to cache lookups of unregistered names in seconds", ""),
+		cfg.NewIntConfig("lookup-cache-key-status-ttl-seconds", 30, "how long to cache the key status in seconds", ""),
+		cfg.NewStringConfig("event-log", "", "where to write events: stdout, file:<path> or badger:<dir>, disabled if empty", ""),
+		cfg.NewBoolConfig("event-log-names", false, "include the sender and the receiver names in the message events", ""),
+		cfg.NewStringConfig("admin-keys", "", "comma-separated hex-encoded operator public keys, enables the admin service", ""),
 		cfg.NewStringConfig("network", "main", "ethereum network to use", "UBK_NETWORK"),
 		cfg.NewStringConfig("infura-project-id", "", "infura project id", "INFURA_PROJECT_ID"),
 		cfg.NewStringConfig("contract-address", "", "contract address", "UBK_CONTRACT_ADDRESS"),
@@ -49,26 +102,259 @@ func main() {
 	dataDir := os.ExpandEnv(viper.GetString("data-dir"))
 	log.Info().Str("data-dir", dataDir).Msg("got data directory")
 
+	var serverMetrics *metrics.Metrics
+	var metricsServer *http.Server
+	if viper.GetInt("metrics-port") != 0 {
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+		serverMetrics = metrics.New(reg)
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler(reg))
+		metricsServer = &http.Server{
+			Addr:              fmt.Sprintf(":%d", viper.GetInt("metrics-port")),
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			err := metricsServer.ListenAndServe()
+			if err != nil && err != http.ErrServerClosed {
+				log.Fatal().Err(err).Msg("metrics server failed")
+			}
+		}()
+		log.Info().Int("port", viper.GetInt("metrics-port")).Msg("serving metrics")
+	}
+
 	lookupClient, err := getLookupService()
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to initialize lookup client")
 	}
+	if viper.GetString("key-registry-url") != "" {
+		conn, err := grpc.Dial(viper.GetString("key-registry-url"),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to connect to key registry")
+		}
+		defer conn.Close()
+		lookupClient = bc.NewBlockchainWithKeyRegistry(lookupClient, pb.NewLookupServiceClient(conn))
+		log.Info().Str("url", viper.GetString("key-registry-url")).Msg("checking key status")
+	}
+	lookupClient = metrics.NewBlockchain(lookupClient, serverMetrics)
+	if viper.GetInt("lookup-cache-ttl-seconds") > 0 {
+		lookupClient = bc.NewCachingBlockchain(lookupClient, bc.CachingOptions{
+			TTL:          time.Duration(viper.GetInt("lookup-cache-ttl-seconds")) * time.Second,
+			NegativeTTL:  time.Duration(viper.GetInt("lookup-cache-negative-ttl-seconds")) * time.Second,
+			KeyStatusTTL: time.Duration(viper.GetInt("lookup-cache-key-status-ttl-seconds")) * time.Second,
+		})
+		log.Info().Int("ttl-seconds", viper.GetInt("lookup-cache-ttl-seconds")).Msg("caching name lookups")
+	}
 
-	maxMessageAgeHours := viper.GetInt("max-message-age-hours")
-	dumpStore, err := store.NewBadger(dataDir, time.Duration(maxMessageAgeHours)*time.Hour)
+	messageStore, err := openMessageStore(dataDir)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to create data store")
 	}
-	dumpServer := server.NewDumpServer(dumpStore, lookupClient)
+	log.Info().Str("store", viper.GetString("store")).Msg("opened message store")
+	if viper.GetString("master-key-file") != "" {
+		log.Info().Msg("stored data is encrypted")
+	}
+	dumpStore := metrics.NewStore(messageStore, serverMetrics)
+	identityPolicy, err := server.ParseIdentityPolicy(viper.GetString("identity-policy"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid identity policy")
+	}
+	var eventSink events.Sink
+	if viper.GetString("event-log") != "" {
+		eventSink, err = events.Open(viper.GetString("event-log"))
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to open event log")
+		}
+		log.Info().Str("location", viper.GetString("event-log")).Msg("writing events")
+	}
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	relayDone := make(chan struct{})
+	var relay *server.Relay
+	var relayStore store.AdminStore
+	var localEndpoints []string
+	if viper.GetBool("relay") {
+		for _, endpoint := range strings.Split(viper.GetString("local-endpoints"), ",") {
+			if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+				localEndpoints = append(localEndpoints, endpoint)
+			}
+		}
+		if len(localEndpoints) == 0 {
+			// Otherwise the messages for the local receivers would be relayed to ourselves.
+			log.Fatal().Msg("--local-endpoints must be specified for relay")
+		}
+		if viper.GetString("store") == "memory" {
+			log.Fatal().Msg("relay requires a persistent --store, the relay queue would be lost on restart")
+		}
+		// The relay queue is kept apart from the mailboxes, so that it doesn't show up
+		// in the admin listings and the exports.
+		relayDataDir := os.ExpandEnv(viper.GetString("relay-data-dir"))
+		relayStore, err = openMessageStore(relayDataDir)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to create relay store")
+		}
+		log.Info().Str("relay-data-dir", relayDataDir).Msg("opened relay store")
+		relay = server.NewRelay(relayStore, lookupClient, protoutil.NewDumpServiceClientFactory(), server.RelayOptions{
+			Workers:     viper.GetInt("relay-workers"),
+			MaxAttempts: viper.GetInt("relay-max-attempts"),
+			MaxBackoff:  time.Duration(viper.GetInt("relay-max-backoff-seconds")) * time.Second,
+			MaxMessages: viper.GetInt("relay-max-messages"),
+			MaxBytes:    viper.GetInt64("relay-max-bytes"),
+			Events:      eventSink,
+			EventNames:  viper.GetBool("event-log-names"),
+		})
+		go func() {
+			defer close(relayDone)
+			relay.Run(relayCtx)
+		}()
+		log.Info().Strs("local-endpoints", localEndpoints).Msg("relay is enabled")
+	}
+	dumpServer := server.NewDumpServerWithOptions(dumpStore, lookupClient, server.DumpServerOptions{
+		VisibilityTimeout:  time.Duration(viper.GetInt("visibility-timeout-seconds")) * time.Second,
+		PowStrength:        viper.GetInt("pow-strength"),
+		MaxMessageSize:     viper.GetInt("max-message-size"),
+		MaxMailboxMessages: viper.GetInt("max-mailbox-messages"),
+		MaxMailboxBytes:    viper.GetInt64("max-mailbox-bytes"),
+		ServerID:           viper.GetString("server-id"),
+		IdentityPolicy:     identityPolicy,
+		MaxClockSkew:       time.Duration(viper.GetInt("max-clock-skew-seconds")) * time.Second,
+		RequireChallenge:   viper.GetBool("require-identity-challenge"),
+		DedupWindow:        time.Duration(viper.GetInt("dedup-window-seconds")) * time.Second,
+		Relay:              relay,
+		LocalEndpoints:     localEndpoints,
+		Metrics:            serverMetrics,
+		Events:             eventSink,
+		EventNames:         viper.GetBool("event-log-names"),
+	})
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", viper.GetInt("port")))
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to listen")
 	}
-	var opts []grpc.ServerOption
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(serverMetrics.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(serverMetrics.StreamServerInterceptor()),
+	}
+	if viper.GetString("tls-cert-file") != "" {
+		creds, err := server.NewTLSCredentials(viper.GetString("tls-cert-file"),
+			viper.GetString("tls-key-file"), viper.GetString("tls-client-ca-file"))
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to load TLS credentials")
+		}
+		opts = append(opts, grpc.Creds(creds))
+		log.Info().Bool("client-auth", viper.GetString("tls-client-ca-file") != "").Msg("using TLS")
+	} else if viper.GetString("tls-client-ca-file") != "" {
+		log.Fatal().Msg("--tls-client-ca-file requires --tls-cert-file")
+	}
 	grpcServer := grpc.NewServer(opts...)
 	pb.RegisterDMSDumpServiceServer(grpcServer, dumpServer)
+	if viper.GetString("admin-keys") != "" {
+		var operatorKeys [][]byte
+		for _, s := range strings.Split(viper.GetString("admin-keys"), ",") {
+			key, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), "0x"))
+			if err != nil {
+				log.Fatal().Err(err).Str("key", s).Msg("invalid admin key")
+			}
+			operatorKeys = append(operatorKeys, key)
+		}
+		// The admin server needs the store operations which the metrics wrapper doesn't have.
+		adminServer := server.NewAdminServer(messageStore, server.AdminServerOptions{
+			OperatorKeys: operatorKeys,
+			ServerID:     viper.GetString("server-id"),
+			MaxClockSkew: time.Duration(viper.GetInt("max-clock-skew-seconds")) * time.Second,
+		})
+		pb.RegisterDumpAdminServiceServer(grpcServer, adminServer)
+		log.Info().Int("operators", len(operatorKeys)).Msg("admin service is enabled")
+	}
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(dumpServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	reflection.Register(grpcServer)
+
+	go func() {
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGTERM, os.Interrupt)
+		sig := <-sigs
+		log.Info().Str("signal", sig.String()).Msg("shutting down")
+		healthServer.Shutdown()
+		dumpServer.Shutdown()
+		stopRelay()
+
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(time.Duration(viper.GetInt("shutdown-timeout-seconds")) * time.Second):
+			log.Warn().Msg("graceful shutdown timed out, stopping")
+			grpcServer.Stop()
+		}
+	}()
+
 	log.Info().Int("port", viper.GetInt("port")).Msg("server is up and running")
-	grpcServer.Serve(lis)
+	err = grpcServer.Serve(lis)
+	if err != nil {
+		log.Error().Err(err).Msg("server failed")
+	}
+
+	stopRelay()
+	if relay != nil {
+		<-relayDone
+		err = relayStore.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("failed to close relay store")
+		}
+	}
+	err = dumpStore.Close()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to close data store")
+	}
+	if eventSink != nil {
+		err = eventSink.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("failed to close event log")
+		}
+	}
+	if metricsServer != nil {
+		metricsServer.Close()
+	}
+	log.Info().Msg("server stopped")
+}
+
+// openMessageStore opens the message store configured by --store in the data
+// directory, encrypted if --master-key-file is set.
+func openMessageStore(dataDir string) (store.AdminStore, error) {
+	maxMessageAgeHours := viper.GetInt("max-message-age-hours")
+	var messageStore store.AdminStore
+	if viper.GetString("store") == "memory" {
+		// The oldest messages are evicted when the limits are reached.
+		messageStore = store.NewMemoryWithOptions(store.MemoryOptions{
+			TTL:                time.Duration(maxMessageAgeHours) * time.Hour,
+			MaxMailboxMessages: viper.GetInt("memory-max-mailbox-messages"),
+			MaxMessages:        viper.GetInt("memory-max-messages"),
+			MaxBytes:           viper.GetInt64("memory-max-bytes"),
+		})
+	} else {
+		var err error
+		messageStore, err = store.Open(viper.GetString("store"), dataDir, time.Duration(maxMessageAgeHours)*time.Hour)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if viper.GetString("master-key-file") == "" {
+		return messageStore, nil
+	}
+	masterKey, err := store.LoadMasterKey(os.ExpandEnv(viper.GetString("master-key-file")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load master key: %w", err)
+	}
+	encryptedStore, err := store.NewEncrypted(messageStore, masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create encrypted store: %w", err)
+	}
+	return encryptedStore, nil
 }
 
 func getLookupService() (bc.Blockchain, error) {
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/regnull/easyecc"
+	"github.com/regnull/ubikom/events"
 	"github.com/regnull/ubikom/pb"
 	"github.com/regnull/ubikom/util"
 	"github.com/rs/zerolog"
@@ -44,6 +45,7 @@ type CmdArgs struct {
 	Network                   string
 	InfuraProjectId           string
 	ContractAddress           string
+	EventLog                  string
 }
 
 type Server struct {
@@ -53,11 +55,12 @@ type Server struct {
 	notificationName      string
 	powStrength           int
 	rateLimiter           *rate.Limiter
+	eventSink             events.Sink
 }
 
 func NewServer(proxyManagementClient pb.ProxyServiceClient,
 	privateKey *easyecc.PrivateKey, name string, notificationName string, powStrength int,
-	rateLimitPerHour int) *Server {
+	rateLimitPerHour int, eventSink events.Sink) *Server {
 	return &Server{
 		proxyManagementClient: proxyManagementClient,
 		privateKey:            privateKey,
@@ -65,6 +68,19 @@ func NewServer(proxyManagementClient pb.ProxyServiceClient,
 		notificationName:      notificationName,
 		powStrength:           powStrength,
 		rateLimiter:           rate.NewLimiter(rate.Every(time.Hour), rateLimitPerHour),
+		eventSink:             eventSink,
+	}
+}
+
+func (s *Server) emitEvent(eventType pb.EventType, user string) {
+	if s.eventSink == nil {
+		return
+	}
+	event := events.New(eventType, "web")
+	event.User1 = user
+	err := s.eventSink.Write(event)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to write event")
 	}
 }
 
@@ -143,6 +159,7 @@ func (s *Server) HandleChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	log.Info().Msg("copy mailboxes request succeeded")
+	s.emitEvent(pb.EventType_ET_WEB_PASSWORD_CHANGED, req.Name)
 }
 
 type CheckMailboxKeyRequest struct {
@@ -228,6 +245,7 @@ func main() {
 	flag.StringVar(&args.Network, "network", defaultNetwork, "ethereum network to use")
 	flag.StringVar(&args.InfuraProjectId, "infura-project-id", "", "infura project id")
 	flag.StringVar(&args.ContractAddress, "contract-address", "", "name registry contract address")
+	flag.StringVar(&args.EventLog, "event-log", "", "where to write events: stdout, file:<path> or badger:<dir>")
 	flag.Parse()
 
 	ctx := context.Background()
@@ -263,8 +281,17 @@ func main() {
 		}
 	}
 
+	var eventSink events.Sink
+	if args.EventLog != "" {
+		eventSink, err = events.Open(args.EventLog)
+		if err != nil {
+			log.Fatal().Err(err).Str("location", args.EventLog).Msg("failed to open event log")
+		}
+		defer eventSink.Close()
+	}
+
 	server := NewServer(proxyManagementClient, privateKey, args.UbikomName,
-		args.NotificationName, args.PowStrength, args.RateLimitPerHour)
+		args.NotificationName, args.PowStrength, args.RateLimitPerHour, eventSink)
 
 	http.HandleFunc("/changePassword", server.HandleChangePassword)
 	http.HandleFunc("/check_mailbox_key", server.HandleCheckMailboxKey)
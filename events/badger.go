@@ -0,0 +1,89 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/regnull/ubikom/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+var badgerEventPrefix = []byte("event_")
+
+// Badger stores the events under event_<timestamp>_<id>, so that they are iterated
+// in the time order. It implements both Sink and Source.
+type Badger struct {
+	db *badger.DB
+}
+
+func NewBadger(dir string) (*Badger, error) {
+	db, err := badger.Open(badger.DefaultOptions(dir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event database: %w", err)
+	}
+	return &Badger{db: db}, nil
+}
+
+// NewBadgerSource opens the events in read-only mode. Badger allows only one process
+// to open the database for writing, so this fails while the server writes the events,
+// use the file log if the events must be read while the server is running.
+func NewBadgerSource(dir string) (*Badger, error) {
+	db, err := badger.Open(badger.DefaultOptions(dir).WithReadOnly(true).WithLogger(nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event database, it can't be read while the server is running: %w", err)
+	}
+	return &Badger{db: db}, nil
+}
+
+func (b *Badger) Write(event *pb.Event) error {
+	bb, err := proto.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to serialize event: %w", err)
+	}
+	return b.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(badgerEventKey(event.GetTimestamp(), event.GetId()), bb)
+	})
+}
+
+func (b *Badger) Query(q *Query) ([]*pb.Event, error) {
+	var res []*pb.Event
+	err := b.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		start := badgerEventPrefix
+		if !q.From.IsZero() {
+			start = badgerEventKey(uint64(q.From.UnixMilli()), "")
+		}
+		for it.Seek(start); it.ValidForPrefix(badgerEventPrefix); it.Next() {
+			if q.Limit > 0 && len(res) >= q.Limit {
+				break
+			}
+			event := &pb.Event{}
+			err := it.Item().Value(func(v []byte) error {
+				return proto.Unmarshal(v, event)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to read event: %w", err)
+			}
+			if !q.To.IsZero() && int64(event.GetTimestamp()) >= q.To.UnixMilli() {
+				break
+			}
+			if q.Match(event) {
+				res = append(res, event)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (b *Badger) Close() error {
+	return b.db.Close()
+}
+
+func badgerEventKey(timestamp uint64, id string) []byte {
+	return []byte(fmt.Sprintf("%s%016x_%s", badgerEventPrefix, timestamp, id))
+}
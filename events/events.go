@@ -0,0 +1,135 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/regnull/ubikom/pb"
+	"github.com/regnull/ubikom/util"
+)
+
+// Sink receives the events.
+type Sink interface {
+	Write(event *pb.Event) error
+	Close() error
+}
+
+// Source returns the stored events.
+type Source interface {
+	Query(q *Query) ([]*pb.Event, error)
+	Close() error
+}
+
+// Query selects the events. Zero fields match everything.
+type Query struct {
+	// Types are the event types to return.
+	Types []pb.EventType
+
+	// User must be either the first or the second user of the event.
+	User string
+
+	// From and To limit the event timestamps, From inclusive, To exclusive.
+	From time.Time
+	To   time.Time
+
+	// Limit is the maximum number of events to return.
+	Limit int
+}
+
+// Match returns true if the event matches the query.
+func (q *Query) Match(event *pb.Event) bool {
+	if len(q.Types) > 0 {
+		found := false
+		for _, t := range q.Types {
+			if t == event.GetEventType() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.User != "" && event.GetUser1() != q.User && event.GetUser2() != q.User {
+		return false
+	}
+	ts := int64(event.GetTimestamp())
+	if !q.From.IsZero() && ts < q.From.UnixMilli() {
+		return false
+	}
+	if !q.To.IsZero() && ts >= q.To.UnixMilli() {
+		return false
+	}
+	return true
+}
+
+// New creates a new event with a random ID and the current timestamp.
+func New(eventType pb.EventType, component string) *pb.Event {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return &pb.Event{
+		Id:        hex.EncodeToString(id),
+		Timestamp: uint64(util.NowMs()),
+		EventType: eventType,
+		Component: component,
+	}
+}
+
+// ParseEventType parses the event type name, like ET_KEY_REGISTRATION. The ET_ prefix
+// is optional, and the case doesn't matter.
+func ParseEventType(s string) (pb.EventType, error) {
+	name := strings.ToUpper(s)
+	if !strings.HasPrefix(name, "ET_") {
+		name = "ET_" + name
+	}
+	v, ok := pb.EventType_value[name]
+	if !ok {
+		return pb.EventType_ET_NONE, fmt.Errorf("invalid event type: %s", s)
+	}
+	return pb.EventType(v), nil
+}
+
+// Open creates the sink given its location:
+//
+//	stdout          - JSON, one event per line, to stdout
+//	file:<path>     - protoio-encoded events, appended to the file
+//	badger:<dir>    - events stored in Badger database
+func Open(location string) (Sink, error) {
+	if location == "stdout" {
+		return NewJSONSink(os.Stdout), nil
+	}
+	if strings.HasPrefix(location, "file:") {
+		sink, err := NewFileSink(strings.TrimPrefix(location, "file:"))
+		if err != nil {
+			return nil, err
+		}
+		return sink, nil
+	}
+	if strings.HasPrefix(location, "badger:") {
+		sink, err := NewBadger(strings.TrimPrefix(location, "badger:"))
+		if err != nil {
+			return nil, err
+		}
+		return sink, nil
+	}
+	return nil, fmt.Errorf("invalid event log location: %s", location)
+}
+
+// OpenSource opens the events written by the sink at the given location (see Open).
+func OpenSource(location string) (Source, error) {
+	if strings.HasPrefix(location, "file:") {
+		return NewFileSource(strings.TrimPrefix(location, "file:")), nil
+	}
+	if strings.HasPrefix(location, "badger:") {
+		source, err := NewBadgerSource(strings.TrimPrefix(location, "badger:"))
+		if err != nil {
+			return nil, err
+		}
+		return source, nil
+	}
+	return nil, fmt.Errorf("events can't be read from %s", location)
+}
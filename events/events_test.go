@@ -0,0 +1,152 @@
+package events
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/regnull/ubikom/pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func Test_ParseEventType(t *testing.T) {
+	assert := assert.New(t)
+
+	eventType, err := ParseEventType("ET_KEY_REGISTRATION")
+	assert.NoError(err)
+	assert.Equal(pb.EventType_ET_KEY_REGISTRATION, eventType)
+
+	eventType, err = ParseEventType("dump_message_stored")
+	assert.NoError(err)
+	assert.Equal(pb.EventType_ET_DUMP_MESSAGE_STORED, eventType)
+
+	_, err = ParseEventType("foo")
+	assert.Error(err)
+}
+
+func Test_Query_Match(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+	event := New(pb.EventType_ET_DUMP_MESSAGE_STORED, "dump")
+	event.Timestamp = uint64(now.UnixMilli())
+	event.User1 = "alice"
+	event.User2 = "bob"
+
+	assert.True((&Query{}).Match(event))
+	assert.True((&Query{User: "bob"}).Match(event))
+	assert.False((&Query{User: "charlie"}).Match(event))
+	assert.True((&Query{Types: []pb.EventType{pb.EventType_ET_KEY_REGISTRATION,
+		pb.EventType_ET_DUMP_MESSAGE_STORED}}).Match(event))
+	assert.False((&Query{Types: []pb.EventType{pb.EventType_ET_KEY_REGISTRATION}}).Match(event))
+	assert.True((&Query{From: now, To: now.Add(time.Second)}).Match(event))
+	assert.False((&Query{From: now.Add(time.Second)}).Match(event))
+	assert.False((&Query{To: now}).Match(event))
+}
+
+func Test_FileSink(t *testing.T) {
+	dir, err := os.MkdirTemp("", "ubikom_events_test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sink, err := Open("file:" + path.Join(dir, "events"))
+	assert.NoError(t, err)
+	source, err := OpenSource("file:" + path.Join(dir, "events"))
+	assert.NoError(t, err)
+	testSinkSource(t, sink, source)
+	assert.NoError(t, sink.Close())
+}
+
+func Test_BadgerSink(t *testing.T) {
+	dir, err := os.MkdirTemp("", "ubikom_events_test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sink, err := NewBadger(dir)
+	assert.NoError(t, err)
+	testSinkSource(t, sink, sink)
+
+	// The database is locked by the sink.
+	_, err = OpenSource("badger:" + dir)
+	assert.Error(t, err)
+	assert.NoError(t, sink.Close())
+
+	source, err := OpenSource("badger:" + dir)
+	assert.NoError(t, err)
+	res, err := source.Query(&Query{})
+	assert.NoError(t, err)
+	assert.Len(t, res, 10)
+	assert.NoError(t, source.Close())
+}
+
+func Test_JSONSink(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	sink := NewJSONSink(&buf)
+	event := New(pb.EventType_ET_WEB_PASSWORD_CHANGED, "web")
+	event.User1 = "alice"
+	assert.NoError(sink.Write(event))
+	assert.NoError(sink.Write(event))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(lines, 2)
+	event1 := &pb.Event{}
+	assert.NoError(protojson.Unmarshal([]byte(lines[0]), event1))
+	assert.Equal(event.GetId(), event1.GetId())
+	assert.Equal(pb.EventType_ET_WEB_PASSWORD_CHANGED, event1.GetEventType())
+	assert.Equal("alice", event1.GetUser1())
+}
+
+func testSinkSource(t *testing.T, sink Sink, source Source) {
+	assert := assert.New(t)
+
+	start := time.Now().Add(-time.Hour)
+	var written []*pb.Event
+	for i := 0; i < 10; i++ {
+		eventType := pb.EventType_ET_DUMP_MESSAGE_STORED
+		if i%2 == 1 {
+			eventType = pb.EventType_ET_DUMP_MAILBOX_POLICY_CHANGED
+		}
+		event := New(eventType, "dump")
+		event.Timestamp = uint64(start.Add(time.Duration(i) * time.Minute).UnixMilli())
+		event.User1 = "alice"
+		if i >= 5 {
+			event.User1 = "bob"
+		}
+		assert.NoError(sink.Write(event))
+		written = append(written, event)
+	}
+
+	res, err := source.Query(&Query{})
+	assert.NoError(err)
+	assert.Len(res, 10)
+	for i, event := range res {
+		assert.Equal(written[i].GetId(), event.GetId())
+	}
+
+	res, err = source.Query(&Query{Types: []pb.EventType{pb.EventType_ET_DUMP_MAILBOX_POLICY_CHANGED}})
+	assert.NoError(err)
+	assert.Len(res, 5)
+
+	res, err = source.Query(&Query{User: "bob", Types: []pb.EventType{pb.EventType_ET_DUMP_MESSAGE_STORED}})
+	assert.NoError(err)
+	assert.Len(res, 2)
+
+	res, err = source.Query(&Query{From: start.Add(2 * time.Minute), To: start.Add(5 * time.Minute)})
+	assert.NoError(err)
+	if assert.Len(res, 3) {
+		assert.Equal(written[2].GetId(), res[0].GetId())
+		assert.Equal(written[4].GetId(), res[2].GetId())
+	}
+
+	res, err = source.Query(&Query{User: "bob", Limit: 2})
+	assert.NoError(err)
+	if assert.Len(res, 2) {
+		assert.Equal(written[5].GetId(), res[0].GetId())
+	}
+}
@@ -0,0 +1,84 @@
+package events
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/regnull/ubikom/pb"
+	"github.com/regnull/ubikom/protoio"
+	"google.golang.org/protobuf/proto"
+)
+
+// FileSink appends protoio-encoded events to a file.
+type FileSink struct {
+	mu     sync.Mutex
+	file   *os.File
+	writer protoio.Writer
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %w", err)
+	}
+	return &FileSink{file: file, writer: protoio.NewWriter(file)}, nil
+}
+
+func (s *FileSink) Write(event *pb.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writer.Write(event)
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// FileSource reads the events written by FileSink.
+type FileSource struct {
+	path string
+}
+
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: path}
+}
+
+// Query scans the whole file, the events are returned in the order they were written.
+func (s *FileSource) Query(q *Query) ([]*pb.Event, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %w", err)
+	}
+	defer file.Close()
+
+	reader := protoio.NewReader(bufio.NewReader(file))
+	var res []*pb.Event
+	for q.Limit <= 0 || len(res) < q.Limit {
+		msg, err := reader.Read(func(b []byte) (proto.Message, error) {
+			event := &pb.Event{}
+			err := proto.Unmarshal(b, event)
+			return event, err
+		})
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read event: %w", err)
+		}
+		event := msg.(*pb.Event)
+		if q.Match(event) {
+			res = append(res, event)
+		}
+	}
+	return res, nil
+}
+
+func (s *FileSource) Close() error {
+	return nil
+}
@@ -0,0 +1,36 @@
+package events
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/regnull/ubikom/pb"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// JSONSink writes the events as JSON, one per line.
+type JSONSink struct {
+	mu   sync.Mutex
+	dest io.Writer
+}
+
+func NewJSONSink(dest io.Writer) *JSONSink {
+	return &JSONSink{dest: dest}
+}
+
+func (s *JSONSink) Write(event *pb.Event) error {
+	b, err := protojson.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to serialize event: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.dest.Write(append(b, '\n'))
+	return err
+}
+
+// Close does nothing, the destination is owned by the caller.
+func (s *JSONSink) Close() error {
+	return nil
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/regnull/easyecc/v2"
+	"github.com/regnull/ubikom/bc"
+	"github.com/regnull/ubikom/pb"
+)
+
+type blockchain struct {
+	bchain  bc.Blockchain
+	metrics *Metrics
+}
+
+// NewBlockchain returns bc.Blockchain which measures the latency of the calls.
+// If m is nil, bchain is returned as is.
+func NewBlockchain(bchain bc.Blockchain, m *Metrics) bc.Blockchain {
+	if m == nil {
+		return bchain
+	}
+	return &blockchain{bchain: bchain, metrics: m}
+}
+
+func (b *blockchain) PublicKey(ctx context.Context, name string) (*easyecc.PublicKey, error) {
+	start := time.Now()
+	key, err := b.bchain.PublicKey(ctx, name)
+	b.metrics.observeBlockchain("PublicKey", err, start)
+	return key, err
+}
+
+func (b *blockchain) Endpoint(ctx context.Context, name string) (string, error) {
+	start := time.Now()
+	endpoint, err := b.bchain.Endpoint(ctx, name)
+	b.metrics.observeBlockchain("Endpoint", err, start)
+	return endpoint, err
+}
+
+func (b *blockchain) PublicKeyP256(ctx context.Context, name string) (*easyecc.PublicKey, error) {
+	start := time.Now()
+	key, err := b.bchain.PublicKeyP256(ctx, name)
+	b.metrics.observeBlockchain("PublicKeyP256", err, start)
+	return key, err
+}
+
+func (b *blockchain) PublicKeyByCurve(ctx context.Context, name string,
+	curve easyecc.EllipticCurve) (*easyecc.PublicKey, error) {
+	start := time.Now()
+	key, err := b.bchain.PublicKeyByCurve(ctx, name, curve)
+	b.metrics.observeBlockchain("PublicKeyByCurve", err, start)
+	return key, err
+}
+
+func (b *blockchain) KeyStatus(ctx context.Context, key []byte) (*pb.LookupKeyResponse, error) {
+	start := time.Now()
+	keyStatus, err := b.bchain.KeyStatus(ctx, key)
+	b.metrics.observeBlockchain("KeyStatus", err, start)
+	return keyStatus, err
+}
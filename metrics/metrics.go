@@ -0,0 +1,165 @@
+// Package metrics collects the dump server metrics and exposes them to Prometheus.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "ubikom"
+
+// Metrics holds the metric collectors. All the methods are safe to call on nil Metrics,
+// in which case they do nothing.
+type Metrics struct {
+	requests           *prometheus.CounterVec
+	requestDuration    *prometheus.HistogramVec
+	signatureFailures  *prometheus.CounterVec
+	legacyIdentity     *prometheus.CounterVec
+	blockchainDuration *prometheus.HistogramVec
+	storeDuration      *prometheus.HistogramVec
+	mailboxMessages    prometheus.Histogram
+	mailboxBytes       prometheus.Histogram
+}
+
+// New creates the collectors and registers them with the given registerer.
+func New(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "dump",
+			Name:      "requests_total",
+			Help:      "Number of dump server requests, by method and status code.",
+		}, []string{"method", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "dump",
+			Name:      "request_duration_seconds",
+			Help:      "Dump server request latency, by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		signatureFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "dump",
+			Name:      "signature_failures_total",
+			Help:      "Number of failed signature verifications, by what was signed.",
+		}, []string{"kind"}),
+		legacyIdentity: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "dump",
+			Name:      "legacy_identity_total",
+			Help:      "Number of accepted legacy identity proofs, by verification kind.",
+		}, []string{"kind"}),
+		blockchainDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "blockchain",
+			Name:      "call_duration_seconds",
+			Help:      "Blockchain lookup latency, by method and result.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "result"}),
+		storeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "store",
+			Name:      "operation_duration_seconds",
+			Help:      "Message store operation latency, by operation and result.",
+			Buckets:   []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1},
+		}, []string{"operation", "result"}),
+		mailboxMessages: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "dump",
+			Name:      "mailbox_messages",
+			Help:      "Number of messages in the receiver's mailbox, observed when a message is sent.",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+		}),
+		mailboxBytes: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "dump",
+			Name:      "mailbox_bytes",
+			Help:      "Total size of the receiver's mailbox, observed when a message is sent.",
+			Buckets:   prometheus.ExponentialBuckets(1024, 4, 10),
+		}),
+	}
+	reg.MustRegister(m.requests, m.requestDuration, m.signatureFailures, m.legacyIdentity,
+		m.blockchainDuration, m.storeDuration, m.mailboxMessages, m.mailboxBytes)
+	return m
+}
+
+// Handler returns the HTTP handler which serves the metrics in Prometheus text format.
+func Handler(gatherer prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})
+}
+
+// SignatureFailure counts the failed signature verification.
+func (m *Metrics) SignatureFailure(kind string) {
+	if m == nil {
+		return
+	}
+	m.signatureFailures.WithLabelValues(kind).Inc()
+}
+
+// LegacyIdentity counts the accepted legacy identity proof.
+func (m *Metrics) LegacyIdentity(kind string) {
+	if m == nil {
+		return
+	}
+	m.legacyIdentity.WithLabelValues(kind).Inc()
+}
+
+// MailboxSize records the size of the receiver's mailbox.
+func (m *Metrics) MailboxSize(count int, size int64) {
+	if m == nil {
+		return
+	}
+	m.mailboxMessages.Observe(float64(count))
+	m.mailboxBytes.Observe(float64(size))
+}
+
+// UnaryServerInterceptor counts the requests and measures their latency.
+func (m *Metrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		res, err := handler(ctx, req)
+		m.observeRequest(info.FullMethod, err, start)
+		return res, err
+	}
+}
+
+// StreamServerInterceptor counts the streaming requests and measures their duration.
+func (m *Metrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		m.observeRequest(info.FullMethod, err, start)
+		return err
+	}
+}
+
+func (m *Metrics) observeRequest(method string, err error, start time.Time) {
+	if m == nil {
+		return
+	}
+	m.requests.WithLabelValues(method, status.Code(err).String()).Inc()
+	m.requestDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+func (m *Metrics) observeBlockchain(method string, err error, start time.Time) {
+	m.blockchainDuration.WithLabelValues(method, result(err)).Observe(time.Since(start).Seconds())
+}
+
+func (m *Metrics) observeStore(operation string, err error, start time.Time) {
+	m.storeDuration.WithLabelValues(operation, result(err)).Observe(time.Since(start).Seconds())
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/regnull/ubikom/pb"
+	"github.com/regnull/ubikom/store"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_Metrics_Nil(t *testing.T) {
+	var m *Metrics
+	// Nothing should panic.
+	m.SignatureFailure("message")
+	m.LegacyIdentity("proof")
+	m.MailboxSize(1, 100)
+	_, err := m.UnaryServerInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/foo"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		})
+	assert.NoError(t, err)
+
+	str := store.NewMemory()
+	assert.Equal(t, str, NewStore(str, nil))
+}
+
+func Test_Metrics_Requests(t *testing.T) {
+	assert := assert.New(t)
+
+	m := New(prometheus.NewRegistry())
+	interceptor := m.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/Ubikom.DMSDumpService/Send"}
+	for i := 0; i < 2; i++ {
+		_, err := interceptor(context.Background(), nil, info,
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, nil
+			})
+		assert.NoError(err)
+	}
+	_, err := interceptor(context.Background(), nil, info,
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, status.Error(codes.InvalidArgument, "bad signature")
+		})
+	assert.Error(err)
+
+	assert.Equal(2.0, testutil.ToFloat64(m.requests.WithLabelValues(info.FullMethod, "OK")))
+	assert.Equal(1.0, testutil.ToFloat64(m.requests.WithLabelValues(info.FullMethod, "InvalidArgument")))
+}
+
+func Test_Metrics_Store(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	m := New(prometheus.NewRegistry())
+	str := NewStore(store.NewMemory(), m)
+	msg := &pb.DMSMessage{Sender: "foo", Receiver: "bar", Content: []byte("hello")}
+	assert.NoError(str.Save(ctx, msg, []byte("123")))
+	saved, err := str.GetNext(ctx, []byte("123"))
+	assert.NoError(err)
+	assert.Equal(msg, saved)
+
+	// One series for each operation.
+	assert.Equal(2, testutil.CollectAndCount(m.storeDuration))
+}
@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/regnull/ubikom/pb"
+	"github.com/regnull/ubikom/store"
+)
+
+type messageStore struct {
+	store   store.Store
+	metrics *Metrics
+}
+
+// NewStore returns store.Store which measures the latency of the operations.
+// If m is nil, str is returned as is.
+func NewStore(str store.Store, m *Metrics) store.Store {
+	if m == nil {
+		return str
+	}
+	return &messageStore{store: str, metrics: m}
+}
+
+func (s *messageStore) Save(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte) error {
+	start := time.Now()
+	err := s.store.Save(ctx, msg, receiverKey)
+	s.metrics.observeStore("Save", err, start)
+	return err
+}
+
+func (s *messageStore) SaveOnce(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte, dedupKey string,
+	window time.Duration) (bool, error) {
+	start := time.Now()
+	saved, err := s.store.SaveOnce(ctx, msg, receiverKey, dedupKey, window)
+	s.metrics.observeStore("SaveOnce", err, start)
+	return saved, err
+}
+
+func (s *messageStore) GetNext(ctx context.Context, receiver []byte) (*pb.DMSMessage, error) {
+	start := time.Now()
+	msg, err := s.store.GetNext(ctx, receiver)
+	s.metrics.observeStore("GetNext", err, start)
+	return msg, err
+}
+
+func (s *messageStore) GetAll(ctx context.Context, receiver []byte) ([]*pb.DMSMessage, error) {
+	start := time.Now()
+	msgs, err := s.store.GetAll(ctx, receiver)
+	s.metrics.observeStore("GetAll", err, start)
+	return msgs, err
+}
+
+func (s *messageStore) List(ctx context.Context, receiverKey []byte) ([]*store.MessageInfo, error) {
+	start := time.Now()
+	infos, err := s.store.List(ctx, receiverKey)
+	s.metrics.observeStore("List", err, start)
+	return infos, err
+}
+
+func (s *messageStore) Get(ctx context.Context, receiverKey []byte, msgID string) (*pb.DMSMessage, error) {
+	start := time.Now()
+	msg, err := s.store.Get(ctx, receiverKey, msgID)
+	s.metrics.observeStore("Get", err, start)
+	return msg, err
+}
+
+func (s *messageStore) Delete(ctx context.Context, receiverKey []byte, msgID string) error {
+	start := time.Now()
+	err := s.store.Delete(ctx, receiverKey, msgID)
+	s.metrics.observeStore("Delete", err, start)
+	return err
+}
+
+func (s *messageStore) Remove(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte) error {
+	start := time.Now()
+	err := s.store.Remove(ctx, msg, receiverKey)
+	s.metrics.observeStore("Remove", err, start)
+	return err
+}
+
+func (s *messageStore) Lease(ctx context.Context, receiverKey []byte, timeout time.Duration) (*pb.DMSMessage, string, error) {
+	start := time.Now()
+	msg, deliveryID, err := s.store.Lease(ctx, receiverKey, timeout)
+	s.metrics.observeStore("Lease", err, start)
+	return msg, deliveryID, err
+}
+
+func (s *messageStore) Ack(ctx context.Context, receiverKey []byte, deliveryID string) error {
+	start := time.Now()
+	err := s.store.Ack(ctx, receiverKey, deliveryID)
+	s.metrics.observeStore("Ack", err, start)
+	return err
+}
+
+func (s *messageStore) Requeue(ctx context.Context, receiverKey []byte, deliveryID string, delay time.Duration) error {
+	start := time.Now()
+	err := s.store.Requeue(ctx, receiverKey, deliveryID, delay)
+	s.metrics.observeStore("Requeue", err, start)
+	return err
+}
+
+func (s *messageStore) Stats(ctx context.Context, receiverKey []byte) (*store.MailboxStats, error) {
+	start := time.Now()
+	stats, err := s.store.Stats(ctx, receiverKey)
+	s.metrics.observeStore("Stats", err, start)
+	return stats, err
+}
+
+func (s *messageStore) SavePolicy(ctx context.Context, receiverKey []byte, policy *pb.Signed) error {
+	start := time.Now()
+	err := s.store.SavePolicy(ctx, receiverKey, policy)
+	s.metrics.observeStore("SavePolicy", err, start)
+	return err
+}
+
+func (s *messageStore) GetPolicy(ctx context.Context, receiverKey []byte) (*pb.Signed, error) {
+	start := time.Now()
+	policy, err := s.store.GetPolicy(ctx, receiverKey)
+	s.metrics.observeStore("GetPolicy", err, start)
+	return policy, err
+}
+
+func (s *messageStore) Close() error {
+	return s.store.Close()
+}
@@ -25,6 +25,146 @@ func (_m *MockDMSDumpServiceClient) EXPECT() *MockDMSDumpServiceClient_Expecter
 	return &MockDMSDumpServiceClient_Expecter{mock: &_m.Mock}
 }
 
+// Ack provides a mock function with given fields: ctx, in, opts
+func (_m *MockDMSDumpServiceClient) Ack(ctx context.Context, in *pb.AckRequest, opts ...grpc.CallOption) (*pb.AckResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *pb.AckResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *pb.AckRequest, ...grpc.CallOption) (*pb.AckResponse, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *pb.AckRequest, ...grpc.CallOption) *pb.AckResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*pb.AckResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *pb.AckRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDMSDumpServiceClient_Ack_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Ack'
+type MockDMSDumpServiceClient_Ack_Call struct {
+	*mock.Call
+}
+
+// Ack is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *pb.AckRequest
+//   - opts ...grpc.CallOption
+func (_e *MockDMSDumpServiceClient_Expecter) Ack(ctx interface{}, in interface{}, opts ...interface{}) *MockDMSDumpServiceClient_Ack_Call {
+	return &MockDMSDumpServiceClient_Ack_Call{Call: _e.mock.On("Ack",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *MockDMSDumpServiceClient_Ack_Call) Run(run func(ctx context.Context, in *pb.AckRequest, opts ...grpc.CallOption)) *MockDMSDumpServiceClient_Ack_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]grpc.CallOption, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(grpc.CallOption)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*pb.AckRequest), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockDMSDumpServiceClient_Ack_Call) Return(_a0 *pb.AckResponse, _a1 error) *MockDMSDumpServiceClient_Ack_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDMSDumpServiceClient_Ack_Call) RunAndReturn(run func(context.Context, *pb.AckRequest, ...grpc.CallOption) (*pb.AckResponse, error)) *MockDMSDumpServiceClient_Ack_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetChallenge provides a mock function with given fields: ctx, in, opts
+func (_m *MockDMSDumpServiceClient) GetChallenge(ctx context.Context, in *pb.GetChallengeRequest, opts ...grpc.CallOption) (*pb.GetChallengeResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *pb.GetChallengeResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *pb.GetChallengeRequest, ...grpc.CallOption) (*pb.GetChallengeResponse, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *pb.GetChallengeRequest, ...grpc.CallOption) *pb.GetChallengeResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*pb.GetChallengeResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *pb.GetChallengeRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDMSDumpServiceClient_GetChallenge_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetChallenge'
+type MockDMSDumpServiceClient_GetChallenge_Call struct {
+	*mock.Call
+}
+
+// GetChallenge is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *pb.GetChallengeRequest
+//   - opts ...grpc.CallOption
+func (_e *MockDMSDumpServiceClient_Expecter) GetChallenge(ctx interface{}, in interface{}, opts ...interface{}) *MockDMSDumpServiceClient_GetChallenge_Call {
+	return &MockDMSDumpServiceClient_GetChallenge_Call{Call: _e.mock.On("GetChallenge",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *MockDMSDumpServiceClient_GetChallenge_Call) Run(run func(ctx context.Context, in *pb.GetChallengeRequest, opts ...grpc.CallOption)) *MockDMSDumpServiceClient_GetChallenge_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]grpc.CallOption, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(grpc.CallOption)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*pb.GetChallengeRequest), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockDMSDumpServiceClient_GetChallenge_Call) Return(_a0 *pb.GetChallengeResponse, _a1 error) *MockDMSDumpServiceClient_GetChallenge_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDMSDumpServiceClient_GetChallenge_Call) RunAndReturn(run func(context.Context, *pb.GetChallengeRequest, ...grpc.CallOption) (*pb.GetChallengeResponse, error)) *MockDMSDumpServiceClient_GetChallenge_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Receive provides a mock function with given fields: ctx, in, opts
 func (_m *MockDMSDumpServiceClient) Receive(ctx context.Context, in *pb.ReceiveRequest, opts ...grpc.CallOption) (*pb.ReceiveResponse, error) {
 	_va := make([]interface{}, len(opts))
@@ -95,6 +235,76 @@ func (_c *MockDMSDumpServiceClient_Receive_Call) RunAndReturn(run func(context.C
 	return _c
 }
 
+// Release provides a mock function with given fields: ctx, in, opts
+func (_m *MockDMSDumpServiceClient) Release(ctx context.Context, in *pb.ReleaseRequest, opts ...grpc.CallOption) (*pb.ReleaseResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *pb.ReleaseResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *pb.ReleaseRequest, ...grpc.CallOption) (*pb.ReleaseResponse, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *pb.ReleaseRequest, ...grpc.CallOption) *pb.ReleaseResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*pb.ReleaseResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *pb.ReleaseRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDMSDumpServiceClient_Release_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Release'
+type MockDMSDumpServiceClient_Release_Call struct {
+	*mock.Call
+}
+
+// Release is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *pb.ReleaseRequest
+//   - opts ...grpc.CallOption
+func (_e *MockDMSDumpServiceClient_Expecter) Release(ctx interface{}, in interface{}, opts ...interface{}) *MockDMSDumpServiceClient_Release_Call {
+	return &MockDMSDumpServiceClient_Release_Call{Call: _e.mock.On("Release",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *MockDMSDumpServiceClient_Release_Call) Run(run func(ctx context.Context, in *pb.ReleaseRequest, opts ...grpc.CallOption)) *MockDMSDumpServiceClient_Release_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]grpc.CallOption, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(grpc.CallOption)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*pb.ReleaseRequest), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockDMSDumpServiceClient_Release_Call) Return(_a0 *pb.ReleaseResponse, _a1 error) *MockDMSDumpServiceClient_Release_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDMSDumpServiceClient_Release_Call) RunAndReturn(run func(context.Context, *pb.ReleaseRequest, ...grpc.CallOption) (*pb.ReleaseResponse, error)) *MockDMSDumpServiceClient_Release_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Send provides a mock function with given fields: ctx, in, opts
 func (_m *MockDMSDumpServiceClient) Send(ctx context.Context, in *pb.SendRequest, opts ...grpc.CallOption) (*pb.SendResponse, error) {
 	_va := make([]interface{}, len(opts))
@@ -165,6 +375,146 @@ func (_c *MockDMSDumpServiceClient_Send_Call) RunAndReturn(run func(context.Cont
 	return _c
 }
 
+// SetMailboxPolicy provides a mock function with given fields: ctx, in, opts
+func (_m *MockDMSDumpServiceClient) SetMailboxPolicy(ctx context.Context, in *pb.SetMailboxPolicyRequest, opts ...grpc.CallOption) (*pb.SetMailboxPolicyResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *pb.SetMailboxPolicyResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *pb.SetMailboxPolicyRequest, ...grpc.CallOption) (*pb.SetMailboxPolicyResponse, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *pb.SetMailboxPolicyRequest, ...grpc.CallOption) *pb.SetMailboxPolicyResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*pb.SetMailboxPolicyResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *pb.SetMailboxPolicyRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDMSDumpServiceClient_SetMailboxPolicy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetMailboxPolicy'
+type MockDMSDumpServiceClient_SetMailboxPolicy_Call struct {
+	*mock.Call
+}
+
+// SetMailboxPolicy is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *pb.SetMailboxPolicyRequest
+//   - opts ...grpc.CallOption
+func (_e *MockDMSDumpServiceClient_Expecter) SetMailboxPolicy(ctx interface{}, in interface{}, opts ...interface{}) *MockDMSDumpServiceClient_SetMailboxPolicy_Call {
+	return &MockDMSDumpServiceClient_SetMailboxPolicy_Call{Call: _e.mock.On("SetMailboxPolicy",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *MockDMSDumpServiceClient_SetMailboxPolicy_Call) Run(run func(ctx context.Context, in *pb.SetMailboxPolicyRequest, opts ...grpc.CallOption)) *MockDMSDumpServiceClient_SetMailboxPolicy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]grpc.CallOption, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(grpc.CallOption)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*pb.SetMailboxPolicyRequest), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockDMSDumpServiceClient_SetMailboxPolicy_Call) Return(_a0 *pb.SetMailboxPolicyResponse, _a1 error) *MockDMSDumpServiceClient_SetMailboxPolicy_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDMSDumpServiceClient_SetMailboxPolicy_Call) RunAndReturn(run func(context.Context, *pb.SetMailboxPolicyRequest, ...grpc.CallOption) (*pb.SetMailboxPolicyResponse, error)) *MockDMSDumpServiceClient_SetMailboxPolicy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Subscribe provides a mock function with given fields: ctx, in, opts
+func (_m *MockDMSDumpServiceClient) Subscribe(ctx context.Context, in *pb.ReceiveRequest, opts ...grpc.CallOption) (pb.DMSDumpService_SubscribeClient, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 pb.DMSDumpService_SubscribeClient
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *pb.ReceiveRequest, ...grpc.CallOption) (pb.DMSDumpService_SubscribeClient, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *pb.ReceiveRequest, ...grpc.CallOption) pb.DMSDumpService_SubscribeClient); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(pb.DMSDumpService_SubscribeClient)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *pb.ReceiveRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDMSDumpServiceClient_Subscribe_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Subscribe'
+type MockDMSDumpServiceClient_Subscribe_Call struct {
+	*mock.Call
+}
+
+// Subscribe is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *pb.ReceiveRequest
+//   - opts ...grpc.CallOption
+func (_e *MockDMSDumpServiceClient_Expecter) Subscribe(ctx interface{}, in interface{}, opts ...interface{}) *MockDMSDumpServiceClient_Subscribe_Call {
+	return &MockDMSDumpServiceClient_Subscribe_Call{Call: _e.mock.On("Subscribe",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *MockDMSDumpServiceClient_Subscribe_Call) Run(run func(ctx context.Context, in *pb.ReceiveRequest, opts ...grpc.CallOption)) *MockDMSDumpServiceClient_Subscribe_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]grpc.CallOption, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(grpc.CallOption)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*pb.ReceiveRequest), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockDMSDumpServiceClient_Subscribe_Call) Return(_a0 pb.DMSDumpService_SubscribeClient, _a1 error) *MockDMSDumpServiceClient_Subscribe_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDMSDumpServiceClient_Subscribe_Call) RunAndReturn(run func(context.Context, *pb.ReceiveRequest, ...grpc.CallOption) (pb.DMSDumpService_SubscribeClient, error)) *MockDMSDumpServiceClient_Subscribe_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockDMSDumpServiceClient creates a new instance of MockDMSDumpServiceClient. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockDMSDumpServiceClient(t interface {
@@ -167,6 +167,18 @@ var _LookupService_serviceDesc = grpc.ServiceDesc{
 type DMSDumpServiceClient interface {
 	Send(ctx context.Context, in *SendRequest, opts ...grpc.CallOption) (*SendResponse, error)
 	Receive(ctx context.Context, in *ReceiveRequest, opts ...grpc.CallOption) (*ReceiveResponse, error)
+	// Subscribe streams all the stored messages, and then keeps the stream open,
+	// pushing new messages as they arrive.
+	Subscribe(ctx context.Context, in *ReceiveRequest, opts ...grpc.CallOption) (DMSDumpService_SubscribeClient, error)
+	// Ack acknowledges the messages received with require_ack, and removes them.
+	Ack(ctx context.Context, in *AckRequest, opts ...grpc.CallOption) (*AckResponse, error)
+	// Release makes the messages received with require_ack available again right
+	// away, without waiting for the visibility timeout.
+	Release(ctx context.Context, in *ReleaseRequest, opts ...grpc.CallOption) (*ReleaseResponse, error)
+	// GetChallenge returns a nonce to be used in the identity proof.
+	GetChallenge(ctx context.Context, in *GetChallengeRequest, opts ...grpc.CallOption) (*GetChallengeResponse, error)
+	// SetMailboxPolicy replaces the receiver's mailbox policy.
+	SetMailboxPolicy(ctx context.Context, in *SetMailboxPolicyRequest, opts ...grpc.CallOption) (*SetMailboxPolicyResponse, error)
 }
 
 type dMSDumpServiceClient struct {
@@ -195,12 +207,92 @@ func (c *dMSDumpServiceClient) Receive(ctx context.Context, in *ReceiveRequest,
 	return out, nil
 }
 
+func (c *dMSDumpServiceClient) Subscribe(ctx context.Context, in *ReceiveRequest, opts ...grpc.CallOption) (DMSDumpService_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_DMSDumpService_serviceDesc.Streams[0], "/Ubikom.DMSDumpService/Subscribe", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dMSDumpServiceSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DMSDumpService_SubscribeClient interface {
+	Recv() (*ReceiveResponse, error)
+	grpc.ClientStream
+}
+
+type dMSDumpServiceSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *dMSDumpServiceSubscribeClient) Recv() (*ReceiveResponse, error) {
+	m := new(ReceiveResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *dMSDumpServiceClient) Ack(ctx context.Context, in *AckRequest, opts ...grpc.CallOption) (*AckResponse, error) {
+	out := new(AckResponse)
+	err := c.cc.Invoke(ctx, "/Ubikom.DMSDumpService/Ack", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dMSDumpServiceClient) Release(ctx context.Context, in *ReleaseRequest, opts ...grpc.CallOption) (*ReleaseResponse, error) {
+	out := new(ReleaseResponse)
+	err := c.cc.Invoke(ctx, "/Ubikom.DMSDumpService/Release", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dMSDumpServiceClient) GetChallenge(ctx context.Context, in *GetChallengeRequest, opts ...grpc.CallOption) (*GetChallengeResponse, error) {
+	out := new(GetChallengeResponse)
+	err := c.cc.Invoke(ctx, "/Ubikom.DMSDumpService/GetChallenge", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dMSDumpServiceClient) SetMailboxPolicy(ctx context.Context, in *SetMailboxPolicyRequest, opts ...grpc.CallOption) (*SetMailboxPolicyResponse, error) {
+	out := new(SetMailboxPolicyResponse)
+	err := c.cc.Invoke(ctx, "/Ubikom.DMSDumpService/SetMailboxPolicy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DMSDumpServiceServer is the server API for DMSDumpService service.
 // All implementations must embed UnimplementedDMSDumpServiceServer
 // for forward compatibility
 type DMSDumpServiceServer interface {
 	Send(context.Context, *SendRequest) (*SendResponse, error)
 	Receive(context.Context, *ReceiveRequest) (*ReceiveResponse, error)
+	// Subscribe streams all the stored messages, and then keeps the stream open,
+	// pushing new messages as they arrive.
+	Subscribe(*ReceiveRequest, DMSDumpService_SubscribeServer) error
+	// Ack acknowledges the messages received with require_ack, and removes them.
+	Ack(context.Context, *AckRequest) (*AckResponse, error)
+	// Release makes the messages received with require_ack available again right
+	// away, without waiting for the visibility timeout.
+	Release(context.Context, *ReleaseRequest) (*ReleaseResponse, error)
+	// GetChallenge returns a nonce to be used in the identity proof.
+	GetChallenge(context.Context, *GetChallengeRequest) (*GetChallengeResponse, error)
+	// SetMailboxPolicy replaces the receiver's mailbox policy.
+	SetMailboxPolicy(context.Context, *SetMailboxPolicyRequest) (*SetMailboxPolicyResponse, error)
 	mustEmbedUnimplementedDMSDumpServiceServer()
 }
 
@@ -214,6 +306,21 @@ func (*UnimplementedDMSDumpServiceServer) Send(context.Context, *SendRequest) (*
 func (*UnimplementedDMSDumpServiceServer) Receive(context.Context, *ReceiveRequest) (*ReceiveResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Receive not implemented")
 }
+func (*UnimplementedDMSDumpServiceServer) Subscribe(*ReceiveRequest, DMSDumpService_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (*UnimplementedDMSDumpServiceServer) Ack(context.Context, *AckRequest) (*AckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ack not implemented")
+}
+func (*UnimplementedDMSDumpServiceServer) Release(context.Context, *ReleaseRequest) (*ReleaseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Release not implemented")
+}
+func (*UnimplementedDMSDumpServiceServer) GetChallenge(context.Context, *GetChallengeRequest) (*GetChallengeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetChallenge not implemented")
+}
+func (*UnimplementedDMSDumpServiceServer) SetMailboxPolicy(context.Context, *SetMailboxPolicyRequest) (*SetMailboxPolicyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetMailboxPolicy not implemented")
+}
 func (*UnimplementedDMSDumpServiceServer) mustEmbedUnimplementedDMSDumpServiceServer() {}
 
 func RegisterDMSDumpServiceServer(s *grpc.Server, srv DMSDumpServiceServer) {
@@ -256,6 +363,99 @@ func _DMSDumpService_Receive_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DMSDumpService_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReceiveRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DMSDumpServiceServer).Subscribe(m, &dMSDumpServiceSubscribeServer{stream})
+}
+
+type DMSDumpService_SubscribeServer interface {
+	Send(*ReceiveResponse) error
+	grpc.ServerStream
+}
+
+type dMSDumpServiceSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *dMSDumpServiceSubscribeServer) Send(m *ReceiveResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _DMSDumpService_Ack_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DMSDumpServiceServer).Ack(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Ubikom.DMSDumpService/Ack",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DMSDumpServiceServer).Ack(ctx, req.(*AckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DMSDumpService_Release_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DMSDumpServiceServer).Release(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Ubikom.DMSDumpService/Release",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DMSDumpServiceServer).Release(ctx, req.(*ReleaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DMSDumpService_GetChallenge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetChallengeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DMSDumpServiceServer).GetChallenge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Ubikom.DMSDumpService/GetChallenge",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DMSDumpServiceServer).GetChallenge(ctx, req.(*GetChallengeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DMSDumpService_SetMailboxPolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetMailboxPolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DMSDumpServiceServer).SetMailboxPolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Ubikom.DMSDumpService/SetMailboxPolicy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DMSDumpServiceServer).SetMailboxPolicy(ctx, req.(*SetMailboxPolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _DMSDumpService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "Ubikom.DMSDumpService",
 	HandlerType: (*DMSDumpServiceServer)(nil),
@@ -268,6 +468,258 @@ var _DMSDumpService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Receive",
 			Handler:    _DMSDumpService_Receive_Handler,
 		},
+		{
+			MethodName: "Ack",
+			Handler:    _DMSDumpService_Ack_Handler,
+		},
+		{
+			MethodName: "Release",
+			Handler:    _DMSDumpService_Release_Handler,
+		},
+		{
+			MethodName: "GetChallenge",
+			Handler:    _DMSDumpService_GetChallenge_Handler,
+		},
+		{
+			MethodName: "SetMailboxPolicy",
+			Handler:    _DMSDumpService_SetMailboxPolicy_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _DMSDumpService_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "ubikom.proto",
+}
+
+// DumpAdminServiceClient is the client API for DumpAdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DumpAdminServiceClient interface {
+	// ListMailboxes returns the number and the size of messages for each receiver.
+	ListMailboxes(ctx context.Context, in *ListMailboxesRequest, opts ...grpc.CallOption) (*ListMailboxesResponse, error)
+	// PurgeMailbox removes all the receiver's messages.
+	PurgeMailbox(ctx context.Context, in *PurgeMailboxRequest, opts ...grpc.CallOption) (*PurgeMailboxResponse, error)
+	// DeleteMessage removes a single message.
+	DeleteMessage(ctx context.Context, in *DeleteMessageRequest, opts ...grpc.CallOption) (*DeleteMessageResponse, error)
+	// GetStorageUsage returns the storage totals.
+	GetStorageUsage(ctx context.Context, in *GetStorageUsageRequest, opts ...grpc.CallOption) (*GetStorageUsageResponse, error)
+	// RunGarbageCollection reclaims the disk space used by the removed messages.
+	RunGarbageCollection(ctx context.Context, in *RunGarbageCollectionRequest, opts ...grpc.CallOption) (*RunGarbageCollectionResponse, error)
+}
+
+type dumpAdminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDumpAdminServiceClient(cc grpc.ClientConnInterface) DumpAdminServiceClient {
+	return &dumpAdminServiceClient{cc}
+}
+
+func (c *dumpAdminServiceClient) ListMailboxes(ctx context.Context, in *ListMailboxesRequest, opts ...grpc.CallOption) (*ListMailboxesResponse, error) {
+	out := new(ListMailboxesResponse)
+	err := c.cc.Invoke(ctx, "/Ubikom.DumpAdminService/ListMailboxes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dumpAdminServiceClient) PurgeMailbox(ctx context.Context, in *PurgeMailboxRequest, opts ...grpc.CallOption) (*PurgeMailboxResponse, error) {
+	out := new(PurgeMailboxResponse)
+	err := c.cc.Invoke(ctx, "/Ubikom.DumpAdminService/PurgeMailbox", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dumpAdminServiceClient) DeleteMessage(ctx context.Context, in *DeleteMessageRequest, opts ...grpc.CallOption) (*DeleteMessageResponse, error) {
+	out := new(DeleteMessageResponse)
+	err := c.cc.Invoke(ctx, "/Ubikom.DumpAdminService/DeleteMessage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dumpAdminServiceClient) GetStorageUsage(ctx context.Context, in *GetStorageUsageRequest, opts ...grpc.CallOption) (*GetStorageUsageResponse, error) {
+	out := new(GetStorageUsageResponse)
+	err := c.cc.Invoke(ctx, "/Ubikom.DumpAdminService/GetStorageUsage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dumpAdminServiceClient) RunGarbageCollection(ctx context.Context, in *RunGarbageCollectionRequest, opts ...grpc.CallOption) (*RunGarbageCollectionResponse, error) {
+	out := new(RunGarbageCollectionResponse)
+	err := c.cc.Invoke(ctx, "/Ubikom.DumpAdminService/RunGarbageCollection", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DumpAdminServiceServer is the server API for DumpAdminService service.
+// All implementations must embed UnimplementedDumpAdminServiceServer
+// for forward compatibility
+type DumpAdminServiceServer interface {
+	// ListMailboxes returns the number and the size of messages for each receiver.
+	ListMailboxes(context.Context, *ListMailboxesRequest) (*ListMailboxesResponse, error)
+	// PurgeMailbox removes all the receiver's messages.
+	PurgeMailbox(context.Context, *PurgeMailboxRequest) (*PurgeMailboxResponse, error)
+	// DeleteMessage removes a single message.
+	DeleteMessage(context.Context, *DeleteMessageRequest) (*DeleteMessageResponse, error)
+	// GetStorageUsage returns the storage totals.
+	GetStorageUsage(context.Context, *GetStorageUsageRequest) (*GetStorageUsageResponse, error)
+	// RunGarbageCollection reclaims the disk space used by the removed messages.
+	RunGarbageCollection(context.Context, *RunGarbageCollectionRequest) (*RunGarbageCollectionResponse, error)
+	mustEmbedUnimplementedDumpAdminServiceServer()
+}
+
+// UnimplementedDumpAdminServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedDumpAdminServiceServer struct {
+}
+
+func (*UnimplementedDumpAdminServiceServer) ListMailboxes(context.Context, *ListMailboxesRequest) (*ListMailboxesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMailboxes not implemented")
+}
+func (*UnimplementedDumpAdminServiceServer) PurgeMailbox(context.Context, *PurgeMailboxRequest) (*PurgeMailboxResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PurgeMailbox not implemented")
+}
+func (*UnimplementedDumpAdminServiceServer) DeleteMessage(context.Context, *DeleteMessageRequest) (*DeleteMessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteMessage not implemented")
+}
+func (*UnimplementedDumpAdminServiceServer) GetStorageUsage(context.Context, *GetStorageUsageRequest) (*GetStorageUsageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStorageUsage not implemented")
+}
+func (*UnimplementedDumpAdminServiceServer) RunGarbageCollection(context.Context, *RunGarbageCollectionRequest) (*RunGarbageCollectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunGarbageCollection not implemented")
+}
+func (*UnimplementedDumpAdminServiceServer) mustEmbedUnimplementedDumpAdminServiceServer() {}
+
+func RegisterDumpAdminServiceServer(s *grpc.Server, srv DumpAdminServiceServer) {
+	s.RegisterService(&_DumpAdminService_serviceDesc, srv)
+}
+
+func _DumpAdminService_ListMailboxes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMailboxesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DumpAdminServiceServer).ListMailboxes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Ubikom.DumpAdminService/ListMailboxes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DumpAdminServiceServer).ListMailboxes(ctx, req.(*ListMailboxesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DumpAdminService_PurgeMailbox_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeMailboxRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DumpAdminServiceServer).PurgeMailbox(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Ubikom.DumpAdminService/PurgeMailbox",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DumpAdminServiceServer).PurgeMailbox(ctx, req.(*PurgeMailboxRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DumpAdminService_DeleteMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DumpAdminServiceServer).DeleteMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Ubikom.DumpAdminService/DeleteMessage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DumpAdminServiceServer).DeleteMessage(ctx, req.(*DeleteMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DumpAdminService_GetStorageUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStorageUsageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DumpAdminServiceServer).GetStorageUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Ubikom.DumpAdminService/GetStorageUsage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DumpAdminServiceServer).GetStorageUsage(ctx, req.(*GetStorageUsageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DumpAdminService_RunGarbageCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunGarbageCollectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DumpAdminServiceServer).RunGarbageCollection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Ubikom.DumpAdminService/RunGarbageCollection",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DumpAdminServiceServer).RunGarbageCollection(ctx, req.(*RunGarbageCollectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _DumpAdminService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "Ubikom.DumpAdminService",
+	HandlerType: (*DumpAdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListMailboxes",
+			Handler:    _DumpAdminService_ListMailboxes_Handler,
+		},
+		{
+			MethodName: "PurgeMailbox",
+			Handler:    _DumpAdminService_PurgeMailbox_Handler,
+		},
+		{
+			MethodName: "DeleteMessage",
+			Handler:    _DumpAdminService_DeleteMessage_Handler,
+		},
+		{
+			MethodName: "GetStorageUsage",
+			Handler:    _DumpAdminService_GetStorageUsage_Handler,
+		},
+		{
+			MethodName: "RunGarbageCollection",
+			Handler:    _DumpAdminService_RunGarbageCollection_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "ubikom.proto",
@@ -45,7 +45,12 @@ const (
 	EventType_ET_GATEWAY_UBIKOM_MESSAGE_SENT     EventType = 2003
 	EventType_ET_GATEWAY_EMAIL_MESSAGE_SENT      EventType = 2004
 	// Web events.
-	EventType_ET_PAGE_SERVED EventType = 3001
+	EventType_ET_PAGE_SERVED          EventType = 3001
+	EventType_ET_WEB_PASSWORD_CHANGED EventType = 3002
+	// Dump server events.
+	EventType_ET_DUMP_MESSAGE_STORED         EventType = 4001
+	EventType_ET_DUMP_MESSAGE_RELAYED        EventType = 4002
+	EventType_ET_DUMP_MAILBOX_POLICY_CHANGED EventType = 4003
 )
 
 // Enum value maps for EventType.
@@ -66,6 +71,10 @@ var (
 		2003: "ET_GATEWAY_UBIKOM_MESSAGE_SENT",
 		2004: "ET_GATEWAY_EMAIL_MESSAGE_SENT",
 		3001: "ET_PAGE_SERVED",
+		3002: "ET_WEB_PASSWORD_CHANGED",
+		4001: "ET_DUMP_MESSAGE_STORED",
+		4002: "ET_DUMP_MESSAGE_RELAYED",
+		4003: "ET_DUMP_MAILBOX_POLICY_CHANGED",
 	}
 	EventType_value = map[string]int32{
 		"ET_NONE":                            0,
@@ -83,6 +92,10 @@ var (
 		"ET_GATEWAY_UBIKOM_MESSAGE_SENT":     2003,
 		"ET_GATEWAY_EMAIL_MESSAGE_SENT":      2004,
 		"ET_PAGE_SERVED":                     3001,
+		"ET_WEB_PASSWORD_CHANGED":            3002,
+		"ET_DUMP_MESSAGE_STORED":             4001,
+		"ET_DUMP_MESSAGE_RELAYED":            4002,
+		"ET_DUMP_MAILBOX_POLICY_CHANGED":     4003,
 	}
 )
 
@@ -881,6 +894,345 @@ func (x *Event) GetComponent() string {
 	return ""
 }
 
+// StoreExportRecord is one record of the dump server store export stream. The stream
+// starts with the header, and ends with the trailer.
+type StoreExportRecord struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Record:
+	//	*StoreExportRecord_Header
+	//	*StoreExportRecord_Message
+	//	*StoreExportRecord_Policy
+	//	*StoreExportRecord_Trailer
+	Record isStoreExportRecord_Record `protobuf_oneof:"record"`
+}
+
+func (x *StoreExportRecord) Reset() {
+	*x = StoreExportRecord{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ubikom_internal_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StoreExportRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StoreExportRecord) ProtoMessage() {}
+
+func (x *StoreExportRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_ubikom_internal_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StoreExportRecord.ProtoReflect.Descriptor instead.
+func (*StoreExportRecord) Descriptor() ([]byte, []int) {
+	return file_ubikom_internal_proto_rawDescGZIP(), []int{11}
+}
+
+func (m *StoreExportRecord) GetRecord() isStoreExportRecord_Record {
+	if m != nil {
+		return m.Record
+	}
+	return nil
+}
+
+func (x *StoreExportRecord) GetHeader() *StoreExportHeader {
+	if x, ok := x.GetRecord().(*StoreExportRecord_Header); ok {
+		return x.Header
+	}
+	return nil
+}
+
+func (x *StoreExportRecord) GetMessage() *StoreExportMessage {
+	if x, ok := x.GetRecord().(*StoreExportRecord_Message); ok {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *StoreExportRecord) GetPolicy() *StoreExportPolicy {
+	if x, ok := x.GetRecord().(*StoreExportRecord_Policy); ok {
+		return x.Policy
+	}
+	return nil
+}
+
+func (x *StoreExportRecord) GetTrailer() *StoreExportTrailer {
+	if x, ok := x.GetRecord().(*StoreExportRecord_Trailer); ok {
+		return x.Trailer
+	}
+	return nil
+}
+
+type isStoreExportRecord_Record interface {
+	isStoreExportRecord_Record()
+}
+
+type StoreExportRecord_Header struct {
+	Header *StoreExportHeader `protobuf:"bytes,1,opt,name=header,proto3,oneof"`
+}
+
+type StoreExportRecord_Message struct {
+	Message *StoreExportMessage `protobuf:"bytes,2,opt,name=message,proto3,oneof"`
+}
+
+type StoreExportRecord_Policy struct {
+	Policy *StoreExportPolicy `protobuf:"bytes,3,opt,name=policy,proto3,oneof"`
+}
+
+type StoreExportRecord_Trailer struct {
+	Trailer *StoreExportTrailer `protobuf:"bytes,4,opt,name=trailer,proto3,oneof"`
+}
+
+func (*StoreExportRecord_Header) isStoreExportRecord_Record() {}
+
+func (*StoreExportRecord_Message) isStoreExportRecord_Record() {}
+
+func (*StoreExportRecord_Policy) isStoreExportRecord_Record() {}
+
+func (*StoreExportRecord_Trailer) isStoreExportRecord_Record() {}
+
+type StoreExportHeader struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version   uint32 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Timestamp uint64 `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+}
+
+func (x *StoreExportHeader) Reset() {
+	*x = StoreExportHeader{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ubikom_internal_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StoreExportHeader) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StoreExportHeader) ProtoMessage() {}
+
+func (x *StoreExportHeader) ProtoReflect() protoreflect.Message {
+	mi := &file_ubikom_internal_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StoreExportHeader.ProtoReflect.Descriptor instead.
+func (*StoreExportHeader) Descriptor() ([]byte, []int) {
+	return file_ubikom_internal_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *StoreExportHeader) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *StoreExportHeader) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type StoreExportMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ReceiverKey []byte      `protobuf:"bytes,1,opt,name=receiver_key,json=receiverKey,proto3" json:"receiver_key,omitempty"`
+	Message     *DMSMessage `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *StoreExportMessage) Reset() {
+	*x = StoreExportMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ubikom_internal_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StoreExportMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StoreExportMessage) ProtoMessage() {}
+
+func (x *StoreExportMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_ubikom_internal_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StoreExportMessage.ProtoReflect.Descriptor instead.
+func (*StoreExportMessage) Descriptor() ([]byte, []int) {
+	return file_ubikom_internal_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *StoreExportMessage) GetReceiverKey() []byte {
+	if x != nil {
+		return x.ReceiverKey
+	}
+	return nil
+}
+
+func (x *StoreExportMessage) GetMessage() *DMSMessage {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+type StoreExportPolicy struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ReceiverKey []byte  `protobuf:"bytes,1,opt,name=receiver_key,json=receiverKey,proto3" json:"receiver_key,omitempty"`
+	Policy      *Signed `protobuf:"bytes,2,opt,name=policy,proto3" json:"policy,omitempty"`
+}
+
+func (x *StoreExportPolicy) Reset() {
+	*x = StoreExportPolicy{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ubikom_internal_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StoreExportPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StoreExportPolicy) ProtoMessage() {}
+
+func (x *StoreExportPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_ubikom_internal_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StoreExportPolicy.ProtoReflect.Descriptor instead.
+func (*StoreExportPolicy) Descriptor() ([]byte, []int) {
+	return file_ubikom_internal_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *StoreExportPolicy) GetReceiverKey() []byte {
+	if x != nil {
+		return x.ReceiverKey
+	}
+	return nil
+}
+
+func (x *StoreExportPolicy) GetPolicy() *Signed {
+	if x != nil {
+		return x.Policy
+	}
+	return nil
+}
+
+type StoreExportTrailer struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MessageCount uint64 `protobuf:"varint,1,opt,name=message_count,json=messageCount,proto3" json:"message_count,omitempty"`
+	PolicyCount  uint64 `protobuf:"varint,2,opt,name=policy_count,json=policyCount,proto3" json:"policy_count,omitempty"`
+	// SHA-256 of the stream up to the trailer.
+	Sha256 []byte `protobuf:"bytes,3,opt,name=sha256,proto3" json:"sha256,omitempty"`
+}
+
+func (x *StoreExportTrailer) Reset() {
+	*x = StoreExportTrailer{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ubikom_internal_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StoreExportTrailer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StoreExportTrailer) ProtoMessage() {}
+
+func (x *StoreExportTrailer) ProtoReflect() protoreflect.Message {
+	mi := &file_ubikom_internal_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StoreExportTrailer.ProtoReflect.Descriptor instead.
+func (*StoreExportTrailer) Descriptor() ([]byte, []int) {
+	return file_ubikom_internal_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *StoreExportTrailer) GetMessageCount() uint64 {
+	if x != nil {
+		return x.MessageCount
+	}
+	return 0
+}
+
+func (x *StoreExportTrailer) GetPolicyCount() uint64 {
+	if x != nil {
+		return x.PolicyCount
+	}
+	return 0
+}
+
+func (x *StoreExportTrailer) GetSha256() []byte {
+	if x != nil {
+		return x.Sha256
+	}
+	return nil
+}
+
 type CopyMailboxesRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -893,7 +1245,7 @@ type CopyMailboxesRequest struct {
 func (x *CopyMailboxesRequest) Reset() {
 	*x = CopyMailboxesRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_ubikom_internal_proto_msgTypes[11]
+		mi := &file_ubikom_internal_proto_msgTypes[16]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -906,7 +1258,7 @@ func (x *CopyMailboxesRequest) String() string {
 func (*CopyMailboxesRequest) ProtoMessage() {}
 
 func (x *CopyMailboxesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ubikom_internal_proto_msgTypes[11]
+	mi := &file_ubikom_internal_proto_msgTypes[16]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -919,7 +1271,7 @@ func (x *CopyMailboxesRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CopyMailboxesRequest.ProtoReflect.Descriptor instead.
 func (*CopyMailboxesRequest) Descriptor() ([]byte, []int) {
-	return file_ubikom_internal_proto_rawDescGZIP(), []int{11}
+	return file_ubikom_internal_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *CopyMailboxesRequest) GetOldKey() []byte {
@@ -945,7 +1297,7 @@ type CopyMailboxesResponse struct {
 func (x *CopyMailboxesResponse) Reset() {
 	*x = CopyMailboxesResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_ubikom_internal_proto_msgTypes[12]
+		mi := &file_ubikom_internal_proto_msgTypes[17]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -958,7 +1310,7 @@ func (x *CopyMailboxesResponse) String() string {
 func (*CopyMailboxesResponse) ProtoMessage() {}
 
 func (x *CopyMailboxesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ubikom_internal_proto_msgTypes[12]
+	mi := &file_ubikom_internal_proto_msgTypes[17]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -971,7 +1323,7 @@ func (x *CopyMailboxesResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CopyMailboxesResponse.ProtoReflect.Descriptor instead.
 func (*CopyMailboxesResponse) Descriptor() ([]byte, []int) {
-	return file_ubikom_internal_proto_rawDescGZIP(), []int{12}
+	return file_ubikom_internal_proto_rawDescGZIP(), []int{17}
 }
 
 type CheckMailboxKeyRequest struct {
@@ -986,7 +1338,7 @@ type CheckMailboxKeyRequest struct {
 func (x *CheckMailboxKeyRequest) Reset() {
 	*x = CheckMailboxKeyRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_ubikom_internal_proto_msgTypes[13]
+		mi := &file_ubikom_internal_proto_msgTypes[18]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -999,7 +1351,7 @@ func (x *CheckMailboxKeyRequest) String() string {
 func (*CheckMailboxKeyRequest) ProtoMessage() {}
 
 func (x *CheckMailboxKeyRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ubikom_internal_proto_msgTypes[13]
+	mi := &file_ubikom_internal_proto_msgTypes[18]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1012,7 +1364,7 @@ func (x *CheckMailboxKeyRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CheckMailboxKeyRequest.ProtoReflect.Descriptor instead.
 func (*CheckMailboxKeyRequest) Descriptor() ([]byte, []int) {
-	return file_ubikom_internal_proto_rawDescGZIP(), []int{13}
+	return file_ubikom_internal_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *CheckMailboxKeyRequest) GetKey() []byte {
@@ -1038,7 +1390,7 @@ type CheckMailboxKeyResponse struct {
 func (x *CheckMailboxKeyResponse) Reset() {
 	*x = CheckMailboxKeyResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_ubikom_internal_proto_msgTypes[14]
+		mi := &file_ubikom_internal_proto_msgTypes[19]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1051,7 +1403,7 @@ func (x *CheckMailboxKeyResponse) String() string {
 func (*CheckMailboxKeyResponse) ProtoMessage() {}
 
 func (x *CheckMailboxKeyResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ubikom_internal_proto_msgTypes[14]
+	mi := &file_ubikom_internal_proto_msgTypes[19]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1064,7 +1416,7 @@ func (x *CheckMailboxKeyResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CheckMailboxKeyResponse.ProtoReflect.Descriptor instead.
 func (*CheckMailboxKeyResponse) Descriptor() ([]byte, []int) {
-	return file_ubikom_internal_proto_rawDescGZIP(), []int{14}
+	return file_ubikom_internal_proto_rawDescGZIP(), []int{19}
 }
 
 var File_ubikom_internal_proto protoreflect.FileDescriptor
@@ -1169,59 +1521,107 @@ var file_ubikom_internal_proto_rawDesc = []byte{
 	0x61, 0x74, 0x61, 0x31, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x64, 0x61, 0x74, 0x61,
 	0x31, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x18, 0x08,
 	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x22,
-	0x46, 0x0a, 0x14, 0x43, 0x6f, 0x70, 0x79, 0x4d, 0x61, 0x69, 0x6c, 0x62, 0x6f, 0x78, 0x65, 0x73,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x6c, 0x64, 0x4b, 0x65,
-	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x6f, 0x6c, 0x64, 0x4b, 0x65, 0x79, 0x12,
-	0x16, 0x0a, 0x06, 0x6e, 0x65, 0x77, 0x4b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
-	0x06, 0x6e, 0x65, 0x77, 0x4b, 0x65, 0x79, 0x22, 0x17, 0x0a, 0x15, 0x43, 0x6f, 0x70, 0x79, 0x4d,
-	0x61, 0x69, 0x6c, 0x62, 0x6f, 0x78, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x22, 0x3e, 0x0a, 0x16, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x4d, 0x61, 0x69, 0x6c, 0x62, 0x6f, 0x78,
-	0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
-	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x12, 0x0a, 0x04,
-	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65,
-	0x22, 0x19, 0x0a, 0x17, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x4d, 0x61, 0x69, 0x6c, 0x62, 0x6f, 0x78,
-	0x4b, 0x65, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2a, 0xc6, 0x03, 0x0a, 0x09,
-	0x45, 0x76, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x45, 0x54, 0x5f,
-	0x4e, 0x4f, 0x4e, 0x45, 0x10, 0x00, 0x12, 0x17, 0x0a, 0x13, 0x45, 0x54, 0x5f, 0x4b, 0x45, 0x59,
-	0x5f, 0x52, 0x45, 0x47, 0x49, 0x53, 0x54, 0x52, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x01, 0x12,
-	0x18, 0x0a, 0x14, 0x45, 0x54, 0x5f, 0x4e, 0x41, 0x4d, 0x45, 0x5f, 0x52, 0x45, 0x47, 0x49, 0x53,
-	0x54, 0x52, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x02, 0x12, 0x1b, 0x0a, 0x17, 0x45, 0x54, 0x5f,
-	0x41, 0x44, 0x44, 0x52, 0x45, 0x53, 0x53, 0x5f, 0x52, 0x45, 0x47, 0x49, 0x53, 0x54, 0x52, 0x41,
-	0x54, 0x49, 0x4f, 0x4e, 0x10, 0x03, 0x12, 0x17, 0x0a, 0x12, 0x45, 0x54, 0x5f, 0x50, 0x52, 0x4f,
-	0x58, 0x59, 0x5f, 0x50, 0x4f, 0x50, 0x5f, 0x4c, 0x4f, 0x47, 0x49, 0x4e, 0x10, 0xe9, 0x07, 0x12,
-	0x18, 0x0a, 0x13, 0x45, 0x54, 0x5f, 0x50, 0x52, 0x4f, 0x58, 0x59, 0x5f, 0x49, 0x4d, 0x41, 0x50,
-	0x5f, 0x4c, 0x4f, 0x47, 0x49, 0x4e, 0x10, 0xcd, 0x08, 0x12, 0x18, 0x0a, 0x13, 0x45, 0x54, 0x5f,
-	0x50, 0x52, 0x4f, 0x58, 0x59, 0x5f, 0x53, 0x4d, 0x54, 0x50, 0x5f, 0x4c, 0x4f, 0x47, 0x49, 0x4e,
-	0x10, 0xb1, 0x09, 0x12, 0x1f, 0x0a, 0x1a, 0x45, 0x54, 0x5f, 0x50, 0x52, 0x4f, 0x58, 0x59, 0x5f,
-	0x53, 0x4d, 0x54, 0x50, 0x5f, 0x4d, 0x45, 0x53, 0x53, 0x41, 0x47, 0x45, 0x5f, 0x53, 0x45, 0x4e,
-	0x54, 0x10, 0xb2, 0x09, 0x12, 0x1b, 0x0a, 0x16, 0x45, 0x54, 0x5f, 0x50, 0x52, 0x4f, 0x58, 0x59,
-	0x5f, 0x57, 0x45, 0x42, 0x4d, 0x41, 0x49, 0x4c, 0x5f, 0x4c, 0x4f, 0x47, 0x49, 0x4e, 0x10, 0x95,
-	0x0a, 0x12, 0x22, 0x0a, 0x1d, 0x45, 0x54, 0x5f, 0x50, 0x52, 0x4f, 0x58, 0x59, 0x5f, 0x57, 0x45,
-	0x42, 0x4d, 0x41, 0x49, 0x4c, 0x5f, 0x4d, 0x45, 0x53, 0x53, 0x41, 0x47, 0x45, 0x5f, 0x53, 0x45,
-	0x4e, 0x54, 0x10, 0x96, 0x0a, 0x12, 0x27, 0x0a, 0x22, 0x45, 0x54, 0x5f, 0x47, 0x41, 0x54, 0x45,
-	0x57, 0x41, 0x59, 0x5f, 0x55, 0x42, 0x49, 0x4b, 0x4f, 0x4d, 0x5f, 0x4d, 0x45, 0x53, 0x53, 0x41,
-	0x47, 0x45, 0x5f, 0x52, 0x45, 0x43, 0x45, 0x49, 0x56, 0x45, 0x44, 0x10, 0xd1, 0x0f, 0x12, 0x26,
-	0x0a, 0x21, 0x45, 0x54, 0x5f, 0x47, 0x41, 0x54, 0x45, 0x57, 0x41, 0x59, 0x5f, 0x45, 0x4d, 0x41,
-	0x49, 0x4c, 0x5f, 0x4d, 0x45, 0x53, 0x53, 0x41, 0x47, 0x45, 0x5f, 0x52, 0x45, 0x43, 0x45, 0x49,
-	0x56, 0x45, 0x44, 0x10, 0xd2, 0x0f, 0x12, 0x23, 0x0a, 0x1e, 0x45, 0x54, 0x5f, 0x47, 0x41, 0x54,
-	0x45, 0x57, 0x41, 0x59, 0x5f, 0x55, 0x42, 0x49, 0x4b, 0x4f, 0x4d, 0x5f, 0x4d, 0x45, 0x53, 0x53,
-	0x41, 0x47, 0x45, 0x5f, 0x53, 0x45, 0x4e, 0x54, 0x10, 0xd3, 0x0f, 0x12, 0x22, 0x0a, 0x1d, 0x45,
-	0x54, 0x5f, 0x47, 0x41, 0x54, 0x45, 0x57, 0x41, 0x59, 0x5f, 0x45, 0x4d, 0x41, 0x49, 0x4c, 0x5f,
-	0x4d, 0x45, 0x53, 0x53, 0x41, 0x47, 0x45, 0x5f, 0x53, 0x45, 0x4e, 0x54, 0x10, 0xd4, 0x0f, 0x12,
-	0x13, 0x0a, 0x0e, 0x45, 0x54, 0x5f, 0x50, 0x41, 0x47, 0x45, 0x5f, 0x53, 0x45, 0x52, 0x56, 0x45,
-	0x44, 0x10, 0xb9, 0x17, 0x32, 0xb0, 0x01, 0x0a, 0x0c, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x53, 0x65,
-	0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x4c, 0x0a, 0x0d, 0x43, 0x6f, 0x70, 0x79, 0x4d, 0x61, 0x69,
-	0x6c, 0x62, 0x6f, 0x78, 0x65, 0x73, 0x12, 0x1c, 0x2e, 0x55, 0x62, 0x69, 0x6b, 0x6f, 0x6d, 0x2e,
-	0x43, 0x6f, 0x70, 0x79, 0x4d, 0x61, 0x69, 0x6c, 0x62, 0x6f, 0x78, 0x65, 0x73, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x55, 0x62, 0x69, 0x6b, 0x6f, 0x6d, 0x2e, 0x43, 0x6f,
-	0x70, 0x79, 0x4d, 0x61, 0x69, 0x6c, 0x62, 0x6f, 0x78, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x12, 0x52, 0x0a, 0x0f, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x4d, 0x61, 0x69, 0x6c,
-	0x62, 0x6f, 0x78, 0x4b, 0x65, 0x79, 0x12, 0x1e, 0x2e, 0x55, 0x62, 0x69, 0x6b, 0x6f, 0x6d, 0x2e,
-	0x43, 0x68, 0x65, 0x63, 0x6b, 0x4d, 0x61, 0x69, 0x6c, 0x62, 0x6f, 0x78, 0x4b, 0x65, 0x79, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x55, 0x62, 0x69, 0x6b, 0x6f, 0x6d, 0x2e,
-	0x43, 0x68, 0x65, 0x63, 0x6b, 0x4d, 0x61, 0x69, 0x6c, 0x62, 0x6f, 0x78, 0x4b, 0x65, 0x79, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x07, 0x5a, 0x05, 0x2e, 0x2f, 0x3b, 0x70, 0x62,
-	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0xf7, 0x01, 0x0a, 0x11, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52,
+	0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x33, 0x0a, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x55, 0x62, 0x69, 0x6b, 0x6f, 0x6d, 0x2e, 0x53,
+	0x74, 0x6f, 0x72, 0x65, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72,
+	0x48, 0x00, 0x52, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x36, 0x0a, 0x07, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x55, 0x62,
+	0x69, 0x6b, 0x6f, 0x6d, 0x2e, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x12, 0x33, 0x0a, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x19, 0x2e, 0x55, 0x62, 0x69, 0x6b, 0x6f, 0x6d, 0x2e, 0x53, 0x74, 0x6f, 0x72,
+	0x65, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x48, 0x00, 0x52,
+	0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x36, 0x0a, 0x07, 0x74, 0x72, 0x61, 0x69, 0x6c,
+	0x65, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x55, 0x62, 0x69, 0x6b, 0x6f,
+	0x6d, 0x2e, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x54, 0x72, 0x61,
+	0x69, 0x6c, 0x65, 0x72, 0x48, 0x00, 0x52, 0x07, 0x74, 0x72, 0x61, 0x69, 0x6c, 0x65, 0x72, 0x42,
+	0x08, 0x0a, 0x06, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x22, 0x4b, 0x0a, 0x11, 0x53, 0x74, 0x6f,
+	0x72, 0x65, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x18,
+	0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x74, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x22, 0x65, 0x0a, 0x12, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x45,
+	0x78, 0x70, 0x6f, 0x72, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x21, 0x0a, 0x0c,
+	0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x0b, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x12,
+	0x2c, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x12, 0x2e, 0x55, 0x62, 0x69, 0x6b, 0x6f, 0x6d, 0x2e, 0x44, 0x4d, 0x53, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x5e, 0x0a,
+	0x11, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x50, 0x6f, 0x6c, 0x69,
+	0x63, 0x79, 0x12, 0x21, 0x0a, 0x0c, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x5f, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76,
+	0x65, 0x72, 0x4b, 0x65, 0x79, 0x12, 0x26, 0x0a, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x55, 0x62, 0x69, 0x6b, 0x6f, 0x6d, 0x2e, 0x53,
+	0x69, 0x67, 0x6e, 0x65, 0x64, 0x52, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x22, 0x74, 0x0a,
+	0x12, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x54, 0x72, 0x61, 0x69,
+	0x6c, 0x65, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x6d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x6f, 0x6c, 0x69,
+	0x63, 0x79, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b,
+	0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73,
+	0x68, 0x61, 0x32, 0x35, 0x36, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x73, 0x68, 0x61,
+	0x32, 0x35, 0x36, 0x22, 0x46, 0x0a, 0x14, 0x43, 0x6f, 0x70, 0x79, 0x4d, 0x61, 0x69, 0x6c, 0x62,
+	0x6f, 0x78, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x6f,
+	0x6c, 0x64, 0x4b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x6f, 0x6c, 0x64,
+	0x4b, 0x65, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x65, 0x77, 0x4b, 0x65, 0x79, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x06, 0x6e, 0x65, 0x77, 0x4b, 0x65, 0x79, 0x22, 0x17, 0x0a, 0x15, 0x43,
+	0x6f, 0x70, 0x79, 0x4d, 0x61, 0x69, 0x6c, 0x62, 0x6f, 0x78, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x22, 0x3e, 0x0a, 0x16, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x4d, 0x61, 0x69,
+	0x6c, 0x62, 0x6f, 0x78, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10,
+	0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79,
+	0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x22, 0x19, 0x0a, 0x17, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x4d, 0x61, 0x69,
+	0x6c, 0x62, 0x6f, 0x78, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2a,
+	0xc4, 0x04, 0x0a, 0x09, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0b, 0x0a,
+	0x07, 0x45, 0x54, 0x5f, 0x4e, 0x4f, 0x4e, 0x45, 0x10, 0x00, 0x12, 0x17, 0x0a, 0x13, 0x45, 0x54,
+	0x5f, 0x4b, 0x45, 0x59, 0x5f, 0x52, 0x45, 0x47, 0x49, 0x53, 0x54, 0x52, 0x41, 0x54, 0x49, 0x4f,
+	0x4e, 0x10, 0x01, 0x12, 0x18, 0x0a, 0x14, 0x45, 0x54, 0x5f, 0x4e, 0x41, 0x4d, 0x45, 0x5f, 0x52,
+	0x45, 0x47, 0x49, 0x53, 0x54, 0x52, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x02, 0x12, 0x1b, 0x0a,
+	0x17, 0x45, 0x54, 0x5f, 0x41, 0x44, 0x44, 0x52, 0x45, 0x53, 0x53, 0x5f, 0x52, 0x45, 0x47, 0x49,
+	0x53, 0x54, 0x52, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x03, 0x12, 0x17, 0x0a, 0x12, 0x45, 0x54,
+	0x5f, 0x50, 0x52, 0x4f, 0x58, 0x59, 0x5f, 0x50, 0x4f, 0x50, 0x5f, 0x4c, 0x4f, 0x47, 0x49, 0x4e,
+	0x10, 0xe9, 0x07, 0x12, 0x18, 0x0a, 0x13, 0x45, 0x54, 0x5f, 0x50, 0x52, 0x4f, 0x58, 0x59, 0x5f,
+	0x49, 0x4d, 0x41, 0x50, 0x5f, 0x4c, 0x4f, 0x47, 0x49, 0x4e, 0x10, 0xcd, 0x08, 0x12, 0x18, 0x0a,
+	0x13, 0x45, 0x54, 0x5f, 0x50, 0x52, 0x4f, 0x58, 0x59, 0x5f, 0x53, 0x4d, 0x54, 0x50, 0x5f, 0x4c,
+	0x4f, 0x47, 0x49, 0x4e, 0x10, 0xb1, 0x09, 0x12, 0x1f, 0x0a, 0x1a, 0x45, 0x54, 0x5f, 0x50, 0x52,
+	0x4f, 0x58, 0x59, 0x5f, 0x53, 0x4d, 0x54, 0x50, 0x5f, 0x4d, 0x45, 0x53, 0x53, 0x41, 0x47, 0x45,
+	0x5f, 0x53, 0x45, 0x4e, 0x54, 0x10, 0xb2, 0x09, 0x12, 0x1b, 0x0a, 0x16, 0x45, 0x54, 0x5f, 0x50,
+	0x52, 0x4f, 0x58, 0x59, 0x5f, 0x57, 0x45, 0x42, 0x4d, 0x41, 0x49, 0x4c, 0x5f, 0x4c, 0x4f, 0x47,
+	0x49, 0x4e, 0x10, 0x95, 0x0a, 0x12, 0x22, 0x0a, 0x1d, 0x45, 0x54, 0x5f, 0x50, 0x52, 0x4f, 0x58,
+	0x59, 0x5f, 0x57, 0x45, 0x42, 0x4d, 0x41, 0x49, 0x4c, 0x5f, 0x4d, 0x45, 0x53, 0x53, 0x41, 0x47,
+	0x45, 0x5f, 0x53, 0x45, 0x4e, 0x54, 0x10, 0x96, 0x0a, 0x12, 0x27, 0x0a, 0x22, 0x45, 0x54, 0x5f,
+	0x47, 0x41, 0x54, 0x45, 0x57, 0x41, 0x59, 0x5f, 0x55, 0x42, 0x49, 0x4b, 0x4f, 0x4d, 0x5f, 0x4d,
+	0x45, 0x53, 0x53, 0x41, 0x47, 0x45, 0x5f, 0x52, 0x45, 0x43, 0x45, 0x49, 0x56, 0x45, 0x44, 0x10,
+	0xd1, 0x0f, 0x12, 0x26, 0x0a, 0x21, 0x45, 0x54, 0x5f, 0x47, 0x41, 0x54, 0x45, 0x57, 0x41, 0x59,
+	0x5f, 0x45, 0x4d, 0x41, 0x49, 0x4c, 0x5f, 0x4d, 0x45, 0x53, 0x53, 0x41, 0x47, 0x45, 0x5f, 0x52,
+	0x45, 0x43, 0x45, 0x49, 0x56, 0x45, 0x44, 0x10, 0xd2, 0x0f, 0x12, 0x23, 0x0a, 0x1e, 0x45, 0x54,
+	0x5f, 0x47, 0x41, 0x54, 0x45, 0x57, 0x41, 0x59, 0x5f, 0x55, 0x42, 0x49, 0x4b, 0x4f, 0x4d, 0x5f,
+	0x4d, 0x45, 0x53, 0x53, 0x41, 0x47, 0x45, 0x5f, 0x53, 0x45, 0x4e, 0x54, 0x10, 0xd3, 0x0f, 0x12,
+	0x22, 0x0a, 0x1d, 0x45, 0x54, 0x5f, 0x47, 0x41, 0x54, 0x45, 0x57, 0x41, 0x59, 0x5f, 0x45, 0x4d,
+	0x41, 0x49, 0x4c, 0x5f, 0x4d, 0x45, 0x53, 0x53, 0x41, 0x47, 0x45, 0x5f, 0x53, 0x45, 0x4e, 0x54,
+	0x10, 0xd4, 0x0f, 0x12, 0x13, 0x0a, 0x0e, 0x45, 0x54, 0x5f, 0x50, 0x41, 0x47, 0x45, 0x5f, 0x53,
+	0x45, 0x52, 0x56, 0x45, 0x44, 0x10, 0xb9, 0x17, 0x12, 0x1c, 0x0a, 0x17, 0x45, 0x54, 0x5f, 0x57,
+	0x45, 0x42, 0x5f, 0x50, 0x41, 0x53, 0x53, 0x57, 0x4f, 0x52, 0x44, 0x5f, 0x43, 0x48, 0x41, 0x4e,
+	0x47, 0x45, 0x44, 0x10, 0xba, 0x17, 0x12, 0x1b, 0x0a, 0x16, 0x45, 0x54, 0x5f, 0x44, 0x55, 0x4d,
+	0x50, 0x5f, 0x4d, 0x45, 0x53, 0x53, 0x41, 0x47, 0x45, 0x5f, 0x53, 0x54, 0x4f, 0x52, 0x45, 0x44,
+	0x10, 0xa1, 0x1f, 0x12, 0x1c, 0x0a, 0x17, 0x45, 0x54, 0x5f, 0x44, 0x55, 0x4d, 0x50, 0x5f, 0x4d,
+	0x45, 0x53, 0x53, 0x41, 0x47, 0x45, 0x5f, 0x52, 0x45, 0x4c, 0x41, 0x59, 0x45, 0x44, 0x10, 0xa2,
+	0x1f, 0x12, 0x23, 0x0a, 0x1e, 0x45, 0x54, 0x5f, 0x44, 0x55, 0x4d, 0x50, 0x5f, 0x4d, 0x41, 0x49,
+	0x4c, 0x42, 0x4f, 0x58, 0x5f, 0x50, 0x4f, 0x4c, 0x49, 0x43, 0x59, 0x5f, 0x43, 0x48, 0x41, 0x4e,
+	0x47, 0x45, 0x44, 0x10, 0xa3, 0x1f, 0x32, 0xb0, 0x01, 0x0a, 0x0c, 0x50, 0x72, 0x6f, 0x78, 0x79,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x4c, 0x0a, 0x0d, 0x43, 0x6f, 0x70, 0x79, 0x4d,
+	0x61, 0x69, 0x6c, 0x62, 0x6f, 0x78, 0x65, 0x73, 0x12, 0x1c, 0x2e, 0x55, 0x62, 0x69, 0x6b, 0x6f,
+	0x6d, 0x2e, 0x43, 0x6f, 0x70, 0x79, 0x4d, 0x61, 0x69, 0x6c, 0x62, 0x6f, 0x78, 0x65, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x55, 0x62, 0x69, 0x6b, 0x6f, 0x6d, 0x2e,
+	0x43, 0x6f, 0x70, 0x79, 0x4d, 0x61, 0x69, 0x6c, 0x62, 0x6f, 0x78, 0x65, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x52, 0x0a, 0x0f, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x4d, 0x61,
+	0x69, 0x6c, 0x62, 0x6f, 0x78, 0x4b, 0x65, 0x79, 0x12, 0x1e, 0x2e, 0x55, 0x62, 0x69, 0x6b, 0x6f,
+	0x6d, 0x2e, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x4d, 0x61, 0x69, 0x6c, 0x62, 0x6f, 0x78, 0x4b, 0x65,
+	0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x55, 0x62, 0x69, 0x6b, 0x6f,
+	0x6d, 0x2e, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x4d, 0x61, 0x69, 0x6c, 0x62, 0x6f, 0x78, 0x4b, 0x65,
+	0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x07, 0x5a, 0x05, 0x2e, 0x2f, 0x3b,
+	0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1237,7 +1637,7 @@ func file_ubikom_internal_proto_rawDescGZIP() []byte {
 }
 
 var file_ubikom_internal_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_ubikom_internal_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_ubikom_internal_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
 var file_ubikom_internal_proto_goTypes = []interface{}{
 	(EventType)(0),                  // 0: Ubikom.EventType
 	(*KeyRecord)(nil),               // 1: Ubikom.KeyRecord
@@ -1251,28 +1651,41 @@ var file_ubikom_internal_proto_goTypes = []interface{}{
 	(*ImapMailboxes)(nil),           // 9: Ubikom.ImapMailboxes
 	(*ImapMessage)(nil),             // 10: Ubikom.ImapMessage
 	(*Event)(nil),                   // 11: Ubikom.Event
-	(*CopyMailboxesRequest)(nil),    // 12: Ubikom.CopyMailboxesRequest
-	(*CopyMailboxesResponse)(nil),   // 13: Ubikom.CopyMailboxesResponse
-	(*CheckMailboxKeyRequest)(nil),  // 14: Ubikom.CheckMailboxKeyRequest
-	(*CheckMailboxKeyResponse)(nil), // 15: Ubikom.CheckMailboxKeyResponse
-	(Protocol)(0),                   // 16: Ubikom.Protocol
-	(*anypb.Any)(nil),               // 17: google.protobuf.Any
+	(*StoreExportRecord)(nil),       // 12: Ubikom.StoreExportRecord
+	(*StoreExportHeader)(nil),       // 13: Ubikom.StoreExportHeader
+	(*StoreExportMessage)(nil),      // 14: Ubikom.StoreExportMessage
+	(*StoreExportPolicy)(nil),       // 15: Ubikom.StoreExportPolicy
+	(*StoreExportTrailer)(nil),      // 16: Ubikom.StoreExportTrailer
+	(*CopyMailboxesRequest)(nil),    // 17: Ubikom.CopyMailboxesRequest
+	(*CopyMailboxesResponse)(nil),   // 18: Ubikom.CopyMailboxesResponse
+	(*CheckMailboxKeyRequest)(nil),  // 19: Ubikom.CheckMailboxKeyRequest
+	(*CheckMailboxKeyResponse)(nil), // 20: Ubikom.CheckMailboxKeyResponse
+	(Protocol)(0),                   // 21: Ubikom.Protocol
+	(*anypb.Any)(nil),               // 22: google.protobuf.Any
+	(*DMSMessage)(nil),              // 23: Ubikom.DMSMessage
+	(*Signed)(nil),                  // 24: Ubikom.Signed
 }
 var file_ubikom_internal_proto_depIdxs = []int32{
-	16, // 0: Ubikom.ExportAddressRecord.protocol:type_name -> Ubikom.Protocol
-	17, // 1: Ubikom.DBValue.payload:type_name -> google.protobuf.Any
+	21, // 0: Ubikom.ExportAddressRecord.protocol:type_name -> Ubikom.Protocol
+	22, // 1: Ubikom.DBValue.payload:type_name -> google.protobuf.Any
 	5,  // 2: Ubikom.DBEntry.value:type_name -> Ubikom.DBValue
 	8,  // 3: Ubikom.ImapMailboxes.mailbox:type_name -> Ubikom.ImapMailbox
 	0,  // 4: Ubikom.Event.event_type:type_name -> Ubikom.EventType
-	12, // 5: Ubikom.ProxyService.CopyMailboxes:input_type -> Ubikom.CopyMailboxesRequest
-	14, // 6: Ubikom.ProxyService.CheckMailboxKey:input_type -> Ubikom.CheckMailboxKeyRequest
-	13, // 7: Ubikom.ProxyService.CopyMailboxes:output_type -> Ubikom.CopyMailboxesResponse
-	15, // 8: Ubikom.ProxyService.CheckMailboxKey:output_type -> Ubikom.CheckMailboxKeyResponse
-	7,  // [7:9] is the sub-list for method output_type
-	5,  // [5:7] is the sub-list for method input_type
-	5,  // [5:5] is the sub-list for extension type_name
-	5,  // [5:5] is the sub-list for extension extendee
-	0,  // [0:5] is the sub-list for field type_name
+	13, // 5: Ubikom.StoreExportRecord.header:type_name -> Ubikom.StoreExportHeader
+	14, // 6: Ubikom.StoreExportRecord.message:type_name -> Ubikom.StoreExportMessage
+	15, // 7: Ubikom.StoreExportRecord.policy:type_name -> Ubikom.StoreExportPolicy
+	16, // 8: Ubikom.StoreExportRecord.trailer:type_name -> Ubikom.StoreExportTrailer
+	23, // 9: Ubikom.StoreExportMessage.message:type_name -> Ubikom.DMSMessage
+	24, // 10: Ubikom.StoreExportPolicy.policy:type_name -> Ubikom.Signed
+	17, // 11: Ubikom.ProxyService.CopyMailboxes:input_type -> Ubikom.CopyMailboxesRequest
+	19, // 12: Ubikom.ProxyService.CheckMailboxKey:input_type -> Ubikom.CheckMailboxKeyRequest
+	18, // 13: Ubikom.ProxyService.CopyMailboxes:output_type -> Ubikom.CopyMailboxesResponse
+	20, // 14: Ubikom.ProxyService.CheckMailboxKey:output_type -> Ubikom.CheckMailboxKeyResponse
+	13, // [13:15] is the sub-list for method output_type
+	11, // [11:13] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_ubikom_internal_proto_init() }
@@ -1415,7 +1828,7 @@ func file_ubikom_internal_proto_init() {
 			}
 		}
 		file_ubikom_internal_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CopyMailboxesRequest); i {
+			switch v := v.(*StoreExportRecord); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1427,7 +1840,7 @@ func file_ubikom_internal_proto_init() {
 			}
 		}
 		file_ubikom_internal_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CopyMailboxesResponse); i {
+			switch v := v.(*StoreExportHeader); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1439,7 +1852,7 @@ func file_ubikom_internal_proto_init() {
 			}
 		}
 		file_ubikom_internal_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CheckMailboxKeyRequest); i {
+			switch v := v.(*StoreExportMessage); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1451,6 +1864,66 @@ func file_ubikom_internal_proto_init() {
 			}
 		}
 		file_ubikom_internal_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StoreExportPolicy); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ubikom_internal_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StoreExportTrailer); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ubikom_internal_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CopyMailboxesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ubikom_internal_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CopyMailboxesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ubikom_internal_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CheckMailboxKeyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ubikom_internal_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*CheckMailboxKeyResponse); i {
 			case 0:
 				return &v.state
@@ -1463,13 +1936,19 @@ func file_ubikom_internal_proto_init() {
 			}
 		}
 	}
+	file_ubikom_internal_proto_msgTypes[11].OneofWrappers = []interface{}{
+		(*StoreExportRecord_Header)(nil),
+		(*StoreExportRecord_Message)(nil),
+		(*StoreExportRecord_Policy)(nil),
+		(*StoreExportRecord_Trailer)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_ubikom_internal_proto_rawDesc,
 			NumEnums:      1,
-			NumMessages:   15,
+			NumMessages:   20,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
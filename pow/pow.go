@@ -2,22 +2,36 @@ package pow
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"math/big"
 	"math/rand"
 	"time"
 )
 
+// ctxCheckInterval is how many nonces are tried between the context checks.
+const ctxCheckInterval = 1 << 12
+
 // Compute computes proof of work for the given chunk of data.
 func Compute(data []byte, zeros int) []byte {
+	nonce, _ := ComputeWithContext(context.Background(), data, zeros)
+	return nonce
+}
+
+// ComputeWithContext computes proof of work for the given chunk of data. It returns
+// the context error if the context is done before the proof of work is found.
+func ComputeWithContext(ctx context.Context, data []byte, zeros int) ([]byte, error) {
 	r := rand.New(rand.NewSource(time.Now().Unix()))
 	start := r.Int63()
 	nonce := big.NewInt(start)
-	for {
+	for i := 0; ; i++ {
+		if i%ctxCheckInterval == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		b := bytes.Join([][]byte{data, nonce.Bytes()}, nil)
 		h := sha256.Sum256(b)
 		if verifyLeadingZeros(h[:], zeros) {
-			return nonce.Bytes()
+			return nonce.Bytes(), nil
 		}
 		nonce.Add(nonce, big.NewInt(1))
 	}
@@ -1,7 +1,9 @@
 package pow
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -34,3 +36,18 @@ func Test_GenerateVerify(t *testing.T) {
 	pow[0] += 7
 	assert.False(Verify(data, pow, 10))
 }
+
+func Test_ComputeWithContext(t *testing.T) {
+	assert := assert.New(t)
+
+	data := []byte("hello there")
+	pow, err := ComputeWithContext(context.Background(), data, 10)
+	assert.NoError(err)
+	assert.True(Verify(data, pow, 10))
+
+	// This would take forever.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = ComputeWithContext(ctx, data, 200)
+	assert.Equal(context.DeadlineExceeded, err)
+}
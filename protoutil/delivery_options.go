@@ -0,0 +1,81 @@
+package protoutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/regnull/easyecc/v2"
+	"github.com/regnull/ubikom/pb"
+	"github.com/regnull/ubikom/util"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrInvalidDeliveryOptions = errors.New("invalid delivery options")
+
+// MessageOptions are the optional delivery parameters set by the sender.
+type MessageOptions struct {
+	// NotBefore is the time before which the message is not delivered. Zero means immediately.
+	NotBefore time.Time
+
+	// Expires is the time after which the message is removed. Zero means never.
+	Expires time.Time
+}
+
+// IsZero returns true if no options are set.
+func (o MessageOptions) IsZero() bool {
+	return o.NotBefore.IsZero() && o.Expires.IsZero()
+}
+
+// SetDeliveryOptions signs the delivery options and attaches them to the message.
+func SetDeliveryOptions(privateKey *easyecc.PrivateKey, msg *pb.DMSMessage, opts MessageOptions) error {
+	if !opts.NotBefore.IsZero() && !opts.Expires.IsZero() && !opts.Expires.After(opts.NotBefore) {
+		return ErrInvalidDeliveryOptions
+	}
+	deliveryOptions := &pb.DeliveryOptions{ContentHash: util.Hash256(msg.GetContent())}
+	if !opts.NotBefore.IsZero() {
+		deliveryOptions.NotBefore = opts.NotBefore.UTC().Unix()
+	}
+	if !opts.Expires.IsZero() {
+		deliveryOptions.Expires = opts.Expires.UTC().Unix()
+	}
+	b, err := proto.Marshal(deliveryOptions)
+	if err != nil {
+		return fmt.Errorf("failed to serialize delivery options: %w", err)
+	}
+	sig, err := privateKey.Sign(util.Hash256(b))
+	if err != nil {
+		return fmt.Errorf("failed to sign delivery options: %w", err)
+	}
+	msg.DeliveryOptions = b
+	msg.DeliveryOptionsSignature = &pb.Signature{
+		R: sig.R.Bytes(),
+		S: sig.S.Bytes(),
+	}
+	return nil
+}
+
+// VerifyDeliveryOptions verifies that the delivery options were signed by the sender
+// for this message. It returns nil if the message has no delivery options.
+func VerifyDeliveryOptions(msg *pb.DMSMessage, senderKey *easyecc.PublicKey) (*pb.DeliveryOptions, error) {
+	if len(msg.GetDeliveryOptions()) == 0 {
+		return nil, nil
+	}
+	if !VerifySignature(msg.GetDeliveryOptionsSignature(), senderKey, msg.GetDeliveryOptions()) {
+		return nil, ErrSignatureVerificationFailed
+	}
+	deliveryOptions := &pb.DeliveryOptions{}
+	err := proto.Unmarshal(msg.GetDeliveryOptions(), deliveryOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse delivery options: %w", err)
+	}
+	if !bytes.Equal(deliveryOptions.GetContentHash(), util.Hash256(msg.GetContent())) {
+		return nil, ErrInvalidDeliveryOptions
+	}
+	if deliveryOptions.GetNotBefore() != 0 && deliveryOptions.GetExpires() != 0 &&
+		deliveryOptions.GetExpires() <= deliveryOptions.GetNotBefore() {
+		return nil, ErrInvalidDeliveryOptions
+	}
+	return deliveryOptions, nil
+}
@@ -0,0 +1,56 @@
+package protoutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/regnull/easyecc/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DeliveryOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+
+	msg, err := CreateMessage(aliceKey, []byte("hi bob"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+
+	// No options.
+	opts, err := VerifyDeliveryOptions(msg, aliceKey.PublicKey())
+	assert.NoError(err)
+	assert.Nil(opts)
+
+	now := time.Now()
+	err = SetDeliveryOptions(aliceKey, msg, MessageOptions{
+		NotBefore: now.Add(time.Hour),
+		Expires:   now.Add(2 * time.Hour),
+	})
+	assert.NoError(err)
+	opts, err = VerifyDeliveryOptions(msg, aliceKey.PublicKey())
+	assert.NoError(err)
+	assert.Equal(now.Add(time.Hour).Unix(), opts.GetNotBefore())
+	assert.Equal(now.Add(2*time.Hour).Unix(), opts.GetExpires())
+
+	// Signed by someone else.
+	_, err = VerifyDeliveryOptions(msg, bobKey.PublicKey())
+	assert.ErrorIs(err, ErrSignatureVerificationFailed)
+
+	// Options moved to another message.
+	otherMsg, err := CreateMessage(aliceKey, []byte("hi again"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	otherMsg.DeliveryOptions = msg.DeliveryOptions
+	otherMsg.DeliveryOptionsSignature = msg.DeliveryOptionsSignature
+	_, err = VerifyDeliveryOptions(otherMsg, aliceKey.PublicKey())
+	assert.ErrorIs(err, ErrInvalidDeliveryOptions)
+
+	// Expires before it's delivered.
+	err = SetDeliveryOptions(aliceKey, msg, MessageOptions{
+		NotBefore: now.Add(time.Hour),
+		Expires:   now,
+	})
+	assert.ErrorIs(err, ErrInvalidDeliveryOptions)
+}
@@ -2,10 +2,16 @@ package protoutil
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/regnull/ubikom/pb"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
@@ -13,6 +19,12 @@ const (
 	defaultTimeout = time.Second * 5
 )
 
+// Endpoint schemes. Endpoints without a scheme use plaintext, unless TLS is required.
+const (
+	EndpointSchemeTLS       = "tls://"
+	EndpointSchemePlaintext = "tcp://"
+)
+
 // DumpServiceClientFactory creates DMSDumpService client, which allows sending and receiving of messages.
 type DumpServiceClientFactory interface {
 	// CreateDumpServiceClient returns a new DMSDumpServiceClient to interact with the server
@@ -25,11 +37,32 @@ type DumpServiceClientFactory interface {
 		timeout time.Duration) (pb.DMSDumpServiceClient, func(), error)
 }
 
-type dumpServiceClientFactoryImpl struct{}
+// DumpServiceClientFactoryOptions control how the connections to the dump servers are made.
+type DumpServiceClientFactoryOptions struct {
+	// RootCAs are used to verify the server certificates. If nil, the system CAs are used.
+	RootCAs *x509.CertPool
+
+	// Certificates are presented to the servers that require client authentication.
+	Certificates []tls.Certificate
+
+	// RequireTLS makes the endpoints without a scheme use TLS. Endpoints which
+	// explicitly ask for plaintext are rejected.
+	RequireTLS bool
+}
+
+type dumpServiceClientFactoryImpl struct {
+	opts DumpServiceClientFactoryOptions
+}
 
 // NewDumpServiceClientFactory creates and returns a new DumpServiceClientFactory.
 func NewDumpServiceClientFactory() DumpServiceClientFactory {
-	return &dumpServiceClientFactoryImpl{}
+	return NewDumpServiceClientFactoryWithOptions(DumpServiceClientFactoryOptions{})
+}
+
+// NewDumpServiceClientFactoryWithOptions creates and returns a new DumpServiceClientFactory
+// with the given options.
+func NewDumpServiceClientFactoryWithOptions(opts DumpServiceClientFactoryOptions) DumpServiceClientFactory {
+	return &dumpServiceClientFactoryImpl{opts: opts}
 }
 
 func (f *dumpServiceClientFactoryImpl) CreateDumpServiceClient(ctx context.Context,
@@ -38,16 +71,63 @@ func (f *dumpServiceClientFactoryImpl) CreateDumpServiceClient(ctx context.Conte
 	if timeout == 0 {
 		timeout = defaultTimeout
 	}
+	target, creds, err := DumpServiceCredentials(url, f.opts)
+	if err != nil {
+		return nil, nil, err
+	}
 	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(creds),
 		grpc.WithBlock(),
 	}
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
-	conn, err := grpc.DialContext(ctxWithTimeout, url, opts...)
+	conn, err := grpc.DialContext(ctxWithTimeout, target, opts...)
 	if err != nil {
 		return nil, nil, err
 	}
 	client := pb.NewDMSDumpServiceClient(conn)
 	return client, func() { conn.Close() }, nil
 }
+
+// DumpServiceCredentials parses the endpoint, and returns the address to dial along with
+// the transport credentials to use.
+func DumpServiceCredentials(endpoint string,
+	opts DumpServiceClientFactoryOptions) (string, credentials.TransportCredentials, error) {
+	useTLS := opts.RequireTLS
+	switch {
+	case strings.HasPrefix(endpoint, EndpointSchemeTLS):
+		endpoint = strings.TrimPrefix(endpoint, EndpointSchemeTLS)
+		useTLS = true
+	case strings.HasPrefix(endpoint, EndpointSchemePlaintext):
+		if opts.RequireTLS {
+			return "", nil, fmt.Errorf("plaintext endpoint is not allowed: %s", endpoint)
+		}
+		endpoint = strings.TrimPrefix(endpoint, EndpointSchemePlaintext)
+	case strings.Contains(endpoint, "://"):
+		return "", nil, fmt.Errorf("unsupported endpoint scheme: %s", endpoint)
+	}
+	if !useTLS {
+		return endpoint, insecure.NewCredentials(), nil
+	}
+	return endpoint, credentials.NewTLS(&tls.Config{
+		RootCAs:      opts.RootCAs,
+		Certificates: opts.Certificates,
+		MinVersion:   tls.VersionTLS12,
+	}), nil
+}
+
+// LoadCertPool creates a certificate pool with the certificates from the given PEM files.
+// It can be used to pin the CAs trusted by the client or the server.
+func LoadCertPool(files ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read certificate: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificates found in %s", file)
+		}
+	}
+	return pool, nil
+}
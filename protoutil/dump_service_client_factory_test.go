@@ -0,0 +1,38 @@
+package protoutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DumpServiceCredentials(t *testing.T) {
+	assert := assert.New(t)
+
+	target, creds, err := DumpServiceCredentials("alpha.ubikom.cc:8826", DumpServiceClientFactoryOptions{})
+	assert.NoError(err)
+	assert.Equal("alpha.ubikom.cc:8826", target)
+	assert.Equal("insecure", creds.Info().SecurityProtocol)
+
+	target, creds, err = DumpServiceCredentials("tls://alpha.ubikom.cc:8826", DumpServiceClientFactoryOptions{})
+	assert.NoError(err)
+	assert.Equal("alpha.ubikom.cc:8826", target)
+	assert.Equal("tls", creds.Info().SecurityProtocol)
+
+	target, creds, err = DumpServiceCredentials("tcp://alpha.ubikom.cc:8826", DumpServiceClientFactoryOptions{})
+	assert.NoError(err)
+	assert.Equal("alpha.ubikom.cc:8826", target)
+	assert.Equal("insecure", creds.Info().SecurityProtocol)
+
+	// No scheme means TLS if it's required.
+	target, creds, err = DumpServiceCredentials("alpha.ubikom.cc:8826", DumpServiceClientFactoryOptions{RequireTLS: true})
+	assert.NoError(err)
+	assert.Equal("alpha.ubikom.cc:8826", target)
+	assert.Equal("tls", creds.Info().SecurityProtocol)
+
+	_, _, err = DumpServiceCredentials("tcp://alpha.ubikom.cc:8826", DumpServiceClientFactoryOptions{RequireTLS: true})
+	assert.Error(err)
+
+	_, _, err = DumpServiceCredentials("http://alpha.ubikom.cc:8826", DumpServiceClientFactoryOptions{})
+	assert.Error(err)
+}
@@ -0,0 +1,175 @@
+package protoutil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/regnull/easyecc/v2"
+	"github.com/regnull/ubikom/pb"
+	"github.com/regnull/ubikom/util"
+	"google.golang.org/protobuf/proto"
+)
+
+// MaxEnvelopeReceivers is the maximum number of receivers of one envelope.
+const MaxEnvelopeReceivers = 100
+
+const contentKeySize = 32
+
+var (
+	ErrNotEnvelope = errors.New("not an envelope")
+	ErrNotReceiver = errors.New("not a receiver of the envelope")
+)
+
+// Recipient is the receiver of an envelope.
+type Recipient struct {
+	Name string
+	Key  *easyecc.PublicKey
+}
+
+// CreateEnvelope creates a new signed MT_ENVELOPE message. The body is encrypted once,
+// with a random content key, and the content key is encrypted for each recipient.
+// The receiver of the returned message is not set, since it goes to many.
+func CreateEnvelope(privateKey *easyecc.PrivateKey, body []byte, sender string,
+	recipients []Recipient) (*pb.DMSMessage, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no recipients")
+	}
+	if len(recipients) > MaxEnvelopeReceivers {
+		return nil, fmt.Errorf("too many recipients")
+	}
+	contentKey := make([]byte, contentKeySize)
+	_, err := io.ReadFull(rand.Reader, contentKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate content key: %w", err)
+	}
+	encryptedBody, err := sealContent(contentKey, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt message: %w", err)
+	}
+	envelope := &pb.Envelope{Body: encryptedBody}
+	for _, recipient := range recipients {
+		wrappedKey, err := privateKey.Encrypt(contentKey, recipient.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt content key: %w", err)
+		}
+		envelope.Key = append(envelope.Key, &pb.WrappedKey{
+			Receiver: recipient.Name,
+			Key:      wrappedKey,
+		})
+	}
+	content, err := proto.Marshal(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize envelope: %w", err)
+	}
+
+	sig, err := privateKey.Sign(util.Hash256(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign message, %w", err)
+	}
+	return &pb.DMSMessage{
+		Sender:  sender,
+		Content: content,
+		Signature: &pb.Signature{
+			R: sig.R.Bytes(),
+			S: sig.S.Bytes(),
+		},
+		CryptoContext: &pb.CryptoContext{
+			EllipticCurve: CurveToProto(privateKey.Curve()),
+			EcdhVersion:   2,
+			EcdsaVersion:  1,
+		},
+		Type: pb.MessageType_MT_ENVELOPE,
+	}, nil
+}
+
+// EnvelopeReceivers returns the names of the envelope receivers.
+func EnvelopeReceivers(msg *pb.DMSMessage) ([]string, error) {
+	envelope, err := parseEnvelope(msg)
+	if err != nil {
+		return nil, err
+	}
+	var receivers []string
+	for _, key := range envelope.GetKey() {
+		receivers = append(receivers, key.GetReceiver())
+	}
+	return receivers, nil
+}
+
+// OpenEnvelope decrypts the envelope content for the message receiver. The sender's
+// signature must be verified by the caller.
+func OpenEnvelope(privateKey *easyecc.PrivateKey, msg *pb.DMSMessage,
+	senderKey *easyecc.PublicKey) ([]byte, error) {
+	envelope, err := parseEnvelope(msg)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range envelope.GetKey() {
+		if key.GetReceiver() != msg.GetReceiver() {
+			continue
+		}
+		contentKey, err := privateKey.Decrypt(key.GetKey(), senderKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt content key")
+		}
+		return openContent(contentKey, envelope.GetBody())
+	}
+	return nil, ErrNotReceiver
+}
+
+func parseEnvelope(msg *pb.DMSMessage) (*pb.Envelope, error) {
+	if msg.GetType() != pb.MessageType_MT_ENVELOPE {
+		return nil, ErrNotEnvelope
+	}
+	envelope := &pb.Envelope{}
+	err := proto.Unmarshal(msg.GetContent(), envelope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse envelope: %w", err)
+	}
+	return envelope, nil
+}
+
+// sealContent encrypts the content using AES-256-GCM, the nonce is prepended to the result.
+func sealContent(key []byte, content []byte) ([]byte, error) {
+	gcm, err := newContentCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, content, nil), nil
+}
+
+// openContent decrypts the content encrypted by sealContent.
+func openContent(key []byte, content []byte) ([]byte, error) {
+	gcm, err := newContentCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) < gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid content")
+	}
+	nonce, ciphertext := content[:gcm.NonceSize()], content[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt content")
+	}
+	return plaintext, nil
+}
+
+func newContentCipher(key []byte) (cipher.AEAD, error) {
+	if len(key) != contentKeySize {
+		return nil, fmt.Errorf("invalid content key")
+	}
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(c)
+}
@@ -0,0 +1,68 @@
+package protoutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/regnull/easyecc/v2"
+	bcmocks "github.com/regnull/ubikom/bc/mocks"
+	"github.com/regnull/ubikom/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/protobuf/proto"
+)
+
+func Test_Envelope(t *testing.T) {
+	assert := assert.New(t)
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.SECP256K1)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.SECP256K1)
+	assert.NoError(err)
+	charlieKey, err := easyecc.NewPrivateKey(easyecc.SECP256K1)
+	assert.NoError(err)
+
+	msg, err := CreateEnvelope(aliceKey, []byte("hi everyone"), "alice", []Recipient{
+		{Name: "bob", Key: bobKey.PublicKey()},
+		{Name: "charlie", Key: charlieKey.PublicKey()},
+	})
+	assert.NoError(err)
+	assert.Equal(pb.MessageType_MT_ENVELOPE, msg.GetType())
+	assert.Empty(msg.GetReceiver())
+
+	receivers, err := EnvelopeReceivers(msg)
+	assert.NoError(err)
+	assert.Equal([]string{"bob", "charlie"}, receivers)
+
+	ctx := context.Background()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
+	bchain.EXPECT().PublicKeyByCurve(ctx, "alice", easyecc.SECP256K1).Return(aliceKey.PublicKey(), nil)
+
+	for name, key := range map[string]*easyecc.PrivateKey{"bob": bobKey, "charlie": charlieKey} {
+		received := proto.Clone(msg).(*pb.DMSMessage)
+		received.Receiver = name
+		content, err := DecryptMessage(ctx, bchain, key, received)
+		assert.NoError(err)
+		assert.Equal("hi everyone", content)
+	}
+
+	// Charlie can't pretend to be Bob.
+	received := proto.Clone(msg).(*pb.DMSMessage)
+	received.Receiver = "bob"
+	_, err = OpenEnvelope(charlieKey, received, aliceKey.PublicKey())
+	assert.Error(err)
+
+	// Dave is not a receiver.
+	daveKey, err := easyecc.NewPrivateKey(easyecc.SECP256K1)
+	assert.NoError(err)
+	received.Receiver = "dave"
+	_, err = OpenEnvelope(daveKey, received, aliceKey.PublicKey())
+	assert.ErrorIs(err, ErrNotReceiver)
+
+	_, err = EnvelopeReceivers(&pb.DMSMessage{})
+	assert.ErrorIs(err, ErrNotEnvelope)
+
+	_, err = CreateEnvelope(aliceKey, []byte("hi"), "alice", nil)
+	assert.Error(err)
+}
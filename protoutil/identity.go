@@ -0,0 +1,229 @@
+package protoutil
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/regnull/easyecc/v2"
+	"github.com/regnull/ubikom/pb"
+	"github.com/regnull/ubikom/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+)
+
+// Operations the identity proof can be created for.
+const (
+	IdentityOperationReceive   = "receive"
+	IdentityOperationSubscribe = "subscribe"
+	IdentityOperationAck       = "ack"
+	IdentityOperationRelease   = "release"
+	IdentityOperationSetPolicy = "set_policy"
+
+	IdentityOperationAdminListMailboxes   = "admin.list_mailboxes"
+	IdentityOperationAdminPurgeMailbox    = "admin.purge_mailbox"
+	IdentityOperationAdminDeleteMessage   = "admin.delete_message"
+	IdentityOperationAdminGetStorageUsage = "admin.get_storage_usage"
+	IdentityOperationAdminRunGC           = "admin.run_gc"
+)
+
+const (
+	identityNonceLength         = 16
+	legacyIdentityContentLength = 8
+	challengeTTL                = time.Minute
+	defaultNonceCacheCapacity   = 100000
+	defaultMaxClockSkew         = 10 * time.Second
+)
+
+var (
+	ErrInvalidNonce        = errors.New("invalid nonce")
+	ErrNonceReused         = errors.New("nonce was already used")
+	ErrUnknownChallenge    = errors.New("unknown or expired challenge")
+	ErrAudienceMismatch    = errors.New("identity proof is intended for another server")
+	ErrOperationMismatch   = errors.New("identity proof is intended for another operation")
+	ErrLegacyIdentityProof = errors.New("legacy identity proof is not allowed")
+)
+
+// CreateIdentityProof creates an identity proof which is bound to the nonce, the server
+// (audience) and the operation. If nonce is nil, a random one is generated.
+func CreateIdentityProof(key *easyecc.PrivateKey, nonce []byte, audience string,
+	operation string, timestamp time.Time) (*pb.Signed, error) {
+	if nonce == nil {
+		nonce = make([]byte, identityNonceLength)
+		_, err := rand.Read(nonce)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate nonce: %w", err)
+		}
+	}
+	content, err := proto.Marshal(&pb.IdentityProofContent{
+		Nonce:     nonce,
+		Timestamp: timestamp.UTC().Unix(),
+		Audience:  audience,
+		Operation: operation,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize identity proof: %w", err)
+	}
+	return CreateSigned(key, content)
+}
+
+// IdentityProofOptions control how the identity proof is created by IdentityProofForServer.
+type IdentityProofOptions struct {
+	// Audience is the identity of the server the proof is intended for. It must come
+	// from the client, see EndpointAudience, the server ID reported by the server
+	// itself is not trusted.
+	Audience string
+
+	// AllowLegacy allows falling back to the legacy identity proof if the server
+	// doesn't issue challenges. Legacy proofs are not bound to the server or the
+	// operation, so they can be replayed.
+	AllowLegacy bool
+}
+
+// IdentityProofForServer asks the server for a challenge, and uses it to create
+// an identity proof for the given operation. If the server doesn't issue challenges,
+// the legacy identity proof is created instead, but only if opts.AllowLegacy is set.
+func IdentityProofForServer(ctx context.Context, client pb.DMSDumpServiceClient,
+	key *easyecc.PrivateKey, operation string, opts IdentityProofOptions) (*pb.Signed, error) {
+	res, err := client.GetChallenge(ctx, &pb.GetChallengeRequest{})
+	if util.ErrEqualCode(err, codes.Unimplemented) {
+		if !opts.AllowLegacy {
+			return nil, fmt.Errorf("%w: the server doesn't issue challenges", ErrLegacyIdentityProof)
+		}
+		return IdentityProof(key, time.Now())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get challenge: %w", err)
+	}
+	if res.GetServerId() != "" && res.GetServerId() != opts.Audience {
+		// The proof would be rejected anyway, fail early with a clear error.
+		return nil, fmt.Errorf("%w: expected %q, the server is %q", ErrAudienceMismatch,
+			opts.Audience, res.GetServerId())
+	}
+	// Use the server's time, so that the clock skew doesn't matter.
+	timestamp := time.Now()
+	if res.GetTimestamp() != 0 {
+		timestamp = time.Unix(res.GetTimestamp(), 0)
+	}
+	return CreateIdentityProof(key, res.GetNonce(), opts.Audience, operation, timestamp)
+}
+
+// EndpointAudience returns the audience for the server at the given endpoint, which
+// is the endpoint without the scheme.
+func EndpointAudience(endpoint string) string {
+	endpoint = strings.TrimPrefix(endpoint, EndpointSchemeTLS)
+	return strings.TrimPrefix(endpoint, EndpointSchemePlaintext)
+}
+
+// IsLegacyIdentityProof returns true if the identity proof was created by IdentityProof,
+// and contains only the timestamp.
+func IsLegacyIdentityProof(signed *pb.Signed) bool {
+	return len(signed.GetContent()) == legacyIdentityContentLength
+}
+
+// IdentityVerifierOptions control the identity proof verification.
+type IdentityVerifierOptions struct {
+	// Audience is the identity of this server. If empty, the audience is not checked.
+	Audience string
+
+	// MaxClockSkew is the maximum allowed difference between the proof timestamp and
+	// the current time.
+	MaxClockSkew time.Duration
+
+	// AllowLegacy controls if the legacy identity proofs are accepted. Legacy proofs are
+	// not bound to nonce, so they can be replayed within MaxClockSkew.
+	AllowLegacy bool
+
+	// RequireChallenge controls if the nonce must be issued by IssueChallenge.
+	RequireChallenge bool
+
+	// NonceCacheCapacity is the maximum number of nonces (and challenges) remembered.
+	NonceCacheCapacity int
+}
+
+// IdentityVerifier verifies identity proofs, and makes sure they are not replayed.
+type IdentityVerifier struct {
+	opts       IdentityVerifierOptions
+	usedNonces *NonceCache
+	challenges *NonceCache
+}
+
+// NewIdentityVerifier creates a new IdentityVerifier.
+func NewIdentityVerifier(opts IdentityVerifierOptions) *IdentityVerifier {
+	if opts.MaxClockSkew == 0 {
+		opts.MaxClockSkew = defaultMaxClockSkew
+	}
+	if opts.NonceCacheCapacity == 0 {
+		opts.NonceCacheCapacity = defaultNonceCacheCapacity
+	}
+	return &IdentityVerifier{
+		opts: opts,
+		// A proof is only valid while its timestamp is within the allowed skew from now,
+		// so we only need to remember the nonce for that long.
+		usedNonces: NewNonceCache(opts.NonceCacheCapacity, 2*opts.MaxClockSkew),
+		challenges: NewNonceCache(opts.NonceCacheCapacity, challengeTTL),
+	}
+}
+
+// IssueChallenge returns a new nonce to be used in the identity proof. It returns
+// ErrNonceCacheFull if there are too many outstanding challenges.
+func (v *IdentityVerifier) IssueChallenge(now time.Time) ([]byte, error) {
+	nonce := make([]byte, identityNonceLength)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	err = v.challenges.Add(nonce, now)
+	if err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+// Verify verifies the identity proof for the given operation. It returns
+// ErrNonceCacheFull if there are too many nonces to remember, in which case the
+// proof can't be accepted without the risk of replay.
+func (v *IdentityVerifier) Verify(signed *pb.Signed, operation string,
+	curve easyecc.EllipticCurve, now time.Time) error {
+	if IsLegacyIdentityProof(signed) {
+		if !v.opts.AllowLegacy {
+			return ErrLegacyIdentityProof
+		}
+		return VerifyIdentity(signed, now, v.opts.MaxClockSkew.Seconds(), curve)
+	}
+
+	key, err := easyecc.NewPublicKeyFromCompressedBytes(curve, signed.GetKey())
+	if err != nil {
+		return err
+	}
+	if !VerifySignature(signed.GetSignature(), key, signed.GetContent()) {
+		return ErrSignatureVerificationFailed
+	}
+
+	content := &pb.IdentityProofContent{}
+	err = proto.Unmarshal(signed.GetContent(), content)
+	if err != nil {
+		return fmt.Errorf("failed to parse identity proof: %w", err)
+	}
+	if len(content.GetNonce()) < identityNonceLength {
+		return ErrInvalidNonce
+	}
+	d := now.UTC().Unix() - content.GetTimestamp()
+	if math.Abs(float64(d)) > v.opts.MaxClockSkew.Seconds() {
+		return ErrTimeDifferenceTooLarge
+	}
+	if v.opts.Audience != "" && content.GetAudience() != v.opts.Audience {
+		return ErrAudienceMismatch
+	}
+	if content.GetOperation() != operation {
+		return ErrOperationMismatch
+	}
+	if v.opts.RequireChallenge && !v.challenges.Take(content.GetNonce(), now) {
+		return ErrUnknownChallenge
+	}
+	return v.usedNonces.Add(content.GetNonce(), now)
+}
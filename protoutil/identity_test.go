@@ -0,0 +1,193 @@
+package protoutil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/regnull/easyecc/v2"
+	"github.com/regnull/ubikom/pb"
+	pbmocks "github.com/regnull/ubikom/pb/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func Test_IdentityVerifier(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+
+	verifier := NewIdentityVerifier(IdentityVerifierOptions{Audience: "server1"})
+	now := time.Now()
+
+	proof, err := CreateIdentityProof(key, nil, "server1", IdentityOperationReceive, now)
+	assert.NoError(err)
+	assert.False(IsLegacyIdentityProof(proof))
+	assert.NoError(verifier.Verify(proof, IdentityOperationReceive, easyecc.P256, now))
+
+	// The same proof can't be used twice.
+	assert.ErrorIs(verifier.Verify(proof, IdentityOperationReceive, easyecc.P256, now), ErrNonceReused)
+
+	proof, err = CreateIdentityProof(key, nil, "server2", IdentityOperationReceive, now)
+	assert.NoError(err)
+	assert.ErrorIs(verifier.Verify(proof, IdentityOperationReceive, easyecc.P256, now), ErrAudienceMismatch)
+
+	proof, err = CreateIdentityProof(key, nil, "server1", IdentityOperationAck, now)
+	assert.NoError(err)
+	assert.ErrorIs(verifier.Verify(proof, IdentityOperationReceive, easyecc.P256, now), ErrOperationMismatch)
+
+	proof, err = CreateIdentityProof(key, nil, "server1", IdentityOperationReceive, now.Add(-time.Minute))
+	assert.NoError(err)
+	assert.ErrorIs(verifier.Verify(proof, IdentityOperationReceive, easyecc.P256, now), ErrTimeDifferenceTooLarge)
+
+	proof, err = CreateIdentityProof(key, []byte("short"), "server1", IdentityOperationReceive, now)
+	assert.NoError(err)
+	assert.ErrorIs(verifier.Verify(proof, IdentityOperationReceive, easyecc.P256, now), ErrInvalidNonce)
+
+	// Tamper with the content.
+	proof, err = CreateIdentityProof(key, nil, "server1", IdentityOperationReceive, now)
+	assert.NoError(err)
+	content := &pb.IdentityProofContent{}
+	assert.NoError(proto.Unmarshal(proof.Content, content))
+	content.Operation = IdentityOperationAck
+	proof.Content, err = proto.Marshal(content)
+	assert.NoError(err)
+	assert.ErrorIs(verifier.Verify(proof, IdentityOperationAck, easyecc.P256, now), ErrSignatureVerificationFailed)
+}
+
+func Test_IdentityVerifier_Legacy(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	now := time.Now()
+	proof, err := IdentityProof(key, now)
+	assert.NoError(err)
+	assert.True(IsLegacyIdentityProof(proof))
+
+	verifier := NewIdentityVerifier(IdentityVerifierOptions{AllowLegacy: true})
+	assert.NoError(verifier.Verify(proof, IdentityOperationReceive, easyecc.P256, now))
+
+	verifier = NewIdentityVerifier(IdentityVerifierOptions{})
+	assert.ErrorIs(verifier.Verify(proof, IdentityOperationReceive, easyecc.P256, now), ErrLegacyIdentityProof)
+}
+
+func Test_IdentityVerifier_Challenge(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	now := time.Now()
+	verifier := NewIdentityVerifier(IdentityVerifierOptions{RequireChallenge: true})
+
+	// Client-generated nonce is not accepted.
+	proof, err := CreateIdentityProof(key, nil, "", IdentityOperationReceive, now)
+	assert.NoError(err)
+	assert.ErrorIs(verifier.Verify(proof, IdentityOperationReceive, easyecc.P256, now), ErrUnknownChallenge)
+
+	nonce, err := verifier.IssueChallenge(now)
+	assert.NoError(err)
+	proof, err = CreateIdentityProof(key, nonce, "", IdentityOperationReceive, now)
+	assert.NoError(err)
+	assert.NoError(verifier.Verify(proof, IdentityOperationReceive, easyecc.P256, now))
+
+	// Challenge can be used only once.
+	proof, err = CreateIdentityProof(key, nonce, "", IdentityOperationReceive, now)
+	assert.NoError(err)
+	assert.ErrorIs(verifier.Verify(proof, IdentityOperationReceive, easyecc.P256, now), ErrUnknownChallenge)
+
+	// Challenge expires.
+	nonce, err = verifier.IssueChallenge(now)
+	assert.NoError(err)
+	later := now.Add(2 * challengeTTL)
+	proof, err = CreateIdentityProof(key, nonce, "", IdentityOperationReceive, later)
+	assert.NoError(err)
+	assert.ErrorIs(verifier.Verify(proof, IdentityOperationReceive, easyecc.P256, later), ErrUnknownChallenge)
+}
+
+func Test_IdentityVerifier_CacheFull(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	now := time.Now()
+	verifier := NewIdentityVerifier(IdentityVerifierOptions{NonceCacheCapacity: 2})
+
+	// The used nonces are not evicted until they expire, so the captured proof
+	// can't be replayed by flooding the verifier with fresh ones.
+	captured, err := CreateIdentityProof(key, nil, "", IdentityOperationReceive, now)
+	assert.NoError(err)
+	assert.NoError(verifier.Verify(captured, IdentityOperationReceive, easyecc.P256, now))
+	proof, err := CreateIdentityProof(key, nil, "", IdentityOperationReceive, now)
+	assert.NoError(err)
+	assert.NoError(verifier.Verify(proof, IdentityOperationReceive, easyecc.P256, now))
+	proof, err = CreateIdentityProof(key, nil, "", IdentityOperationReceive, now)
+	assert.NoError(err)
+	assert.ErrorIs(verifier.Verify(proof, IdentityOperationReceive, easyecc.P256, now), ErrNonceCacheFull)
+	assert.ErrorIs(verifier.Verify(captured, IdentityOperationReceive, easyecc.P256, now), ErrNonceReused)
+
+	// Same for the challenges.
+	_, err = verifier.IssueChallenge(now)
+	assert.NoError(err)
+	_, err = verifier.IssueChallenge(now)
+	assert.NoError(err)
+	_, err = verifier.IssueChallenge(now)
+	assert.ErrorIs(err, ErrNonceCacheFull)
+	_, err = verifier.IssueChallenge(now.Add(2 * challengeTTL))
+	assert.NoError(err)
+}
+
+func Test_IdentityProofForServer(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	ctx := context.Background()
+
+	client := new(pbmocks.MockDMSDumpServiceClient)
+	client.EXPECT().GetChallenge(ctx, mock.Anything).Return(&pb.GetChallengeResponse{
+		Nonce:     []byte("0123456789abcdef"),
+		ServerId:  "server1",
+		Timestamp: time.Now().Unix(),
+	}, nil).Once()
+	proof, err := IdentityProofForServer(ctx, client, key, IdentityOperationReceive,
+		IdentityProofOptions{Audience: "server1"})
+	assert.NoError(err)
+	verifier := NewIdentityVerifier(IdentityVerifierOptions{Audience: "server1"})
+	assert.NoError(verifier.Verify(proof, IdentityOperationReceive, easyecc.P256, time.Now()))
+
+	// The server claims to be another server.
+	client.EXPECT().GetChallenge(ctx, mock.Anything).Return(&pb.GetChallengeResponse{
+		Nonce:     []byte("0123456789abcdef"),
+		ServerId:  "server2",
+		Timestamp: time.Now().Unix(),
+	}, nil).Once()
+	_, err = IdentityProofForServer(ctx, client, key, IdentityOperationReceive,
+		IdentityProofOptions{Audience: "server1"})
+	assert.ErrorIs(err, ErrAudienceMismatch)
+
+	// Old server, the legacy proof is only created if allowed.
+	client.EXPECT().GetChallenge(ctx, mock.Anything).Return(nil,
+		status.Error(codes.Unimplemented, "not implemented")).Twice()
+	_, err = IdentityProofForServer(ctx, client, key, IdentityOperationReceive,
+		IdentityProofOptions{Audience: "server1"})
+	assert.ErrorIs(err, ErrLegacyIdentityProof)
+	proof, err = IdentityProofForServer(ctx, client, key, IdentityOperationReceive,
+		IdentityProofOptions{Audience: "server1", AllowLegacy: true})
+	assert.NoError(err)
+	assert.True(IsLegacyIdentityProof(proof))
+
+	client.AssertExpectations(t)
+}
+
+func Test_EndpointAudience(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("dump.ubikom.cc:8826", EndpointAudience("tls://dump.ubikom.cc:8826"))
+	assert.Equal("localhost:8826", EndpointAudience("tcp://localhost:8826"))
+	assert.Equal("localhost:8826", EndpointAudience("localhost:8826"))
+}
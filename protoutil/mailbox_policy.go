@@ -0,0 +1,70 @@
+package protoutil
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/regnull/easyecc/v2"
+	"github.com/regnull/ubikom/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxMailboxPolicyPowStrength limits the proof of work the receiver can demand,
+// so that the policy can't make sending impossible.
+const maxMailboxPolicyPowStrength = 32
+
+var ErrInvalidMailboxPolicy = errors.New("invalid mailbox policy")
+
+// CreateMailboxPolicy signs the mailbox policy with the receiver's key. The policy
+// timestamp is set to the given time.
+func CreateMailboxPolicy(privateKey *easyecc.PrivateKey, policy *pb.MailboxPolicy,
+	timestamp time.Time) (*pb.Signed, error) {
+	policy = proto.Clone(policy).(*pb.MailboxPolicy)
+	policy.Timestamp = timestamp.UTC().Unix()
+	if err := validateMailboxPolicy(policy); err != nil {
+		return nil, err
+	}
+	content, err := proto.Marshal(policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize mailbox policy: %w", err)
+	}
+	return CreateSigned(privateKey, content)
+}
+
+// VerifyMailboxPolicy verifies that the mailbox policy is signed by its key, and
+// returns its content.
+func VerifyMailboxPolicy(signed *pb.Signed, curve easyecc.EllipticCurve) (*pb.MailboxPolicy, error) {
+	key, err := easyecc.NewPublicKeyFromCompressedBytes(curve, signed.GetKey())
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	if !VerifySignature(signed.GetSignature(), key, signed.GetContent()) {
+		return nil, ErrSignatureVerificationFailed
+	}
+	policy := &pb.MailboxPolicy{}
+	err = proto.Unmarshal(signed.GetContent(), policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse mailbox policy: %w", err)
+	}
+	if err := validateMailboxPolicy(policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func validateMailboxPolicy(policy *pb.MailboxPolicy) error {
+	switch policy.GetStrangers() {
+	case pb.StrangerPolicy_SP_ALLOW, pb.StrangerPolicy_SP_REJECT:
+	case pb.StrangerPolicy_SP_REQUIRE_POW:
+		if policy.GetPowStrength() <= 0 {
+			return ErrInvalidMailboxPolicy
+		}
+	default:
+		return ErrInvalidMailboxPolicy
+	}
+	if policy.GetPowStrength() < 0 || policy.GetPowStrength() > maxMailboxPolicyPowStrength {
+		return ErrInvalidMailboxPolicy
+	}
+	return nil
+}
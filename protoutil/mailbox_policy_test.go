@@ -0,0 +1,43 @@
+package protoutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/regnull/easyecc/v2"
+	"github.com/regnull/ubikom/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MailboxPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	now := time.Now()
+
+	signed, err := CreateMailboxPolicy(key, &pb.MailboxPolicy{
+		Allow:       []string{"alice"},
+		Strangers:   pb.StrangerPolicy_SP_REQUIRE_POW,
+		PowStrength: 10,
+	}, now)
+	assert.NoError(err)
+	policy, err := VerifyMailboxPolicy(signed, easyecc.P256)
+	assert.NoError(err)
+	assert.Equal([]string{"alice"}, policy.GetAllow())
+	assert.Equal(now.Unix(), policy.GetTimestamp())
+
+	// Tampered policy is rejected.
+	signed.Content = append(signed.Content, 0)
+	_, err = VerifyMailboxPolicy(signed, easyecc.P256)
+	assert.ErrorIs(err, ErrSignatureVerificationFailed)
+
+	// Proof of work strength must be set, and must be reasonable.
+	_, err = CreateMailboxPolicy(key, &pb.MailboxPolicy{Strangers: pb.StrangerPolicy_SP_REQUIRE_POW}, now)
+	assert.ErrorIs(err, ErrInvalidMailboxPolicy)
+	_, err = CreateMailboxPolicy(key, &pb.MailboxPolicy{
+		Strangers:   pb.StrangerPolicy_SP_REQUIRE_POW,
+		PowStrength: 100,
+	}, now)
+	assert.ErrorIs(err, ErrInvalidMailboxPolicy)
+}
@@ -2,7 +2,9 @@ package protoutil
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/regnull/easyecc/v2"
 	"github.com/regnull/ubikom/bc"
@@ -10,9 +12,24 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// maxPowStrength limits the proof of work the client agrees to compute, so that
+// the server can't keep it busy forever.
+const maxPowStrength = 32
+
+var ErrPowTooStrong = errors.New("required proof of work is too strong")
+
 type MessageSender interface {
 	Send(ctx context.Context, privateKey *easyecc.PrivateKey, body []byte,
 		sender, receiver string) error
+
+	// SendWithOptions sends the message with the given delivery options.
+	SendWithOptions(ctx context.Context, privateKey *easyecc.PrivateKey, body []byte,
+		sender, receiver string, opts MessageOptions) error
+
+	// SendToMany sends the message to several receivers. The body is encrypted once,
+	// and one request is sent to each of the receivers' dump servers.
+	SendToMany(ctx context.Context, privateKey *easyecc.PrivateKey, body []byte,
+		sender string, receivers []string, opts MessageOptions) error
 }
 
 type messageSenderImpl struct {
@@ -28,6 +45,11 @@ func NewMessageSender(dumpServiceClientFactory DumpServiceClientFactory, bchain
 
 func (s *messageSenderImpl) Send(ctx context.Context, privateKey *easyecc.PrivateKey, body []byte,
 	sender, receiver string) error {
+	return s.SendWithOptions(ctx, privateKey, body, sender, receiver, MessageOptions{})
+}
+
+func (s *messageSenderImpl) SendWithOptions(ctx context.Context, privateKey *easyecc.PrivateKey, body []byte,
+	sender, receiver string, opts MessageOptions) error {
 	// Get receiver's public key.
 	receiverKey, err := s.bchain.PublicKeyByCurve(ctx, receiver, privateKey.Curve())
 	if err != nil {
@@ -46,6 +68,12 @@ func (s *messageSenderImpl) Send(ctx context.Context, privateKey *easyecc.Privat
 	if err != nil {
 		return err
 	}
+	if !opts.IsZero() {
+		err = SetDeliveryOptions(privateKey, msg, opts)
+		if err != nil {
+			return err
+		}
+	}
 	client, cleanup, err := s.dumpServiceClientFactory.CreateDumpServiceClient(ctx, endpoint, 0)
 	if err != nil {
 		return err
@@ -53,10 +81,116 @@ func (s *messageSenderImpl) Send(ctx context.Context, privateKey *easyecc.Privat
 	if cleanup != nil {
 		defer cleanup()
 	}
-	_, err = client.Send(ctx, &pb.SendRequest{Message: msg})
+	err = SendToDumpServer(ctx, client, msg)
 	if err != nil {
 		return fmt.Errorf("failed to send message: %w", err)
 	}
 	log.Debug().Msg("sent message successfully")
 	return nil
 }
+
+func (s *messageSenderImpl) SendToMany(ctx context.Context, privateKey *easyecc.PrivateKey, body []byte,
+	sender string, receivers []string, opts MessageOptions) error {
+	// Group the receivers by their dump servers.
+	var recipients []Recipient
+	var endpoints []string
+	byEndpoint := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, receiver := range receivers {
+		if seen[receiver] {
+			continue
+		}
+		seen[receiver] = true
+		receiverKey, err := s.bchain.PublicKeyByCurve(ctx, receiver, privateKey.Curve())
+		if err != nil {
+			return fmt.Errorf("failed to get public key for %s: %w", receiver, err)
+		}
+		endpoint, err := s.bchain.Endpoint(ctx, receiver)
+		if err != nil {
+			return fmt.Errorf("failed to get address for %s: %w", receiver, err)
+		}
+		recipients = append(recipients, Recipient{Name: receiver, Key: receiverKey})
+		if _, ok := byEndpoint[endpoint]; !ok {
+			endpoints = append(endpoints, endpoint)
+		}
+		byEndpoint[endpoint] = append(byEndpoint[endpoint], receiver)
+	}
+
+	msg, err := CreateEnvelope(privateKey, body, sender, recipients)
+	if err != nil {
+		return err
+	}
+	if !opts.IsZero() {
+		err = SetDeliveryOptions(privateKey, msg, opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Keep going if one of the servers fails, so that the others get the message.
+	var failed []string
+	var firstErr error
+	for _, endpoint := range endpoints {
+		err := s.sendEnvelope(ctx, endpoint, msg, byEndpoint[endpoint])
+		if err != nil {
+			log.Debug().Err(err).Str("address", endpoint).Msg("failed to send message")
+			failed = append(failed, byEndpoint[endpoint]...)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if firstErr != nil {
+		return fmt.Errorf("failed to send message to %s: %w", strings.Join(failed, ", "), firstErr)
+	}
+	log.Debug().Int("receivers", len(recipients)).Int("servers", len(endpoints)).Msg("sent message successfully")
+	return nil
+}
+
+func (s *messageSenderImpl) sendEnvelope(ctx context.Context, endpoint string, msg *pb.DMSMessage,
+	receivers []string) error {
+	client, cleanup, err := s.dumpServiceClientFactory.CreateDumpServiceClient(ctx, endpoint, 0)
+	if err != nil {
+		return err
+	}
+	if cleanup != nil {
+		defer cleanup()
+	}
+	return sendRequestWithPow(ctx, client, &pb.SendRequest{Message: msg, Receiver: receivers})
+}
+
+// SendToDumpServer sends the message to the dump server. If the server requires proof
+// of work, it is computed and the message is sent again.
+func SendToDumpServer(ctx context.Context, client pb.DMSDumpServiceClient, msg *pb.DMSMessage) error {
+	return sendRequestWithPow(ctx, client, &pb.SendRequest{Message: msg})
+}
+
+// RelayToDumpServer is like SendToDumpServer, but marks the message as relayed, so
+// that the receiving server doesn't relay it again.
+func RelayToDumpServer(ctx context.Context, client pb.DMSDumpServiceClient, msg *pb.DMSMessage) error {
+	return sendRequestWithPow(ctx, client, &pb.SendRequest{Message: msg, RelayHops: 1})
+}
+
+func sendRequestWithPow(ctx context.Context, client pb.DMSDumpServiceClient, req *pb.SendRequest) error {
+	_, err := client.Send(ctx, req)
+	computed := 0
+	for {
+		// The server wants proof of work, compute it and try again. The receiver's
+		// policy may require stronger proof than the server, so keep going for as long
+		// as the requirement goes up.
+		strength, ok := PowRequirementFromError(err)
+		if !ok || strength <= computed {
+			return err
+		}
+		if strength > maxPowStrength {
+			return fmt.Errorf("%w: %d", ErrPowTooStrong, strength)
+		}
+		log.Debug().Int("strength", strength).Msg("computing proof of work")
+		req.Pow, err = ComputeMessagePow(ctx, req.GetMessage(), strength)
+		if err != nil {
+			return err
+		}
+		_, err = client.Send(ctx, req)
+		computed = strength
+	}
+}
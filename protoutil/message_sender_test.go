@@ -2,6 +2,7 @@ package protoutil
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
 )
 
 func Test_MessageSender(t *testing.T) {
@@ -52,3 +54,124 @@ func Test_MessageSender(t *testing.T) {
 	dscfactory.AssertExpectations(t)
 	dsclient.AssertExpectations(t)
 }
+
+func Test_MessageSender_Pow(t *testing.T) {
+	assert := assert.New(t)
+
+	bchain := new(bcmocks.MockBlockchain)
+	dscfactory := new(pumocks.MockDumpServiceClientFactory)
+	dsclient := new(pbmocks.MockDMSDumpServiceClient)
+
+	privateKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+
+	receiverPrivateKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+
+	const powStrength = 10
+	ctx := context.Background()
+	bchain.EXPECT().PublicKeyByCurve(ctx, "bob",
+		easyecc.P256).Return(receiverPrivateKey.PublicKey(), nil)
+	bchain.EXPECT().Endpoint(ctx, "bob").Return("bob's endpoint", nil)
+	dscfactory.EXPECT().CreateDumpServiceClient(ctx, "bob's endpoint", time.Duration(0)).Return(dsclient, nil, nil)
+	// The first request is rejected, because it doesn't have proof of work.
+	dsclient.EXPECT().Send(ctx, mock.Anything).RunAndReturn(
+		func(ctx context.Context, req *pb.SendRequest,
+			opts ...grpc.CallOption) (*pb.SendResponse, error) {
+			assert.Empty(req.GetPow())
+			return nil, NewPowRequiredError("proof of work required", powStrength)
+		}).Once()
+	dsclient.EXPECT().Send(ctx, mock.Anything).RunAndReturn(
+		func(ctx context.Context, req *pb.SendRequest,
+			opts ...grpc.CallOption) (*pb.SendResponse, error) {
+			assert.True(VerifyMessagePow(req.GetMessage(), req.GetPow(), powStrength))
+			return &pb.SendResponse{}, nil
+		}).Once()
+	sender := NewMessageSender(dscfactory, bchain)
+	err = sender.Send(ctx, privateKey, []byte("the message"), "alice", "bob")
+	assert.NoError(err)
+
+	bchain.AssertExpectations(t)
+	dscfactory.AssertExpectations(t)
+	dsclient.AssertExpectations(t)
+}
+
+func Test_MessageSender_PowTooStrong(t *testing.T) {
+	assert := assert.New(t)
+
+	dsclient := new(pbmocks.MockDMSDumpServiceClient)
+	ctx := context.Background()
+	dsclient.EXPECT().Send(ctx, mock.Anything).Return(
+		nil, NewPowRequiredError("proof of work required", maxPowStrength+1)).Once()
+
+	err := SendToDumpServer(ctx, dsclient, &pb.DMSMessage{Content: []byte("the message")})
+	assert.True(errors.Is(err, ErrPowTooStrong))
+
+	dsclient.AssertExpectations(t)
+}
+
+func Test_MessageSender_SendToMany(t *testing.T) {
+	assert := assert.New(t)
+
+	bchain := new(bcmocks.MockBlockchain)
+	dscfactory := new(pumocks.MockDumpServiceClientFactory)
+	server1 := new(pbmocks.MockDMSDumpServiceClient)
+	server2 := new(pbmocks.MockDMSDumpServiceClient)
+
+	privateKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+
+	ctx := context.Background()
+	receiverKeys := make(map[string]*easyecc.PrivateKey)
+	for name, endpoint := range map[string]string{"bob": "server1", "charlie": "server1", "dave": "server2"} {
+		receiverKeys[name], err = easyecc.NewPrivateKey(easyecc.P256)
+		assert.NoError(err)
+		bchain.EXPECT().PublicKeyByCurve(ctx, name, easyecc.P256).Return(receiverKeys[name].PublicKey(), nil)
+		bchain.EXPECT().Endpoint(ctx, name).Return(endpoint, nil)
+	}
+	dscfactory.EXPECT().CreateDumpServiceClient(ctx, "server1", time.Duration(0)).Return(server1, nil, nil)
+	dscfactory.EXPECT().CreateDumpServiceClient(ctx, "server2", time.Duration(0)).Return(server2, nil, nil)
+
+	var content []byte
+	checkRequest := func(req *pb.SendRequest) {
+		msg := req.GetMessage()
+		assert.Equal(pb.MessageType_MT_ENVELOPE, msg.GetType())
+		assert.True(VerifySignature(msg.GetSignature(), privateKey.PublicKey(), msg.GetContent()))
+		// The same envelope goes to every server.
+		if content != nil {
+			assert.Equal(content, msg.GetContent())
+		}
+		content = msg.GetContent()
+		for _, receiver := range req.GetReceiver() {
+			msg := proto.Clone(msg).(*pb.DMSMessage)
+			msg.Receiver = receiver
+			body, err := OpenEnvelope(receiverKeys[receiver], msg, privateKey.PublicKey())
+			assert.NoError(err)
+			assert.Equal("the message", string(body))
+		}
+	}
+	server1.EXPECT().Send(ctx, mock.Anything).RunAndReturn(
+		func(ctx context.Context, req *pb.SendRequest,
+			opts ...grpc.CallOption) (*pb.SendResponse, error) {
+			assert.Equal([]string{"bob", "charlie"}, req.GetReceiver())
+			checkRequest(req)
+			return &pb.SendResponse{}, nil
+		}).Once()
+	server2.EXPECT().Send(ctx, mock.Anything).RunAndReturn(
+		func(ctx context.Context, req *pb.SendRequest,
+			opts ...grpc.CallOption) (*pb.SendResponse, error) {
+			assert.Equal([]string{"dave"}, req.GetReceiver())
+			checkRequest(req)
+			return &pb.SendResponse{}, nil
+		}).Once()
+
+	sender := NewMessageSender(dscfactory, bchain)
+	err = sender.SendToMany(ctx, privateKey, []byte("the message"), "alice",
+		[]string{"bob", "charlie", "dave", "bob"}, MessageOptions{})
+	assert.NoError(err)
+
+	bchain.AssertExpectations(t)
+	dscfactory.AssertExpectations(t)
+	server1.AssertExpectations(t)
+	server2.AssertExpectations(t)
+}
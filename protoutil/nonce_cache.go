@@ -0,0 +1,93 @@
+package protoutil
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrNonceCacheFull = errors.New("too many outstanding nonces")
+
+// NonceCache remembers the nonces for a limited time. Nonces are only dropped once
+// they expire, so when the cache is full, new nonces are rejected. Evicting the
+// live nonces would let anyone who can add nonces push out the one they want to
+// replay.
+type NonceCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type nonceEntry struct {
+	nonce   string
+	expires time.Time
+}
+
+// NewNonceCache creates a new NonceCache which holds up to capacity nonces,
+// each for the duration of ttl.
+func NewNonceCache(capacity int, ttl time.Duration) *NonceCache {
+	return &NonceCache{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Add adds the nonce to the cache. It returns ErrNonceReused if the nonce is already
+// there, or ErrNonceCacheFull if there's no room for it.
+func (c *NonceCache) Add(nonce []byte, now time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evictExpired(now)
+	key := string(nonce)
+	if _, ok := c.entries[key]; ok {
+		return ErrNonceReused
+	}
+	if c.order.Len() >= c.capacity {
+		return ErrNonceCacheFull
+	}
+	c.entries[key] = c.order.PushBack(&nonceEntry{nonce: key, expires: now.Add(c.ttl)})
+	return nil
+}
+
+// Take removes the nonce from the cache. It returns false if the nonce was not
+// there, or if it has expired.
+func (c *NonceCache) Take(nonce []byte, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evictExpired(now)
+	elem, ok := c.entries[string(nonce)]
+	if !ok {
+		return false
+	}
+	c.remove(elem)
+	return true
+}
+
+// Len returns the number of nonces in the cache.
+func (c *NonceCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *NonceCache) evictExpired(now time.Time) {
+	// Entries are ordered by expiration time, since they all have the same TTL.
+	for elem := c.order.Front(); elem != nil; elem = c.order.Front() {
+		if now.Before(elem.Value.(*nonceEntry).expires) {
+			return
+		}
+		c.remove(elem)
+	}
+}
+
+func (c *NonceCache) remove(elem *list.Element) {
+	delete(c.entries, elem.Value.(*nonceEntry).nonce)
+	c.order.Remove(elem)
+}
@@ -0,0 +1,32 @@
+package protoutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NonceCache(t *testing.T) {
+	assert := assert.New(t)
+
+	cache := NewNonceCache(2, time.Minute)
+	now := time.Now()
+
+	assert.NoError(cache.Add([]byte("a"), now))
+	assert.Equal(ErrNonceReused, cache.Add([]byte("a"), now))
+	assert.NoError(cache.Add([]byte("b"), now))
+
+	// The cache is full, the live nonces are kept.
+	assert.Equal(ErrNonceCacheFull, cache.Add([]byte("c"), now))
+	assert.Equal(2, cache.Len())
+	assert.Equal(ErrNonceReused, cache.Add([]byte("a"), now))
+
+	// Expired nonces are evicted, which makes room.
+	later := now.Add(2 * time.Minute)
+	assert.NoError(cache.Add([]byte("c"), later))
+	assert.Equal(1, cache.Len())
+
+	assert.True(cache.Take([]byte("c"), later))
+	assert.False(cache.Take([]byte("c"), later))
+}
@@ -16,8 +16,11 @@ import (
 	"github.com/regnull/ubikom/bc"
 	"github.com/regnull/ubikom/mail"
 	"github.com/regnull/ubikom/pb"
+	"github.com/regnull/ubikom/pow"
 	"github.com/regnull/ubikom/util"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
@@ -50,8 +53,8 @@ func CreateSigned(privateKey *easyecc.PrivateKey, content []byte) (*pb.Signed, e
 // VerifySignature returns true if the provided signature is valid for the given key and content.
 func VerifySignature(sig *pb.Signature, key *easyecc.PublicKey, content []byte) bool {
 	eccSig := &easyecc.Signature{
-		R: new(big.Int).SetBytes(sig.R),
-		S: new(big.Int).SetBytes(sig.S)}
+		R: new(big.Int).SetBytes(sig.GetR()),
+		S: new(big.Int).SetBytes(sig.GetS())}
 
 	if !eccSig.Verify(key, util.Hash256(content)) {
 		log.Printf("signature verification failed")
@@ -147,11 +150,13 @@ func SendMessage(ctx context.Context, privateKey *easyecc.PrivateKey, body []byt
 	return messageSender.Send(ctx, privateKey, body, sender, receiver)
 }
 
+// DecryptMessage verifies and decrypts the message. IsInvalidMessage tells if the
+// returned error means that the message can never be read.
 func DecryptMessage(ctx context.Context, bchain bc.Blockchain,
 	privateKey *easyecc.PrivateKey, msg *pb.DMSMessage) (string, error) {
 	curve := CurveFromProto(msg.GetCryptoContext().GetEllipticCurve())
 	if curve == easyecc.INVALID_CURVE {
-		return "", fmt.Errorf("unsupported curve")
+		return "", ErrUnsupportedCurve
 	}
 
 	senderKey, err := bchain.PublicKeyByCurve(ctx, msg.GetSender(), curve)
@@ -160,12 +165,25 @@ func DecryptMessage(ctx context.Context, bchain bc.Blockchain,
 	}
 
 	if !VerifySignature(msg.GetSignature(), senderKey, msg.GetContent()) {
-		return "", fmt.Errorf("signature verification failed")
+		return "", ErrSignatureVerificationFailed
+	}
+
+	err = bc.CheckKey(ctx, bchain, senderKey.CompressedBytes())
+	if err != nil {
+		return "", fmt.Errorf("failed to check sender's key: %w", err)
+	}
+
+	if msg.GetType() == pb.MessageType_MT_ENVELOPE {
+		content, err := OpenEnvelope(privateKey, msg, senderKey)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+		}
+		return string(content), nil
 	}
 
 	content, err := privateKey.Decrypt(msg.Content, senderKey)
 	if err != nil {
-		return "", fmt.Errorf("failed to decrypt message")
+		return "", fmt.Errorf("%w: failed to decrypt message", ErrInvalidMessage)
 	}
 	return string(content), nil
 }
@@ -193,6 +211,11 @@ func DecryptLegacyMessage(ctx context.Context, bchain bc.Blockchain,
 		return "", fmt.Errorf("signature verification failed")
 	}
 
+	err = bc.CheckKey(ctx, bchain, senderKey.CompressedBytes())
+	if err != nil {
+		return "", fmt.Errorf("failed to check sender's key: %w", err)
+	}
+
 	content, err := privateKeyV1.Decrypt(msg.Content, senderKeyV1)
 	if err != nil {
 		return "", fmt.Errorf("failed to decrypt message")
@@ -248,6 +271,43 @@ func VerifyIdentity(signed *pb.Signed, now time.Time, allowedDeltaSeconds float6
 	return nil
 }
 
+// ComputeMessagePow computes proof of work for the given message. It gives up
+// when the context is done.
+func ComputeMessagePow(ctx context.Context, msg *pb.DMSMessage, strength int) ([]byte, error) {
+	return pow.ComputeWithContext(ctx, util.Hash256(msg.GetContent()), strength)
+}
+
+// VerifyMessagePow returns true if the proof of work for the given message is valid.
+func VerifyMessagePow(msg *pb.DMSMessage, nonce []byte, strength int) bool {
+	return pow.Verify(util.Hash256(msg.GetContent()), nonce, strength)
+}
+
+// NewPowRequiredError returns gRPC error which tells the client that the proof of work
+// of the given strength is required.
+func NewPowRequiredError(message string, strength int) error {
+	st, err := status.New(codes.FailedPrecondition, message).WithDetails(
+		&pb.PowRequirement{Strength: int32(strength)})
+	if err != nil {
+		return status.Error(codes.FailedPrecondition, message)
+	}
+	return st.Err()
+}
+
+// PowRequirementFromError returns the required proof of work strength, if the error
+// was created by NewPowRequiredError.
+func PowRequirementFromError(err error) (int, bool) {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.FailedPrecondition {
+		return 0, false
+	}
+	for _, d := range st.Details() {
+		if req, ok := d.(*pb.PowRequirement); ok {
+			return int(req.GetStrength()), true
+		}
+	}
+	return 0, false
+}
+
 func CurveToProto(curve easyecc.EllipticCurve) pb.EllipticCurve {
 	switch curve {
 	case easyecc.SECP256K1:
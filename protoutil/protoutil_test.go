@@ -7,9 +7,11 @@ import (
 	"time"
 
 	"github.com/regnull/easyecc/v2"
+	"github.com/regnull/ubikom/bc"
 	bcmocks "github.com/regnull/ubikom/bc/mocks"
 	"github.com/regnull/ubikom/pb"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func Test_CreateSigned(t *testing.T) {
@@ -178,6 +180,7 @@ func Test_DecryptMessage(t *testing.T) {
 
 	ctx := context.Background()
 	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
 
 	message := []byte("All experience is preceded by mind")
 
@@ -207,3 +210,23 @@ func Test_DecryptMessage(t *testing.T) {
 
 	bchain.AssertExpectations(t)
 }
+
+func Test_DecryptMessage_DisabledKey(t *testing.T) {
+	assert := assert.New(t)
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.SECP256K1)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.SECP256K1)
+	assert.NoError(err)
+
+	ctx := context.Background()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "alice", easyecc.SECP256K1).Return(aliceKey.PublicKey(), nil)
+	bchain.EXPECT().KeyStatus(ctx, aliceKey.PublicKey().CompressedBytes()).Return(
+		&pb.LookupKeyResponse{Disabled: true}, nil)
+
+	msg, err := CreateMessage(aliceKey, []byte("hi bob"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	_, err = DecryptMessage(ctx, bchain, bobKey, msg)
+	assert.ErrorIs(err, bc.ErrKeyDisabled)
+}
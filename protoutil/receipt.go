@@ -0,0 +1,116 @@
+package protoutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/regnull/easyecc/v2"
+	"github.com/regnull/ubikom/bc"
+	"github.com/regnull/ubikom/pb"
+	"github.com/regnull/ubikom/util"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrNotDeliveryReceipt = errors.New("not a delivery receipt")
+
+// MessageHash returns the hash that identifies the message in the delivery receipts.
+func MessageHash(msg *pb.DMSMessage) []byte {
+	return util.Hash256(msg.GetContent())
+}
+
+// CreateDeliveryReceipt creates a delivery receipt for the received message. The receipt
+// is signed with the receiver's key, and addressed to the message sender.
+func CreateDeliveryReceipt(privateKey *easyecc.PrivateKey, msg *pb.DMSMessage,
+	timestamp time.Time) (*pb.DMSMessage, error) {
+	content, err := proto.Marshal(&pb.DeliveryReceipt{
+		MessageHash: MessageHash(msg),
+		Sender:      msg.GetSender(),
+		Receiver:    msg.GetReceiver(),
+		Timestamp:   timestamp.UTC().Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize delivery receipt: %w", err)
+	}
+	sig, err := privateKey.Sign(util.Hash256(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign delivery receipt: %w", err)
+	}
+	return &pb.DMSMessage{
+		Sender:   msg.GetReceiver(),
+		Receiver: msg.GetSender(),
+		Content:  content,
+		Signature: &pb.Signature{
+			R: sig.R.Bytes(),
+			S: sig.S.Bytes(),
+		},
+		CryptoContext: &pb.CryptoContext{
+			EllipticCurve: CurveToProto(privateKey.Curve()),
+			EcdhVersion:   2,
+			EcdsaVersion:  1,
+		},
+		Type: pb.MessageType_MT_DELIVERY_RECEIPT,
+	}, nil
+}
+
+// VerifyDeliveryReceipt verifies that the delivery receipt is signed by the receiver
+// of the original message, and returns its content.
+func VerifyDeliveryReceipt(ctx context.Context, bchain bc.Blockchain,
+	msg *pb.DMSMessage) (*pb.DeliveryReceipt, error) {
+	if msg.GetType() != pb.MessageType_MT_DELIVERY_RECEIPT {
+		return nil, ErrNotDeliveryReceipt
+	}
+	curve := CurveFromProto(msg.GetCryptoContext().GetEllipticCurve())
+	if curve == easyecc.INVALID_CURVE {
+		return nil, ErrUnsupportedCurve
+	}
+	receiverKey, err := bchain.PublicKeyByCurve(ctx, msg.GetSender(), curve)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receiver public key: %w", err)
+	}
+	if !VerifySignature(msg.GetSignature(), receiverKey, msg.GetContent()) {
+		return nil, ErrSignatureVerificationFailed
+	}
+	receipt := &pb.DeliveryReceipt{}
+	err = proto.Unmarshal(msg.GetContent(), receipt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse delivery receipt: %v", ErrInvalidMessage, err)
+	}
+	if receipt.GetReceiver() != msg.GetSender() || receipt.GetSender() != msg.GetReceiver() {
+		return nil, fmt.Errorf("%w: delivery receipt doesn't match its envelope", ErrInvalidMessage)
+	}
+	return receipt, nil
+}
+
+// ReceiptMatches returns true if the receipt is for the given message.
+func ReceiptMatches(receipt *pb.DeliveryReceipt, msg *pb.DMSMessage) bool {
+	return bytes.Equal(receipt.GetMessageHash(), MessageHash(msg))
+}
+
+// SendDeliveryReceipt creates a delivery receipt for the received message, and sends
+// it to the sender's dump server.
+func SendDeliveryReceipt(ctx context.Context, dscFactory DumpServiceClientFactory, bchain bc.Blockchain,
+	privateKey *easyecc.PrivateKey, msg *pb.DMSMessage) error {
+	receipt, err := CreateDeliveryReceipt(privateKey, msg, time.Now())
+	if err != nil {
+		return err
+	}
+	endpoint, err := bchain.Endpoint(ctx, msg.GetSender())
+	if err != nil {
+		return fmt.Errorf("failed to get sender's address: %w", err)
+	}
+	client, cleanup, err := dscFactory.CreateDumpServiceClient(ctx, endpoint, 0)
+	if err != nil {
+		return err
+	}
+	if cleanup != nil {
+		defer cleanup()
+	}
+	err = SendToDumpServer(ctx, client, receipt)
+	if err != nil {
+		return fmt.Errorf("failed to send delivery receipt: %w", err)
+	}
+	return nil
+}
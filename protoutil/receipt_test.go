@@ -0,0 +1,59 @@
+package protoutil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/regnull/easyecc/v2"
+	bcmocks "github.com/regnull/ubikom/bc/mocks"
+	"github.com/regnull/ubikom/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_DeliveryReceipt(t *testing.T) {
+	assert := assert.New(t)
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+
+	ctx := context.Background()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
+	bchain.EXPECT().PublicKeyByCurve(ctx, "bob", easyecc.P256).Return(bobKey.PublicKey(), nil)
+
+	msg, err := CreateMessage(aliceKey, []byte("hi bob"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	now := time.Now()
+	receiptMsg, err := CreateDeliveryReceipt(bobKey, msg, now)
+	assert.NoError(err)
+	assert.Equal("bob", receiptMsg.GetSender())
+	assert.Equal("alice", receiptMsg.GetReceiver())
+	assert.Equal(pb.MessageType_MT_DELIVERY_RECEIPT, receiptMsg.GetType())
+
+	receipt, err := VerifyDeliveryReceipt(ctx, bchain, receiptMsg)
+	assert.NoError(err)
+	assert.Equal("alice", receipt.GetSender())
+	assert.Equal("bob", receipt.GetReceiver())
+	assert.Equal(now.Unix(), receipt.GetTimestamp())
+	assert.True(ReceiptMatches(receipt, msg))
+
+	otherMsg, err := CreateMessage(aliceKey, []byte("hi again"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	assert.False(ReceiptMatches(receipt, otherMsg))
+
+	// Regular message is not a receipt.
+	_, err = VerifyDeliveryReceipt(ctx, bchain, msg)
+	assert.ErrorIs(err, ErrNotDeliveryReceipt)
+
+	// Receipt signed by someone else is rejected.
+	forged, err := CreateDeliveryReceipt(aliceKey, msg, now)
+	assert.NoError(err)
+	_, err = VerifyDeliveryReceipt(ctx, bchain, forged)
+	assert.ErrorIs(err, ErrSignatureVerificationFailed)
+
+	bchain.AssertExpectations(t)
+}
@@ -0,0 +1,132 @@
+package protoutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/regnull/easyecc/v2"
+	"github.com/regnull/ubikom/bc"
+	"github.com/regnull/ubikom/pb"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidMessage is returned for the messages that are malformed or can't be decrypted.
+var ErrInvalidMessage = errors.New("invalid message")
+
+// IsInvalidMessage returns true if the message can never be read - it's malformed,
+// forged or signed by a disabled key. Other errors, like lookup failures, might go away.
+func IsInvalidMessage(err error) bool {
+	return errors.Is(err, ErrInvalidMessage) || errors.Is(err, ErrSignatureVerificationFailed) ||
+		errors.Is(err, ErrUnsupportedCurve) || errors.Is(err, bc.ErrKeyDisabled) ||
+		errors.Is(err, bc.ErrNotFound)
+}
+
+// ReceivedMessage is the message returned by ReceiveMessage.
+type ReceivedMessage struct {
+	Message *pb.DMSMessage
+
+	// Content is the decrypted content, nil for delivery receipts.
+	Content []byte
+
+	// Receipt is the verified delivery receipt, nil for other messages.
+	Receipt *pb.DeliveryReceipt
+
+	// DeliveryID must be acknowledged with AckMessages once the message is processed.
+	// It's empty if the server doesn't support acknowledgements, the message is
+	// removed when it's received then.
+	DeliveryID string
+}
+
+// ReceiveMessage receives the next message, verifies and decrypts it. The messages
+// that can never be read are logged and acknowledged, so that they don't block the
+// mailbox, and the next message is received instead. Other errors leave the message
+// in the mailbox, to be received again after its visibility timeout.
+func ReceiveMessage(ctx context.Context, client pb.DMSDumpServiceClient, bchain bc.Blockchain,
+	privateKey *easyecc.PrivateKey, opts IdentityProofOptions) (*ReceivedMessage, error) {
+	for {
+		signed, err := IdentityProofForServer(ctx, client, privateKey, IdentityOperationReceive, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create identity proof: %w", err)
+		}
+		res, err := client.Receive(ctx, &pb.ReceiveRequest{
+			IdentityProof: signed,
+			CryptoContext: receiverCryptoContext(privateKey),
+			RequireAck:    true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		received, err := readMessage(ctx, bchain, privateKey, res.GetMessage())
+		if err == nil {
+			received.DeliveryID = res.GetDeliveryId()
+			return received, nil
+		}
+		if !IsInvalidMessage(err) {
+			return nil, err
+		}
+		log.Warn().Err(err).Str("sender", res.GetMessage().GetSender()).Msg("dropping invalid message")
+		if res.GetDeliveryId() == "" {
+			continue
+		}
+		err = AckMessages(ctx, client, privateKey, opts, []string{res.GetDeliveryId()})
+		if err != nil {
+			return nil, fmt.Errorf("failed to acknowledge invalid message: %w", err)
+		}
+	}
+}
+
+// AckMessages acknowledges the received messages, which removes them from the mailbox.
+func AckMessages(ctx context.Context, client pb.DMSDumpServiceClient, privateKey *easyecc.PrivateKey,
+	opts IdentityProofOptions, deliveryIDs []string) error {
+	signed, err := IdentityProofForServer(ctx, client, privateKey, IdentityOperationAck, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create identity proof: %w", err)
+	}
+	_, err = client.Ack(ctx, &pb.AckRequest{
+		IdentityProof: signed,
+		CryptoContext: receiverCryptoContext(privateKey),
+		DeliveryId:    deliveryIDs,
+	})
+	return err
+}
+
+// ReleaseMessages makes the received messages available again right away, instead
+// of after their visibility timeout.
+func ReleaseMessages(ctx context.Context, client pb.DMSDumpServiceClient, privateKey *easyecc.PrivateKey,
+	opts IdentityProofOptions, deliveryIDs []string) error {
+	signed, err := IdentityProofForServer(ctx, client, privateKey, IdentityOperationRelease, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create identity proof: %w", err)
+	}
+	_, err = client.Release(ctx, &pb.ReleaseRequest{
+		IdentityProof: signed,
+		CryptoContext: receiverCryptoContext(privateKey),
+		DeliveryId:    deliveryIDs,
+	})
+	return err
+}
+
+func readMessage(ctx context.Context, bchain bc.Blockchain, privateKey *easyecc.PrivateKey,
+	msg *pb.DMSMessage) (*ReceivedMessage, error) {
+	if msg.GetType() == pb.MessageType_MT_DELIVERY_RECEIPT {
+		receipt, err := VerifyDeliveryReceipt(ctx, bchain, msg)
+		if err != nil {
+			return nil, err
+		}
+		return &ReceivedMessage{Message: msg, Receipt: receipt}, nil
+	}
+	content, err := DecryptMessage(ctx, bchain, privateKey, msg)
+	if err != nil {
+		return nil, err
+	}
+	return &ReceivedMessage{Message: msg, Content: []byte(content)}, nil
+}
+
+func receiverCryptoContext(privateKey *easyecc.PrivateKey) *pb.CryptoContext {
+	return &pb.CryptoContext{
+		EllipticCurve: CurveToProto(privateKey.Curve()),
+		EcdhVersion:   2,
+		EcdsaVersion:  1,
+	}
+}
@@ -0,0 +1,84 @@
+package protoutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/regnull/easyecc/v2"
+	bcmocks "github.com/regnull/ubikom/bc/mocks"
+	"github.com/regnull/ubikom/pb"
+	pbmocks "github.com/regnull/ubikom/pb/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+)
+
+func Test_ReceiveMessage_SkipsInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.SECP256K1)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.SECP256K1)
+	assert.NoError(err)
+
+	forged, err := CreateMessage(aliceKey, []byte("forged"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	forged.Content[0] ^= 0xff
+	good, err := CreateMessage(aliceKey, []byte("hi bob"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+
+	ctx := context.Background()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "alice", easyecc.SECP256K1).Return(aliceKey.PublicKey(), nil)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
+	client := new(pbmocks.MockDMSDumpServiceClient)
+	client.EXPECT().GetChallenge(ctx, mock.Anything).Return(&pb.GetChallengeResponse{
+		Nonce: []byte("nonce"), ServerId: "server"}, nil)
+	client.EXPECT().Receive(ctx, mock.Anything).Return(
+		&pb.ReceiveResponse{Message: forged, DeliveryId: "forged"}, nil).Once()
+	client.EXPECT().Receive(ctx, mock.Anything).Return(
+		&pb.ReceiveResponse{Message: good, DeliveryId: "good"}, nil).Once()
+	// Only the forged message is acknowledged, the caller acknowledges the good one.
+	client.EXPECT().Ack(ctx, mock.Anything).RunAndReturn(
+		func(ctx context.Context, req *pb.AckRequest, opts ...grpc.CallOption) (*pb.AckResponse, error) {
+			assert.Equal([]string{"forged"}, req.GetDeliveryId())
+			return &pb.AckResponse{}, nil
+		}).Once()
+
+	received, err := ReceiveMessage(ctx, client, bchain, bobKey, IdentityProofOptions{Audience: "server"})
+	assert.NoError(err)
+	if assert.NotNil(received) {
+		assert.Equal([]byte("hi bob"), received.Content)
+		assert.Equal("good", received.DeliveryID)
+	}
+	bchain.AssertExpectations(t)
+	client.AssertExpectations(t)
+}
+
+func Test_ReceiveMessage_LookupFailure(t *testing.T) {
+	assert := assert.New(t)
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.SECP256K1)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.SECP256K1)
+	assert.NoError(err)
+	msg, err := CreateMessage(aliceKey, []byte("hi bob"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+
+	ctx := context.Background()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "alice", easyecc.SECP256K1).Return(nil, errors.New("node is down"))
+	client := new(pbmocks.MockDMSDumpServiceClient)
+	client.EXPECT().GetChallenge(ctx, mock.Anything).Return(&pb.GetChallengeResponse{
+		Nonce: []byte("nonce"), ServerId: "server"}, nil)
+	client.EXPECT().Receive(ctx, mock.Anything).Return(
+		&pb.ReceiveResponse{Message: msg, DeliveryId: "msg"}, nil).Once()
+
+	// The message might be readable later, so it's not acknowledged.
+	_, err = ReceiveMessage(ctx, client, bchain, bobKey, IdentityProofOptions{Audience: "server"})
+	assert.Error(err)
+	assert.False(IsInvalidMessage(err))
+	client.AssertNotCalled(t, "Ack", mock.Anything, mock.Anything)
+	client.AssertExpectations(t)
+}
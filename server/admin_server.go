@@ -0,0 +1,224 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/regnull/easyecc/v2"
+	"github.com/regnull/ubikom/pb"
+	"github.com/regnull/ubikom/protoutil"
+	"github.com/regnull/ubikom/store"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const defaultDiscardRatio = 0.5
+
+// AdminServerOptions control the behavior of the admin server.
+type AdminServerOptions struct {
+	// OperatorKeys are the compressed public keys of the operators who are allowed
+	// to use the admin service.
+	OperatorKeys [][]byte
+
+	// ServerID, if set, must be the audience of the operator's identity proof.
+	ServerID string
+
+	// MaxClockSkew is the maximum allowed difference between the identity proof
+	// timestamp and the server time.
+	MaxClockSkew time.Duration
+}
+
+// AdminServer implements DumpAdminService, which lets the operators inspect and
+// maintain the message store.
+type AdminServer struct {
+	pb.UnimplementedDumpAdminServiceServer
+
+	store            store.AdminStore
+	opts             AdminServerOptions
+	identityVerifier *protoutil.IdentityVerifier
+}
+
+func NewAdminServer(str store.AdminStore, opts AdminServerOptions) *AdminServer {
+	if opts.MaxClockSkew == 0 {
+		opts.MaxClockSkew = defaultMaxClockSkew
+	}
+	return &AdminServer{
+		store: str,
+		opts:  opts,
+		identityVerifier: protoutil.NewIdentityVerifier(protoutil.IdentityVerifierOptions{
+			Audience:     opts.ServerID,
+			MaxClockSkew: opts.MaxClockSkew,
+		}),
+	}
+}
+
+func (s *AdminServer) ListMailboxes(ctx context.Context, req *pb.ListMailboxesRequest) (*pb.ListMailboxesResponse, error) {
+	err := s.verifyOperator(req.GetIdentityProof(), req.GetCryptoContext(),
+		protoutil.IdentityOperationAdminListMailboxes)
+	if err != nil {
+		return nil, err
+	}
+	mailboxes, err := s.store.Mailboxes(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list mailboxes")
+		return nil, status.Error(codes.Internal, "message store error")
+	}
+	res := &pb.ListMailboxesResponse{}
+	for _, mailbox := range mailboxes {
+		res.Mailbox = append(res.Mailbox, &pb.MailboxInfo{
+			ReceiverKey: mailbox.ReceiverKey,
+			Count:       int64(mailbox.Count),
+			Size:        mailbox.Size,
+		})
+	}
+	return res, nil
+}
+
+func (s *AdminServer) PurgeMailbox(ctx context.Context, req *pb.PurgeMailboxRequest) (*pb.PurgeMailboxResponse, error) {
+	err := s.verifyOperator(req.GetIdentityProof(), req.GetCryptoContext(),
+		protoutil.IdentityOperationAdminPurgeMailbox)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.GetReceiverKey()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "receiver key is required")
+	}
+	count, err := s.store.Purge(ctx, req.GetReceiverKey())
+	if err != nil {
+		log.Error().Err(err).Msg("failed to purge mailbox")
+		return nil, status.Error(codes.Internal, "message store error")
+	}
+	auditEvent(auditMailboxPurged).
+		Hex("operator", req.GetIdentityProof().GetKey()).
+		Hex("receiver", req.GetReceiverKey()).
+		Int("count", count).
+		Msg("mailbox purged")
+	return &pb.PurgeMailboxResponse{Count: int64(count)}, nil
+}
+
+func (s *AdminServer) DeleteMessage(ctx context.Context, req *pb.DeleteMessageRequest) (*pb.DeleteMessageResponse, error) {
+	err := s.verifyOperator(req.GetIdentityProof(), req.GetCryptoContext(),
+		protoutil.IdentityOperationAdminDeleteMessage)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.GetReceiverKey()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "receiver key is required")
+	}
+	// The message hash is the same as its message ID.
+	err = s.store.Delete(ctx, req.GetReceiverKey(), hex.EncodeToString(req.GetMessageHash()))
+	if errors.Is(err, store.ErrInvalidDeliveryID) {
+		return nil, status.Error(codes.InvalidArgument, "invalid message hash")
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("failed to delete message")
+		return nil, status.Error(codes.Internal, "message store error")
+	}
+	auditEvent(auditMessageDeleted).
+		Hex("operator", req.GetIdentityProof().GetKey()).
+		Hex("receiver", req.GetReceiverKey()).
+		Hex("hash", req.GetMessageHash()).
+		Msg("message deleted")
+	return &pb.DeleteMessageResponse{}, nil
+}
+
+func (s *AdminServer) GetStorageUsage(ctx context.Context, req *pb.GetStorageUsageRequest) (*pb.GetStorageUsageResponse, error) {
+	err := s.verifyOperator(req.GetIdentityProof(), req.GetCryptoContext(),
+		protoutil.IdentityOperationAdminGetStorageUsage)
+	if err != nil {
+		return nil, err
+	}
+	mailboxes, err := s.store.Mailboxes(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list mailboxes")
+		return nil, status.Error(codes.Internal, "message store error")
+	}
+	diskSize, err := s.store.DiskSize(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get disk size")
+		return nil, status.Error(codes.Internal, "message store error")
+	}
+	res := &pb.GetStorageUsageResponse{
+		Mailboxes: int64(len(mailboxes)),
+		DiskSize:  diskSize,
+	}
+	for _, mailbox := range mailboxes {
+		res.Messages += int64(mailbox.Count)
+		res.Size += mailbox.Size
+	}
+	return res, nil
+}
+
+func (s *AdminServer) RunGarbageCollection(ctx context.Context, req *pb.RunGarbageCollectionRequest) (*pb.RunGarbageCollectionResponse, error) {
+	err := s.verifyOperator(req.GetIdentityProof(), req.GetCryptoContext(),
+		protoutil.IdentityOperationAdminRunGC)
+	if err != nil {
+		return nil, err
+	}
+	discardRatio := req.GetDiscardRatio()
+	if discardRatio == 0 {
+		discardRatio = defaultDiscardRatio
+	}
+	if discardRatio < 0 || discardRatio >= 1 {
+		return nil, status.Error(codes.InvalidArgument, "discard ratio must be between 0 and 1")
+	}
+	rewritten, err := s.store.CollectGarbage(ctx, discardRatio)
+	if errors.Is(err, store.ErrNotSupported) {
+		return nil, status.Error(codes.Unimplemented, "message store doesn't need garbage collection")
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("garbage collection failed")
+		return nil, status.Error(codes.Internal, "garbage collection failed")
+	}
+	log.Info().Int("rewritten", rewritten).Msg("garbage collection finished")
+	return &pb.RunGarbageCollectionResponse{Rewritten: int32(rewritten)}, nil
+}
+
+// verifyOperator makes sure the request comes from one of the operators, and the
+// identity proof was created for this operation.
+func (s *AdminServer) verifyOperator(identityProof *pb.Signed, cryptoContext *pb.CryptoContext,
+	operation string) error {
+	isOperator := false
+	for _, key := range s.opts.OperatorKeys {
+		if bytes.Equal(key, identityProof.GetKey()) {
+			isOperator = true
+			break
+		}
+	}
+	if !isOperator {
+		auditEvent(auditAdminRejected).
+			Hex("key", identityProof.GetKey()).
+			Str("operation", operation).
+			Msg("admin request from unknown key")
+		return status.Error(codes.PermissionDenied, "not an operator")
+	}
+
+	curve := easyecc.SECP256K1
+	if cryptoContext != nil {
+		curve = protoutil.CurveFromProto(cryptoContext.GetEllipticCurve())
+		if curve == easyecc.INVALID_CURVE {
+			return status.Error(codes.InvalidArgument, "invalid curve")
+		}
+	}
+	err := s.identityVerifier.Verify(identityProof, operation, curve, time.Now())
+	if errors.Is(err, protoutil.ErrNonceCacheFull) {
+		return status.Error(codes.ResourceExhausted, "too many requests, try again later")
+	}
+	if err != nil {
+		auditEvent(auditAdminRejected).
+			Err(err).
+			Hex("key", identityProof.GetKey()).
+			Str("operation", operation).
+			Msg("admin identity verification failed")
+		return status.Error(codes.InvalidArgument, "bad identity proof")
+	}
+	auditEvent(auditAdminRequest).
+		Hex("operator", identityProof.GetKey()).
+		Str("operation", operation).
+		Msg("admin request")
+	return nil
+}
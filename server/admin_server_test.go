@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/regnull/easyecc/v2"
+	"github.com/regnull/ubikom/pb"
+	"github.com/regnull/ubikom/protoutil"
+	"github.com/regnull/ubikom/store"
+	"github.com/regnull/ubikom/util"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+)
+
+func Test_AdminServer(t *testing.T) {
+	assert := assert.New(t)
+
+	dumpStore := store.NewMemory()
+	ctx := context.Background()
+
+	operatorKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	strangerKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	adminServer := NewAdminServer(dumpStore, AdminServerOptions{
+		OperatorKeys: [][]byte{operatorKey.PublicKey().CompressedBytes()},
+		ServerID:     "dump1",
+	})
+
+	cryptoContext := &pb.CryptoContext{
+		EllipticCurve: pb.EllipticCurve(easyecc.P256),
+		EcdhVersion:   2,
+		EcdsaVersion:  1,
+	}
+	identityProof := func(key *easyecc.PrivateKey, operation string) *pb.Signed {
+		proof, err := protoutil.CreateIdentityProof(key, nil, "dump1", operation, time.Now())
+		assert.NoError(err)
+		return proof
+	}
+
+	bobKey := []byte("bob")
+	msg1 := &pb.DMSMessage{Sender: "alice", Receiver: "bob", Content: []byte("message 1")}
+	msg2 := &pb.DMSMessage{Sender: "alice", Receiver: "bob", Content: []byte("message 2")}
+	assert.NoError(dumpStore.Save(ctx, msg1, bobKey))
+	assert.NoError(dumpStore.Save(ctx, msg2, bobKey))
+	assert.NoError(dumpStore.Save(ctx, msg1, []byte("carol")))
+
+	// Only the operators are allowed.
+	_, err = adminServer.ListMailboxes(ctx, &pb.ListMailboxesRequest{
+		IdentityProof: identityProof(strangerKey, protoutil.IdentityOperationAdminListMailboxes),
+		CryptoContext: cryptoContext,
+	})
+	assert.True(util.ErrEqualCode(err, codes.PermissionDenied))
+
+	// The identity proof can't be replayed.
+	proof := identityProof(operatorKey, protoutil.IdentityOperationAdminListMailboxes)
+	listRes, err := adminServer.ListMailboxes(ctx, &pb.ListMailboxesRequest{
+		IdentityProof: proof,
+		CryptoContext: cryptoContext,
+	})
+	assert.NoError(err)
+	assert.Len(listRes.GetMailbox(), 2)
+	assert.Equal(bobKey, listRes.GetMailbox()[0].GetReceiverKey())
+	assert.EqualValues(2, listRes.GetMailbox()[0].GetCount())
+	_, err = adminServer.ListMailboxes(ctx, &pb.ListMailboxesRequest{
+		IdentityProof: proof,
+		CryptoContext: cryptoContext,
+	})
+	assert.True(util.ErrEqualCode(err, codes.InvalidArgument))
+
+	usageRes, err := adminServer.GetStorageUsage(ctx, &pb.GetStorageUsageRequest{
+		IdentityProof: identityProof(operatorKey, protoutil.IdentityOperationAdminGetStorageUsage),
+		CryptoContext: cryptoContext,
+	})
+	assert.NoError(err)
+	assert.EqualValues(2, usageRes.GetMailboxes())
+	assert.EqualValues(3, usageRes.GetMessages())
+
+	b, err := proto.Marshal(msg1)
+	assert.NoError(err)
+	hash := sha256.Sum256(b)
+	_, err = adminServer.DeleteMessage(ctx, &pb.DeleteMessageRequest{
+		IdentityProof: identityProof(operatorKey, protoutil.IdentityOperationAdminDeleteMessage),
+		CryptoContext: cryptoContext,
+		ReceiverKey:   bobKey,
+		MessageHash:   hash[:],
+	})
+	assert.NoError(err)
+	stats, err := dumpStore.Stats(ctx, bobKey)
+	assert.NoError(err)
+	assert.Equal(1, stats.Count)
+
+	// The proof created for one admin operation can't be used for another.
+	_, err = adminServer.PurgeMailbox(ctx, &pb.PurgeMailboxRequest{
+		IdentityProof: identityProof(operatorKey, protoutil.IdentityOperationAdminListMailboxes),
+		CryptoContext: cryptoContext,
+		ReceiverKey:   bobKey,
+	})
+	assert.True(util.ErrEqualCode(err, codes.InvalidArgument))
+
+	purgeRes, err := adminServer.PurgeMailbox(ctx, &pb.PurgeMailboxRequest{
+		IdentityProof: identityProof(operatorKey, protoutil.IdentityOperationAdminPurgeMailbox),
+		CryptoContext: cryptoContext,
+		ReceiverKey:   bobKey,
+	})
+	assert.NoError(err)
+	assert.EqualValues(1, purgeRes.GetCount())
+
+	// Memory store doesn't need garbage collection.
+	_, err = adminServer.RunGarbageCollection(ctx, &pb.RunGarbageCollectionRequest{
+		IdentityProof: identityProof(operatorKey, protoutil.IdentityOperationAdminRunGC),
+		CryptoContext: cryptoContext,
+	})
+	assert.True(util.ErrEqualCode(err, codes.Unimplemented))
+}
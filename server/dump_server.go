@@ -1,37 +1,157 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/regnull/easyecc/v2"
 	"github.com/regnull/ubikom/bc"
+	"github.com/regnull/ubikom/events"
+	"github.com/regnull/ubikom/metrics"
 	"github.com/regnull/ubikom/pb"
 	"github.com/regnull/ubikom/protoutil"
 	"github.com/regnull/ubikom/store"
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
-const maxAllowedIdentitySignatureDifferenceSeconds = 10000000000.0
+const (
+	defaultVisibilityTimeout = 5 * time.Minute
+	defaultMaxClockSkew      = 10 * time.Second
+	defaultDedupWindow       = time.Hour
+	subscribePollInterval    = time.Minute
+)
+
+// DumpServerOptions control the behavior of the dump server. Zero values
+// result in the default behavior.
+type DumpServerOptions struct {
+	// VisibilityTimeout is how long the message received with require_ack stays
+	// hidden from other receive calls, unless it's acknowledged.
+	VisibilityTimeout time.Duration
+
+	// PowStrength is the proof of work strength required to send a message.
+	// Zero means proof of work is not required.
+	PowStrength int
+
+	// MaxMessageSize is the maximum size of a single message, in bytes.
+	// Zero means no limit.
+	MaxMessageSize int
+
+	// MaxMailboxMessages is the maximum number of messages stored for each receiver.
+	// Zero means no limit.
+	MaxMailboxMessages int
+
+	// MaxMailboxBytes is the maximum total size of messages stored for each receiver.
+	// Zero means no limit.
+	MaxMailboxBytes int64
+
+	// ServerID identifies this server, identity proofs intended for other servers are
+	// rejected. If empty, identity proof audience is not checked.
+	ServerID string
+
+	// IdentityPolicy defines which identity proofs are accepted.
+	IdentityPolicy IdentityPolicy
+
+	// MaxClockSkew is the maximum allowed difference between the identity proof
+	// timestamp and the server time.
+	MaxClockSkew time.Duration
+
+	// RequireChallenge makes the clients use challenges issued by this server
+	// in their identity proofs.
+	RequireChallenge bool
+
+	// Relay, if not nil, forwards the messages for the receivers hosted elsewhere.
+	// The receivers whose endpoint is one of LocalEndpoints get their messages here.
+	Relay *Relay
+
+	// LocalEndpoints are the endpoints served by this dump server.
+	LocalEndpoints []string
+
+	// DedupWindow is how long the server remembers the messages, the retried sends
+	// within the window are accepted, but not stored again.
+	DedupWindow time.Duration
+
+	// Metrics, if not nil, collects the server metrics.
+	Metrics *metrics.Metrics
+
+	// Events, if not nil, receives the events for the stored messages and the
+	// mailbox policy changes.
+	Events events.Sink
+
+	// EventNames makes the message events include the sender and the receiver names,
+	// which are otherwise left out, since they reveal who talks to whom.
+	EventNames bool
+}
 
 type DumpServer struct {
 	pb.UnimplementedDMSDumpServiceServer
 
-	bchain bc.Blockchain
-	store  store.Store
+	bchain           bc.Blockchain
+	store            store.Store
+	opts             DumpServerOptions
+	subscriptions    *subscriptions
+	identityVerifier *protoutil.IdentityVerifier
+	identityCounters identityCounters
+	shutdown         chan struct{}
+	shutdownOnce     sync.Once
 }
 
 func NewDumpServer(str store.Store, bchain bc.Blockchain) *DumpServer {
+	return NewDumpServerWithOptions(str, bchain, DumpServerOptions{})
+}
+
+func NewDumpServerWithOptions(str store.Store, bchain bc.Blockchain, opts DumpServerOptions) *DumpServer {
+	if opts.VisibilityTimeout == 0 {
+		opts.VisibilityTimeout = defaultVisibilityTimeout
+	}
+	if opts.MaxClockSkew == 0 {
+		opts.MaxClockSkew = defaultMaxClockSkew
+	}
+	if opts.DedupWindow == 0 {
+		opts.DedupWindow = defaultDedupWindow
+	}
 	return &DumpServer{
-		store:  str,
-		bchain: bchain,
+		store:         str,
+		bchain:        bchain,
+		opts:          opts,
+		subscriptions: newSubscriptions(),
+		shutdown:      make(chan struct{}),
+		identityVerifier: protoutil.NewIdentityVerifier(protoutil.IdentityVerifierOptions{
+			Audience:         opts.ServerID,
+			MaxClockSkew:     opts.MaxClockSkew,
+			AllowLegacy:      opts.IdentityPolicy != IdentityPolicyStrict,
+			RequireChallenge: opts.RequireChallenge,
+		}),
 	}
 }
 
 func (s *DumpServer) Send(ctx context.Context, req *pb.SendRequest) (*pb.SendResponse, error) {
 	log.Debug().Msg("got send request")
+	msgSize := proto.Size(req.GetMessage())
+	if s.opts.MaxMessageSize > 0 && msgSize > s.opts.MaxMessageSize {
+		log.Warn().Int("size", msgSize).Msg("message is too large")
+		return nil, status.Error(codes.ResourceExhausted, "message is too large")
+	}
+
+	// Check proof of work first, since it's cheap compared to name lookups.
+	if s.opts.PowStrength > 0 {
+		if len(req.GetPow()) == 0 {
+			return nil, protoutil.NewPowRequiredError("proof of work required", s.opts.PowStrength)
+		}
+		if !protoutil.VerifyMessagePow(req.GetMessage(), req.GetPow(), s.opts.PowStrength) {
+			log.Warn().Msg("proof of work verification failed")
+			return nil, protoutil.NewPowRequiredError("insufficient proof of work", s.opts.PowStrength)
+		}
+	}
+
 	protoCurve := req.GetMessage().GetCryptoContext().GetEllipticCurve()
 	curve := protoutil.CurveFromProto(protoCurve)
 	if curve == easyecc.INVALID_CURVE {
@@ -43,71 +163,590 @@ func (s *DumpServer) Send(ctx context.Context, req *pb.SendRequest) (*pb.SendRes
 		return nil, status.Error(codes.Internal, "failed to lookup name")
 	}
 
-	receiverKey, resErr := s.bchain.PublicKeyByCurve(ctx, req.GetMessage().GetReceiver(), curve)
-	if resErr != nil {
-		return nil, status.Error(codes.Internal, "failed to lookup name")
+	receivers, err := sendReceivers(req)
+	if err != nil {
+		return nil, err
+	}
+	var deliveries []*delivery
+	for _, receiver := range receivers {
+		receiverKey, resErr := s.bchain.PublicKeyByCurve(ctx, receiver, curve)
+		if resErr != nil {
+			return nil, status.Error(codes.Internal, "failed to lookup name")
+		}
+		msg := req.GetMessage()
+		if msg.GetReceiver() != receiver {
+			// Each receiver of the envelope gets its own copy.
+			msg = proto.Clone(msg).(*pb.DMSMessage)
+			msg.Receiver = receiver
+		}
+		deliveries = append(deliveries, &delivery{msg: msg, receiverKey: receiverKey.CompressedBytes()})
 	}
 
 	// Verify signature.
 	if !protoutil.VerifySignature(req.GetMessage().GetSignature(),
 		senderKey, req.GetMessage().GetContent()) {
 		log.Warn().Msg("signature verification failed")
+		s.opts.Metrics.SignatureFailure("message")
 		return nil, status.Error(codes.InvalidArgument, "bad signature")
 	}
 
-	err := s.store.Save(req.GetMessage(), receiverKey.CompressedBytes())
+	err = bc.CheckKey(ctx, s.bchain, senderKey.CompressedBytes())
+	if errors.Is(err, bc.ErrKeyDisabled) {
+		log.Warn().Str("sender", req.GetMessage().GetSender()).Msg("sender's key is disabled")
+		return nil, status.Error(codes.PermissionDenied, "sender's key is disabled")
+	}
 	if err != nil {
-		log.Error().Err(err).Msg("failed to save message")
-		return nil, status.Error(codes.Internal, "message store error")
+		log.Error().Err(err).Msg("failed to check sender's key")
+		return nil, status.Error(codes.Internal, "failed to lookup key status")
+	}
+
+	deliveryOptions, err := protoutil.VerifyDeliveryOptions(req.GetMessage(), senderKey)
+	if err != nil {
+		log.Warn().Err(err).Msg("delivery options verification failed")
+		s.opts.Metrics.SignatureFailure("delivery_options")
+		return nil, status.Error(codes.InvalidArgument, "invalid delivery options")
+	}
+	if deliveryOptions.GetExpires() != 0 && deliveryOptions.GetExpires() <= time.Now().Unix() {
+		return nil, status.Error(codes.InvalidArgument, "message has expired")
+	}
+
+	// Check every receiver before anything is stored, so that the message is either
+	// accepted or rejected as a whole.
+	for _, d := range deliveries {
+		if s.opts.Relay != nil {
+			d.relay, err = s.isRemote(ctx, d.msg.GetReceiver())
+			if err != nil {
+				return nil, err
+			}
+			if d.relay && req.GetRelayHops() >= maxRelayHops {
+				log.Warn().Uint32("hops", req.GetRelayHops()).Msg("relay loop detected")
+				return nil, status.Error(codes.InvalidArgument, "relayed message is not for this server")
+			}
+			if d.relay {
+				// The receiver's server checks the policy and the quota, here we only
+				// make sure the relay queue doesn't grow without bounds.
+				err = s.opts.Relay.checkRoom(ctx, proto.Size(d.msg))
+				if err != nil {
+					return nil, relayQueueError(err)
+				}
+				continue
+			}
+		}
+
+		err = s.checkMailboxPolicy(ctx, d.receiverKey, req)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.checkQuota(ctx, d.receiverKey, msgSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, d := range deliveries {
+		if d.relay {
+			err = s.opts.Relay.Enqueue(ctx, d.msg)
+			if err != nil {
+				return nil, relayQueueError(err)
+			}
+			log.Debug().Str("receiver", d.msg.GetReceiver()).Msg("message queued for relay")
+			continue
+		}
+
+		// Every message is deduplicated, so that a captured message can't be replayed
+		// with another message ID, or without one.
+		saved, err := s.store.SaveOnce(ctx, d.msg, d.receiverKey, dedupKey(d.msg), s.opts.DedupWindow)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to save message")
+			return nil, status.Error(codes.Internal, "message store error")
+		}
+		if !saved {
+			log.Debug().Str("sender", d.msg.GetSender()).Msg("duplicate message ignored")
+			continue
+		}
+
+		// Wake up the receiver's subscribers, if any.
+		s.subscriptions.notify(d.receiverKey)
+
+		sender, receiver := eventNames(s.opts.EventNames, d.msg)
+		emitEvent(s.opts.Events, pb.EventType_ET_DUMP_MESSAGE_STORED, sender, receiver, "")
 	}
 
-	// Save message.
 	return &pb.SendResponse{}, nil
 }
 
+// delivery is the message copy for one of the receivers.
+type delivery struct {
+	msg         *pb.DMSMessage
+	receiverKey []byte
+	relay       bool
+}
+
+// dedupKey identifies the retries of the same message. It only depends on the signed
+// sender's content, since the message ID is not signed, and anyone can change it.
+// The content is encrypted with a random key, so different messages never have the
+// same content.
+func dedupKey(msg *pb.DMSMessage) string {
+	h := sha256.New()
+	h.Write([]byte(msg.GetSender()))
+	h.Write([]byte{0})
+	contentHash := sha256.Sum256(msg.GetContent())
+	h.Write(contentHash[:])
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// sendReceivers returns the receivers of the message. The envelope can go to several
+// receivers, and each of them must have the content key.
+func sendReceivers(req *pb.SendRequest) ([]string, error) {
+	if len(req.GetReceiver()) == 0 {
+		return []string{req.GetMessage().GetReceiver()}, nil
+	}
+	if len(req.GetReceiver()) > protoutil.MaxEnvelopeReceivers {
+		return nil, status.Error(codes.InvalidArgument, "too many receivers")
+	}
+	envelopeReceivers, err := protoutil.EnvelopeReceivers(req.GetMessage())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "multiple receivers require an envelope")
+	}
+	var receivers []string
+	for _, receiver := range req.GetReceiver() {
+		if !containsName(envelopeReceivers, receiver) {
+			return nil, status.Error(codes.InvalidArgument, "receiver is not in the envelope")
+		}
+		if !containsName(receivers, receiver) {
+			receivers = append(receivers, receiver)
+		}
+	}
+	return receivers, nil
+}
+
 func (s *DumpServer) Receive(ctx context.Context, req *pb.ReceiveRequest) (*pb.ReceiveResponse, error) {
 	log.Debug().Msg("got receive request")
+	err := s.verifyIdentityProof(req.GetIdentityProof(), req.GetCryptoContext(),
+		protoutil.IdentityOperationReceive)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetRequireAck() {
+		res, err := s.leaseMessage(ctx, req.GetIdentityProof().GetKey())
+		if err != nil {
+			return nil, err
+		}
+		if res == nil {
+			return nil, status.Error(codes.NotFound, "not found")
+		}
+		return res, nil
+	}
+
+	// The message is leased before it's removed, so that it can't be handed out
+	// to an ack-mode receiver at the same time.
+	res, err := s.leaseMessage(ctx, req.GetIdentityProof().GetKey())
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+
+	err = s.store.Ack(ctx, req.GetIdentityProof().GetKey(), res.GetDeliveryId())
+	if err != nil {
+		log.Error().Err(err).Msg("failed to remove message")
+	}
+
+	return &pb.ReceiveResponse{Message: res.GetMessage()}, nil
+}
+
+// Subscribe sends all the messages available for the receiver, and then keeps
+// the stream open, pushing new messages as they are saved. If require_ack is set,
+// the messages are leased instead of removed. Messages with expired leases and
+// scheduled messages are picked up when the next message arrives, or on the periodic
+// mailbox check.
+func (s *DumpServer) Subscribe(req *pb.ReceiveRequest, stream pb.DMSDumpService_SubscribeServer) error {
+	log.Debug().Msg("got subscribe request")
+	err := s.verifyIdentityProof(req.GetIdentityProof(), req.GetCryptoContext(),
+		protoutil.IdentityOperationSubscribe)
+	if err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+	key := req.GetIdentityProof().GetKey()
+	// Subscribe before draining the mailbox, so that we don't miss messages
+	// saved in between.
+	notifications, unsubscribe := s.subscriptions.subscribe(key)
+	defer unsubscribe()
+	ticker := time.NewTicker(subscribePollInterval)
+	defer ticker.Stop()
+
+	for {
+		for req.GetRequireAck() {
+			res, err := s.leaseMessage(ctx, key)
+			if err != nil {
+				return err
+			}
+			if res == nil {
+				break
+			}
+			err = stream.Send(res)
+			if err != nil {
+				log.Debug().Err(err).Msg("failed to send message to subscriber")
+				return err
+			}
+		}
+
+		for !req.GetRequireAck() {
+			res, err := s.leaseMessage(ctx, key)
+			if err != nil {
+				return err
+			}
+			if res == nil {
+				break
+			}
+			err = stream.Send(&pb.ReceiveResponse{Message: res.GetMessage()})
+			if err != nil {
+				log.Debug().Err(err).Msg("failed to send message to subscriber")
+				return err
+			}
+			err = s.store.Ack(ctx, key, res.GetDeliveryId())
+			if err != nil {
+				log.Error().Err(err).Msg("failed to remove message")
+				return status.Error(codes.Internal, "message store error")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Debug().Msg("subscriber is gone")
+			return nil
+		case <-s.shutdown:
+			log.Debug().Msg("closing subscription, server is shutting down")
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case <-notifications:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Ack removes the messages that were received with require_ack.
+func (s *DumpServer) Ack(ctx context.Context, req *pb.AckRequest) (*pb.AckResponse, error) {
+	log.Debug().Msg("got ack request")
+	err := s.verifyIdentityProof(req.GetIdentityProof(), req.GetCryptoContext(),
+		protoutil.IdentityOperationAck)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, deliveryID := range req.GetDeliveryId() {
+		err = s.store.Ack(ctx, req.GetIdentityProof().GetKey(), deliveryID)
+		if errors.Is(err, store.ErrInvalidDeliveryID) {
+			return nil, status.Error(codes.InvalidArgument, "invalid delivery id")
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("failed to acknowledge message")
+			return nil, status.Error(codes.Internal, "message store error")
+		}
+	}
+	return &pb.AckResponse{}, nil
+}
+
+// Release makes the messages that were received with require_ack available again.
+func (s *DumpServer) Release(ctx context.Context, req *pb.ReleaseRequest) (*pb.ReleaseResponse, error) {
+	log.Debug().Msg("got release request")
+	err := s.verifyIdentityProof(req.GetIdentityProof(), req.GetCryptoContext(),
+		protoutil.IdentityOperationRelease)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, deliveryID := range req.GetDeliveryId() {
+		err = s.store.Requeue(ctx, req.GetIdentityProof().GetKey(), deliveryID, 0)
+		if errors.Is(err, store.ErrInvalidDeliveryID) {
+			return nil, status.Error(codes.InvalidArgument, "invalid delivery id")
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("failed to release message")
+			return nil, status.Error(codes.Internal, "message store error")
+		}
+	}
+	return &pb.ReleaseResponse{}, nil
+}
+
+// GetChallenge issues a nonce to be used in the identity proof.
+func (s *DumpServer) GetChallenge(ctx context.Context, req *pb.GetChallengeRequest) (*pb.GetChallengeResponse, error) {
+	now := time.Now()
+	nonce, err := s.identityVerifier.IssueChallenge(now)
+	if errors.Is(err, protoutil.ErrNonceCacheFull) {
+		log.Warn().Msg("too many outstanding challenges, rejecting")
+		return nil, status.Error(codes.ResourceExhausted, "too many requests, try again later")
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("failed to issue challenge")
+		return nil, status.Error(codes.Internal, "failed to issue challenge")
+	}
+	return &pb.GetChallengeResponse{
+		Nonce:     nonce,
+		ServerId:  s.opts.ServerID,
+		Timestamp: now.Unix(),
+	}, nil
+}
+
+// SetMailboxPolicy saves the receiver's mailbox policy, which decides whose messages
+// are accepted. The policy must be signed by the receiver, and be newer than the
+// current one.
+func (s *DumpServer) SetMailboxPolicy(ctx context.Context, req *pb.SetMailboxPolicyRequest) (*pb.SetMailboxPolicyResponse, error) {
+	log.Debug().Msg("got set mailbox policy request")
+	err := s.verifyIdentityProof(req.GetIdentityProof(), req.GetCryptoContext(),
+		protoutil.IdentityOperationSetPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	receiverKey := req.GetIdentityProof().GetKey()
+	if !bytes.Equal(req.GetPolicy().GetKey(), receiverKey) {
+		return nil, status.Error(codes.PermissionDenied, "policy is signed by another key")
+	}
 	curve := easyecc.SECP256K1
 	if req.GetCryptoContext() != nil {
-		protoCurve := req.GetCryptoContext().GetEllipticCurve()
-		curve = protoutil.CurveFromProto(protoCurve)
-		if curve == easyecc.INVALID_CURVE {
-			return nil, status.Error(codes.Internal, "invalid curve")
-		}
+		curve = protoutil.CurveFromProto(req.GetCryptoContext().GetEllipticCurve())
+	}
+	policy, err := protoutil.VerifyMailboxPolicy(req.GetPolicy(), curve)
+	if err != nil {
+		log.Warn().Err(err).Msg("mailbox policy verification failed")
+		s.opts.Metrics.SignatureFailure("policy")
+		return nil, status.Error(codes.InvalidArgument, "invalid mailbox policy")
+	}
+	if time.Unix(policy.GetTimestamp(), 0).After(time.Now().Add(s.opts.MaxClockSkew)) {
+		return nil, status.Error(codes.InvalidArgument, "policy timestamp is in the future")
+	}
+
+	// Don't let an old policy replace the newer one.
+	current, err := s.loadMailboxPolicy(ctx, receiverKey)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && policy.GetTimestamp() <= current.GetTimestamp() {
+		return nil, status.Error(codes.FailedPrecondition, "policy is not newer than the current one")
 	}
 
-	key, err := easyecc.NewPublicKeyFromCompressedBytes(curve, req.GetIdentityProof().GetKey())
+	err = s.store.SavePolicy(ctx, receiverKey, req.GetPolicy())
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, "invalid key")
+		log.Error().Err(err).Msg("failed to save mailbox policy")
+		return nil, status.Error(codes.Internal, "message store error")
 	}
-	err = protoutil.VerifyIdentity(req.GetIdentityProof(), time.Now(), 10.0, curve)
+	auditEvent(auditMailboxPolicyChanged).
+		Hex("key", receiverKey).
+		Int("allow", len(policy.GetAllow())).
+		Int("block", len(policy.GetBlock())).
+		Str("strangers", policy.GetStrangers().String()).
+		Msg("mailbox policy changed")
+	emitEvent(s.opts.Events, pb.EventType_ET_DUMP_MAILBOX_POLICY_CHANGED, hex.EncodeToString(receiverKey), "",
+		policy.GetStrangers().String())
+	return &pb.SetMailboxPolicyResponse{}, nil
+}
+
+// isRemote returns true if the receiver's messages are served by another dump server.
+func (s *DumpServer) isRemote(ctx context.Context, receiver string) (bool, error) {
+	endpoint, err := s.bchain.Endpoint(ctx, receiver)
 	if err != nil {
-		log.Debug().Err(err).Msg("identity verification failed, using fallback")
-		// return nil, status.Error(codes.InvalidArgument, "bad signature")
-		// For now, we fallback to the old verification algorithm. To be removed later.
-		// TODO: remove this once all the clients are migrated.
-		if !protoutil.VerifySignature(req.GetIdentityProof().GetSignature(), key,
-			req.GetIdentityProof().GetContent()) {
-			log.Warn().Msg("signature verification failed")
-			return nil, status.Error(codes.InvalidArgument, "bad signature")
+		return false, status.Error(codes.Internal, "failed to lookup endpoint")
+	}
+	for _, local := range s.opts.LocalEndpoints {
+		if normalizeEndpoint(local) == normalizeEndpoint(endpoint) {
+			return false, nil
 		}
-		log.Debug().Msg("signature verification succeeded")
 	}
+	return true, nil
+}
 
-	msg, err := s.store.GetNext(req.GetIdentityProof().GetKey())
+// checkMailboxPolicy returns an error if the receiver's policy doesn't accept
+// messages from the sender.
+func (s *DumpServer) checkMailboxPolicy(ctx context.Context, receiverKey []byte, req *pb.SendRequest) error {
+	policy, err := s.loadMailboxPolicy(ctx, receiverKey)
+	if err != nil || policy == nil {
+		return err
+	}
+	sender := req.GetMessage().GetSender()
+	if containsName(policy.GetBlock(), sender) {
+		log.Debug().Str("sender", sender).Msg("sender is blocked by the receiver")
+		return status.Error(codes.PermissionDenied, "sender is not allowed by the receiver")
+	}
+	if containsName(policy.GetAllow(), sender) {
+		return nil
+	}
+	switch policy.GetStrangers() {
+	case pb.StrangerPolicy_SP_REJECT:
+		log.Debug().Str("sender", sender).Msg("sender is not in the receiver's allow list")
+		return status.Error(codes.PermissionDenied, "sender is not allowed by the receiver")
+	case pb.StrangerPolicy_SP_REQUIRE_POW:
+		strength := int(policy.GetPowStrength())
+		if s.opts.PowStrength > strength {
+			strength = s.opts.PowStrength
+		}
+		if len(req.GetPow()) == 0 {
+			return protoutil.NewPowRequiredError("proof of work required by the receiver", strength)
+		}
+		if !protoutil.VerifyMessagePow(req.GetMessage(), req.GetPow(), strength) {
+			return protoutil.NewPowRequiredError("insufficient proof of work", strength)
+		}
+	}
+	return nil
+}
+
+// loadMailboxPolicy returns the receiver's mailbox policy, or nil if it's not set.
+// The signature is verified when the policy is saved, so it's not checked again.
+func (s *DumpServer) loadMailboxPolicy(ctx context.Context, receiverKey []byte) (*pb.MailboxPolicy, error) {
+	signed, err := s.store.GetPolicy(ctx, receiverKey)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get mailbox policy")
+		return nil, status.Error(codes.Internal, "message store error")
+	}
+	if signed == nil {
+		return nil, nil
+	}
+	policy := &pb.MailboxPolicy{}
+	err = proto.Unmarshal(signed.GetContent(), policy)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to get next message")
+		log.Error().Err(err).Msg("failed to parse mailbox policy")
 		return nil, status.Error(codes.Internal, "message store error")
 	}
+	return policy, nil
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkQuota returns an error if the receiver's mailbox has no room for another
+// message of the given size. It also records the mailbox size metrics.
+func (s *DumpServer) checkQuota(ctx context.Context, receiverKey []byte, msgSize int) error {
+	if s.opts.MaxMailboxMessages <= 0 && s.opts.MaxMailboxBytes <= 0 && s.opts.Metrics == nil {
+		return nil
+	}
+	stats, err := s.store.Stats(ctx, receiverKey)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get mailbox stats")
+		return status.Error(codes.Internal, "message store error")
+	}
+	s.opts.Metrics.MailboxSize(stats.Count, stats.Size)
+	if s.opts.MaxMailboxMessages > 0 && stats.Count >= s.opts.MaxMailboxMessages {
+		log.Warn().Int("count", stats.Count).Msg("mailbox message quota exceeded")
+		return status.Error(codes.ResourceExhausted, "mailbox is full")
+	}
+	if s.opts.MaxMailboxBytes > 0 && stats.Size+int64(msgSize) > s.opts.MaxMailboxBytes {
+		log.Warn().Int64("size", stats.Size).Msg("mailbox size quota exceeded")
+		return status.Error(codes.ResourceExhausted, "mailbox is full")
+	}
+	return nil
+}
 
+// leaseMessage leases the next message for the receiver. It returns nil if there
+// are no messages available.
+func (s *DumpServer) leaseMessage(ctx context.Context, receiverKey []byte) (*pb.ReceiveResponse, error) {
+	msg, deliveryID, err := s.store.Lease(ctx, receiverKey, s.opts.VisibilityTimeout)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to lease message")
+		return nil, status.Error(codes.Internal, "message store error")
+	}
 	if msg == nil {
-		return nil, status.Error(codes.NotFound, "not found")
+		return nil, nil
 	}
+	return &pb.ReceiveResponse{
+		Message:                  msg,
+		DeliveryId:               deliveryID,
+		VisibilityTimeoutSeconds: int32(s.opts.VisibilityTimeout.Seconds()),
+	}, nil
+}
 
-	err = s.store.Remove(msg, req.GetIdentityProof().GetKey())
+// Shutdown closes the open Subscribe streams, so that the server can stop gracefully.
+func (s *DumpServer) Shutdown() {
+	s.shutdownOnce.Do(func() {
+		close(s.shutdown)
+	})
+}
+
+// IdentityStats returns the legacy identity proof counters.
+func (s *DumpServer) IdentityStats() IdentityStats {
+	return IdentityStats{
+		LegacyProofs: s.identityCounters.legacyProofs.Load(),
+		Fallbacks:    s.identityCounters.fallbacks.Load(),
+	}
+}
+
+// verifyIdentityProof verifies the identity proof that comes with the request.
+func (s *DumpServer) verifyIdentityProof(identityProof *pb.Signed, cryptoContext *pb.CryptoContext,
+	operation string) error {
+	curve := easyecc.SECP256K1
+	if cryptoContext != nil {
+		protoCurve := cryptoContext.GetEllipticCurve()
+		curve = protoutil.CurveFromProto(protoCurve)
+		if curve == easyecc.INVALID_CURVE {
+			return status.Error(codes.Internal, "invalid curve")
+		}
+	}
+
+	key, err := easyecc.NewPublicKeyFromCompressedBytes(curve, identityProof.GetKey())
 	if err != nil {
-		log.Error().Err(err).Msg("failed to remove message")
+		return status.Error(codes.InvalidArgument, "invalid key")
+	}
+	legacy := protoutil.IsLegacyIdentityProof(identityProof)
+	err = s.identityVerifier.Verify(identityProof, operation, curve, time.Now())
+	if err == nil {
+		if legacy {
+			s.countIdentity(&s.identityCounters.legacyProofs, "proof")
+			auditEvent(auditLegacyIdentityProof).
+				Hex("key", identityProof.GetKey()).
+				Str("operation", operation).
+				Msg("accepted legacy identity proof")
+		}
+		return nil
+	}
+
+	if errors.Is(err, protoutil.ErrNonceCacheFull) {
+		log.Warn().Str("operation", operation).Msg("too many identity proofs, rejecting")
+		return status.Error(codes.ResourceExhausted, "too many requests, try again later")
+	}
+
+	// Only the legacy identity proofs get the fallback, and only if the policy allows it.
+	if !legacy || s.opts.IdentityPolicy == IdentityPolicyStrict ||
+		errors.Is(err, protoutil.ErrLegacyIdentityProof) {
+		auditEvent(auditIdentityRejected).
+			Err(err).
+			Hex("key", identityProof.GetKey()).
+			Str("operation", operation).
+			Str("policy", s.opts.IdentityPolicy.String()).
+			Msg("identity verification failed")
+		s.opts.Metrics.SignatureFailure("identity")
+		return status.Error(codes.InvalidArgument, "bad identity proof")
 	}
 
-	return &pb.ReceiveResponse{Message: msg}, nil
+	// The fallback doesn't check the timestamp, so the proof can be replayed.
+	// TODO: remove this once all the clients are migrated.
+	if !protoutil.VerifySignature(identityProof.GetSignature(), key,
+		identityProof.GetContent()) {
+		log.Warn().Msg("signature verification failed")
+		s.opts.Metrics.SignatureFailure("identity")
+		return status.Error(codes.InvalidArgument, "bad signature")
+	}
+	s.countIdentity(&s.identityCounters.fallbacks, "fallback")
+	auditEvent(auditLegacyIdentityFallback).
+		Err(err).
+		Hex("key", identityProof.GetKey()).
+		Str("operation", operation).
+		Msg("accepted identity proof by signature only")
+	return nil
+}
+
+// countIdentity increments the identity counter, if the policy requires metrics.
+func (s *DumpServer) countIdentity(counter *atomic.Int64, kind string) {
+	if s.opts.IdentityPolicy == IdentityPolicyLegacyWithMetrics {
+		counter.Add(1)
+		s.opts.Metrics.LegacyIdentity(kind)
+	}
 }
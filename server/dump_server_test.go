@@ -1,16 +1,27 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/regnull/easyecc/v2"
 	bcmocks "github.com/regnull/ubikom/bc/mocks"
+	"github.com/regnull/ubikom/events"
+	"github.com/regnull/ubikom/metrics"
 	"github.com/regnull/ubikom/pb"
 	"github.com/regnull/ubikom/protoutil"
 	"github.com/regnull/ubikom/store"
+	"github.com/regnull/ubikom/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
 func Test_DumpServer_SendReceive(t *testing.T) {
@@ -18,6 +29,7 @@ func Test_DumpServer_SendReceive(t *testing.T) {
 
 	dumpStore := store.NewMemory()
 	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
 	ctx := context.Background()
 	dumpServer := NewDumpServer(dumpStore, bchain)
 
@@ -66,6 +78,7 @@ func Test_DumpServer_SendReceiveLegacy(t *testing.T) {
 
 	dumpStore := store.NewMemory()
 	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
 	ctx := context.Background()
 	dumpServer := NewDumpServer(dumpStore, bchain)
 
@@ -110,6 +123,7 @@ func Test_DumpServer_IdentityFallback(t *testing.T) {
 
 	dumpStore := store.NewMemory()
 	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
 	ctx := context.Background()
 	dumpServer := NewDumpServer(dumpStore, bchain)
 
@@ -153,3 +167,942 @@ func Test_DumpServer_IdentityFallback(t *testing.T) {
 
 	bchain.AssertExpectations(t)
 }
+
+func Test_DumpServer_ReceiveAck(t *testing.T) {
+	assert := assert.New(t)
+
+	dumpStore := store.NewMemory()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
+	ctx := context.Background()
+	dumpServer := NewDumpServerWithOptions(dumpStore, bchain,
+		DumpServerOptions{VisibilityTimeout: 50 * time.Millisecond})
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+
+	bchain.EXPECT().PublicKeyByCurve(ctx, "alice",
+		easyecc.P256).Return(aliceKey.PublicKey(), nil)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "bob",
+		easyecc.P256).Return(bobKey.PublicKey(), nil)
+
+	msg, err := protoutil.CreateMessage(aliceKey, []byte("hi bob"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.NoError(err)
+
+	cryptoContext := &pb.CryptoContext{
+		EllipticCurve: pb.EllipticCurve(easyecc.P256),
+		EcdhVersion:   2,
+		EcdsaVersion:  1,
+	}
+	receive := func(requireAck bool) (*pb.ReceiveResponse, error) {
+		identityProof, err := protoutil.IdentityProof(bobKey, time.Now())
+		assert.NoError(err)
+		return dumpServer.Receive(ctx, &pb.ReceiveRequest{
+			IdentityProof: identityProof,
+			CryptoContext: cryptoContext,
+			RequireAck:    requireAck,
+		})
+	}
+
+	receiveRes, err := receive(true)
+	assert.NoError(err)
+	assert.NotEmpty(receiveRes.GetDeliveryId())
+	assert.True(proto.Equal(msg, receiveRes.GetMessage()))
+
+	// The message is hidden until the visibility timeout expires.
+	_, err = receive(true)
+	assert.True(util.ErrEqualCode(err, codes.NotFound))
+	// Including the receivers which don't acknowledge.
+	_, err = receive(false)
+	assert.True(util.ErrEqualCode(err, codes.NotFound))
+
+	// The message which is not acknowledged comes back.
+	time.Sleep(100 * time.Millisecond)
+	receiveRes, err = receive(true)
+	assert.NoError(err)
+	assert.True(proto.Equal(msg, receiveRes.GetMessage()))
+
+	identityProof, err := protoutil.IdentityProof(bobKey, time.Now())
+	assert.NoError(err)
+	_, err = dumpServer.Ack(ctx, &pb.AckRequest{
+		IdentityProof: identityProof,
+		CryptoContext: cryptoContext,
+		DeliveryId:    []string{receiveRes.GetDeliveryId()},
+	})
+	assert.NoError(err)
+
+	// Once acknowledged, the message is gone for good.
+	time.Sleep(100 * time.Millisecond)
+	_, err = receive(true)
+	assert.True(util.ErrEqualCode(err, codes.NotFound))
+
+	bchain.AssertExpectations(t)
+}
+
+func Test_DumpServer_ReceiveRelease(t *testing.T) {
+	assert := assert.New(t)
+
+	dumpStore := store.NewMemory()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
+	ctx := context.Background()
+	dumpServer := NewDumpServerWithOptions(dumpStore, bchain,
+		DumpServerOptions{VisibilityTimeout: time.Hour})
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "alice",
+		easyecc.P256).Return(aliceKey.PublicKey(), nil)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "bob",
+		easyecc.P256).Return(bobKey.PublicKey(), nil)
+
+	msg, err := protoutil.CreateMessage(aliceKey, []byte("hi bob"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.NoError(err)
+
+	cryptoContext := &pb.CryptoContext{
+		EllipticCurve: pb.EllipticCurve(easyecc.P256),
+		EcdhVersion:   2,
+		EcdsaVersion:  1,
+	}
+	receive := func() (*pb.ReceiveResponse, error) {
+		identityProof, err := protoutil.IdentityProof(bobKey, time.Now())
+		assert.NoError(err)
+		return dumpServer.Receive(ctx, &pb.ReceiveRequest{
+			IdentityProof: identityProof,
+			CryptoContext: cryptoContext,
+			RequireAck:    true,
+		})
+	}
+
+	receiveRes, err := receive()
+	assert.NoError(err)
+	_, err = receive()
+	assert.True(util.ErrEqualCode(err, codes.NotFound))
+
+	// The released message is available again, without waiting for the timeout.
+	identityProof, err := protoutil.IdentityProof(bobKey, time.Now())
+	assert.NoError(err)
+	_, err = dumpServer.Release(ctx, &pb.ReleaseRequest{
+		IdentityProof: identityProof,
+		CryptoContext: cryptoContext,
+		DeliveryId:    []string{receiveRes.GetDeliveryId()},
+	})
+	assert.NoError(err)
+	receiveRes, err = receive()
+	assert.NoError(err)
+	assert.True(proto.Equal(msg, receiveRes.GetMessage()))
+
+	_, err = dumpServer.Release(ctx, &pb.ReleaseRequest{
+		IdentityProof: identityProof,
+		CryptoContext: cryptoContext,
+		DeliveryId:    []string{"../foo"},
+	})
+	assert.True(util.ErrEqualCode(err, codes.InvalidArgument))
+
+	bchain.AssertExpectations(t)
+}
+
+func Test_DumpServer_SendPow(t *testing.T) {
+	assert := assert.New(t)
+
+	dumpStore := store.NewMemory()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
+	ctx := context.Background()
+	const powStrength = 10
+	dumpServer := NewDumpServerWithOptions(dumpStore, bchain,
+		DumpServerOptions{PowStrength: powStrength})
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+
+	bchain.EXPECT().PublicKeyByCurve(ctx, "alice",
+		easyecc.P256).Return(aliceKey.PublicKey(), nil)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "bob",
+		easyecc.P256).Return(bobKey.PublicKey(), nil)
+
+	msg, err := protoutil.CreateMessage(aliceKey, []byte("hi bob"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+
+	// No proof of work.
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.True(util.ErrEqualCode(err, codes.FailedPrecondition))
+	strength, ok := protoutil.PowRequirementFromError(err)
+	assert.True(ok)
+	assert.Equal(powStrength, strength)
+
+	// Bad proof of work.
+	badPow := []byte{0x01}
+	for protoutil.VerifyMessagePow(msg, badPow, powStrength) {
+		badPow[0]++
+	}
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg, Pow: badPow})
+	assert.True(util.ErrEqualCode(err, codes.FailedPrecondition))
+
+	// Good proof of work.
+	nonce, err := protoutil.ComputeMessagePow(ctx, msg, powStrength)
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg, Pow: nonce})
+	assert.NoError(err)
+
+	msgs, err := dumpStore.GetAll(ctx, bobKey.PublicKey().CompressedBytes())
+	assert.NoError(err)
+	assert.Len(msgs, 1)
+
+	bchain.AssertExpectations(t)
+}
+
+func Test_DumpServer_Quota(t *testing.T) {
+	assert := assert.New(t)
+
+	dumpStore := store.NewMemory()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
+	ctx := context.Background()
+	dumpServer := NewDumpServerWithOptions(dumpStore, bchain,
+		DumpServerOptions{MaxMessageSize: 1000, MaxMailboxMessages: 2})
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+
+	bchain.EXPECT().PublicKeyByCurve(ctx, "alice",
+		easyecc.P256).Return(aliceKey.PublicKey(), nil)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "bob",
+		easyecc.P256).Return(bobKey.PublicKey(), nil)
+
+	// The message is too large.
+	msg, err := protoutil.CreateMessage(aliceKey, make([]byte, 2000), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.True(util.ErrEqualCode(err, codes.ResourceExhausted))
+
+	for i := 0; i < 2; i++ {
+		msg, err := protoutil.CreateMessage(aliceKey, []byte(fmt.Sprintf("message %d", i)),
+			"alice", "bob", bobKey.PublicKey())
+		assert.NoError(err)
+		_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+		assert.NoError(err)
+	}
+
+	// The mailbox is full.
+	msg, err = protoutil.CreateMessage(aliceKey, []byte("one too many"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.True(util.ErrEqualCode(err, codes.ResourceExhausted))
+
+	bchain.AssertExpectations(t)
+}
+
+func Test_DumpServer_QuotaBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	dumpStore := store.NewMemory()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
+	ctx := context.Background()
+	dumpServer := NewDumpServerWithOptions(dumpStore, bchain,
+		DumpServerOptions{MaxMailboxBytes: 1500})
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+
+	bchain.EXPECT().PublicKeyByCurve(ctx, "alice",
+		easyecc.P256).Return(aliceKey.PublicKey(), nil)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "bob",
+		easyecc.P256).Return(bobKey.PublicKey(), nil)
+
+	msg, err := protoutil.CreateMessage(aliceKey, make([]byte, 1000), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.NoError(err)
+
+	msg, err = protoutil.CreateMessage(aliceKey, make([]byte, 1000), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.True(util.ErrEqualCode(err, codes.ResourceExhausted))
+
+	bchain.AssertExpectations(t)
+}
+
+func Test_DumpServer_IdentityChallenge(t *testing.T) {
+	assert := assert.New(t)
+
+	dumpStore := store.NewMemory()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
+	ctx := context.Background()
+	dumpServer := NewDumpServerWithOptions(dumpStore, bchain, DumpServerOptions{
+		ServerID:       "dump1",
+		IdentityPolicy: IdentityPolicyStrict,
+	})
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+
+	bchain.EXPECT().PublicKeyByCurve(ctx, "alice",
+		easyecc.P256).Return(aliceKey.PublicKey(), nil)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "bob",
+		easyecc.P256).Return(bobKey.PublicKey(), nil)
+
+	for i := 0; i < 2; i++ {
+		msg, err := protoutil.CreateMessage(aliceKey, []byte("hi bob"), "alice", "bob", bobKey.PublicKey())
+		assert.NoError(err)
+		_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+		assert.NoError(err)
+	}
+
+	cryptoContext := &pb.CryptoContext{
+		EllipticCurve: pb.EllipticCurve(easyecc.P256),
+		EcdhVersion:   2,
+		EcdsaVersion:  1,
+	}
+	challenge, err := dumpServer.GetChallenge(ctx, &pb.GetChallengeRequest{})
+	assert.NoError(err)
+	assert.Equal("dump1", challenge.GetServerId())
+
+	identityProof, err := protoutil.CreateIdentityProof(bobKey, challenge.GetNonce(), challenge.GetServerId(),
+		protoutil.IdentityOperationReceive, time.Now())
+	assert.NoError(err)
+	req := &pb.ReceiveRequest{
+		IdentityProof: identityProof,
+		CryptoContext: cryptoContext,
+	}
+	_, err = dumpServer.Receive(ctx, req)
+	assert.NoError(err)
+
+	// Replay is rejected.
+	_, err = dumpServer.Receive(ctx, req)
+	assert.True(util.ErrEqualCode(err, codes.InvalidArgument))
+
+	// Legacy proof is rejected.
+	identityProof, err = protoutil.IdentityProof(bobKey, time.Now())
+	assert.NoError(err)
+	_, err = dumpServer.Receive(ctx, &pb.ReceiveRequest{
+		IdentityProof: identityProof,
+		CryptoContext: cryptoContext,
+	})
+	assert.True(util.ErrEqualCode(err, codes.InvalidArgument))
+
+	bchain.AssertExpectations(t)
+}
+
+func Test_DumpServer_IdentityPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	bobKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	cryptoContext := &pb.CryptoContext{
+		EllipticCurve: pb.EllipticCurve(easyecc.P256),
+		EcdhVersion:   2,
+		EcdsaVersion:  1,
+	}
+
+	// The timestamp is too old, only signature-only fallback can accept it.
+	staleProof, err := protoutil.IdentityProof(bobKey, time.Now().Add(-time.Hour))
+	assert.NoError(err)
+	freshProof, err := protoutil.IdentityProof(bobKey, time.Now())
+	assert.NoError(err)
+
+	dumpServer := NewDumpServerWithOptions(store.NewMemory(), nil, DumpServerOptions{
+		IdentityPolicy: IdentityPolicyLegacyWithMetrics,
+	})
+	_, err = dumpServer.Receive(ctx, &pb.ReceiveRequest{IdentityProof: staleProof, CryptoContext: cryptoContext})
+	assert.True(util.ErrEqualCode(err, codes.NotFound))
+	_, err = dumpServer.Receive(ctx, &pb.ReceiveRequest{IdentityProof: freshProof, CryptoContext: cryptoContext})
+	assert.True(util.ErrEqualCode(err, codes.NotFound))
+	assert.Equal(IdentityStats{LegacyProofs: 1, Fallbacks: 1}, dumpServer.IdentityStats())
+
+	dumpServer = NewDumpServerWithOptions(store.NewMemory(), nil, DumpServerOptions{
+		IdentityPolicy: IdentityPolicyStrict,
+	})
+	_, err = dumpServer.Receive(ctx, &pb.ReceiveRequest{IdentityProof: staleProof, CryptoContext: cryptoContext})
+	assert.True(util.ErrEqualCode(err, codes.InvalidArgument))
+	_, err = dumpServer.Receive(ctx, &pb.ReceiveRequest{IdentityProof: freshProof, CryptoContext: cryptoContext})
+	assert.True(util.ErrEqualCode(err, codes.InvalidArgument))
+	assert.Equal(IdentityStats{}, dumpServer.IdentityStats())
+}
+
+func Test_DumpServer_Metrics(t *testing.T) {
+	assert := assert.New(t)
+
+	reg := prometheus.NewRegistry()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
+	ctx := context.Background()
+	dumpServer := NewDumpServerWithOptions(store.NewMemory(), bchain, DumpServerOptions{
+		IdentityPolicy: IdentityPolicyLegacyWithMetrics,
+		Metrics:        metrics.New(reg),
+	})
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "alice", easyecc.P256).Return(aliceKey.PublicKey(), nil)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "bob", easyecc.P256).Return(bobKey.PublicKey(), nil)
+	cryptoContext := &pb.CryptoContext{
+		EllipticCurve: pb.EllipticCurve(easyecc.P256),
+		EcdhVersion:   2,
+		EcdsaVersion:  1,
+	}
+
+	// The mailbox size is observed for every accepted message.
+	msg, err := protoutil.CreateMessage(aliceKey, []byte("hi bob"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.NoError(err)
+	assert.Equal(2.0, gatheredValue(t, reg, "ubikom_dump_mailbox_messages", ""))
+	assert.Equal(2.0, gatheredValue(t, reg, "ubikom_dump_mailbox_bytes", ""))
+
+	// Bad message signature.
+	msg, err = protoutil.CreateMessage(aliceKey, []byte("hi bob"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	msg.Content = append(msg.Content, 0)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.True(util.ErrEqualCode(err, codes.InvalidArgument))
+	assert.Equal(1.0, gatheredValue(t, reg, "ubikom_dump_signature_failures_total", "message"))
+
+	// The legacy identity proofs, accepted as is and by signature only.
+	freshProof, err := protoutil.IdentityProof(bobKey, time.Now())
+	assert.NoError(err)
+	_, err = dumpServer.Receive(ctx, &pb.ReceiveRequest{IdentityProof: freshProof, CryptoContext: cryptoContext})
+	assert.NoError(err)
+	staleProof, err := protoutil.IdentityProof(bobKey, time.Now().Add(-time.Hour))
+	assert.NoError(err)
+	_, err = dumpServer.Receive(ctx, &pb.ReceiveRequest{IdentityProof: staleProof, CryptoContext: cryptoContext})
+	assert.True(util.ErrEqualCode(err, codes.NotFound))
+	assert.Equal(1.0, gatheredValue(t, reg, "ubikom_dump_legacy_identity_total", "proof"))
+	assert.Equal(1.0, gatheredValue(t, reg, "ubikom_dump_legacy_identity_total", "fallback"))
+
+	// Bad identity proof signature.
+	badProof, err := protoutil.IdentityProof(bobKey, time.Now().Add(-time.Hour))
+	assert.NoError(err)
+	badProof.Signature.R = append(badProof.Signature.R, 1)
+	_, err = dumpServer.Receive(ctx, &pb.ReceiveRequest{IdentityProof: badProof, CryptoContext: cryptoContext})
+	assert.True(util.ErrEqualCode(err, codes.InvalidArgument))
+	assert.Equal(1.0, gatheredValue(t, reg, "ubikom_dump_signature_failures_total", "identity"))
+}
+
+// gatheredValue returns the value of the counter with the given label value, or the
+// number of observations of the histogram.
+func gatheredValue(t *testing.T, reg *prometheus.Registry, name string, label string) float64 {
+	families, err := reg.Gather()
+	assert.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			if m.GetHistogram() != nil {
+				return float64(m.GetHistogram().GetSampleCount())
+			}
+			if len(m.GetLabel()) > 0 && m.GetLabel()[0].GetValue() == label {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func Test_DumpServer_SendScheduled(t *testing.T) {
+	assert := assert.New(t)
+
+	dumpStore := store.NewMemory()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
+	ctx := context.Background()
+	dumpServer := NewDumpServer(dumpStore, bchain)
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+
+	bchain.EXPECT().PublicKeyByCurve(ctx, "alice", easyecc.P256).Return(aliceKey.PublicKey(), nil)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "bob", easyecc.P256).Return(bobKey.PublicKey(), nil)
+
+	// The message is saved, but not delivered yet.
+	msg, err := protoutil.CreateMessage(aliceKey, []byte("hi bob"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	assert.NoError(protoutil.SetDeliveryOptions(aliceKey, msg, protoutil.MessageOptions{
+		NotBefore: time.Now().Add(time.Hour),
+	}))
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.NoError(err)
+
+	identityProof, err := protoutil.IdentityProof(bobKey, time.Now())
+	assert.NoError(err)
+	_, err = dumpServer.Receive(ctx, &pb.ReceiveRequest{
+		IdentityProof: identityProof,
+		CryptoContext: &pb.CryptoContext{
+			EllipticCurve: pb.EllipticCurve(easyecc.P256),
+			EcdhVersion:   2,
+			EcdsaVersion:  1,
+		},
+	})
+	assert.True(util.ErrEqualCode(err, codes.NotFound))
+
+	// Expired message is rejected.
+	msg, err = protoutil.CreateMessage(aliceKey, []byte("hi bob"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	assert.NoError(protoutil.SetDeliveryOptions(aliceKey, msg, protoutil.MessageOptions{
+		Expires: time.Now().Add(-time.Minute),
+	}))
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.True(util.ErrEqualCode(err, codes.InvalidArgument))
+
+	// Options signed by someone else are rejected.
+	msg, err = protoutil.CreateMessage(aliceKey, []byte("hi bob"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	assert.NoError(protoutil.SetDeliveryOptions(bobKey, msg, protoutil.MessageOptions{
+		Expires: time.Now().Add(time.Hour),
+	}))
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.True(util.ErrEqualCode(err, codes.InvalidArgument))
+
+	bchain.AssertExpectations(t)
+}
+
+type testSubscribeServer struct {
+	grpc.ServerStream
+
+	ctx       context.Context
+	responses chan *pb.ReceiveResponse
+}
+
+func (s *testSubscribeServer) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testSubscribeServer) Send(res *pb.ReceiveResponse) error {
+	s.responses <- res
+	return nil
+}
+
+func Test_DumpServer_Subscribe(t *testing.T) {
+	assert := assert.New(t)
+
+	// Subscribe runs concurrently with Send, so we need a thread-safe store.
+	dumpStore, err := store.NewBadger(t.TempDir(), time.Hour)
+	assert.NoError(err)
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
+	ctx := context.Background()
+	dumpServer := NewDumpServer(dumpStore, bchain)
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+
+	bchain.EXPECT().PublicKeyByCurve(ctx, "alice",
+		easyecc.P256).Return(aliceKey.PublicKey(), nil)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "bob",
+		easyecc.P256).Return(bobKey.PublicKey(), nil)
+
+	// This message is stored before the subscription.
+	msg, err := protoutil.CreateMessage(aliceKey, []byte("first"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.NoError(err)
+
+	identityProof, err := protoutil.IdentityProof(bobKey, time.Now())
+	assert.NoError(err)
+	req := &pb.ReceiveRequest{
+		IdentityProof: identityProof,
+		CryptoContext: &pb.CryptoContext{
+			EllipticCurve: pb.EllipticCurve(easyecc.P256),
+			EcdhVersion:   2,
+			EcdsaVersion:  1,
+		},
+	}
+	subscribeCtx, cancel := context.WithCancel(ctx)
+	stream := &testSubscribeServer{ctx: subscribeCtx, responses: make(chan *pb.ReceiveResponse, 10)}
+	done := make(chan error)
+	go func() {
+		done <- dumpServer.Subscribe(req, stream)
+	}()
+
+	res := <-stream.responses
+	content, err := protoutil.DecryptMessage(ctx, bchain, bobKey, res.GetMessage())
+	assert.NoError(err)
+	assert.Equal("first", content)
+
+	// This message is pushed to the open stream.
+	msg, err = protoutil.CreateMessage(aliceKey, []byte("second"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.NoError(err)
+
+	select {
+	case res = <-stream.responses:
+	case <-time.After(5 * time.Second):
+		assert.Fail("timed out waiting for message")
+	}
+	content, err = protoutil.DecryptMessage(ctx, bchain, bobKey, res.GetMessage())
+	assert.NoError(err)
+	assert.Equal("second", content)
+
+	cancel()
+	assert.NoError(<-done)
+
+	bchain.AssertExpectations(t)
+}
+
+func Test_DumpServer_SubscribeShutdown(t *testing.T) {
+	assert := assert.New(t)
+
+	dumpServer := NewDumpServer(store.NewMemory(), nil)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	identityProof, err := protoutil.IdentityProof(bobKey, time.Now())
+	assert.NoError(err)
+	req := &pb.ReceiveRequest{
+		IdentityProof: identityProof,
+		CryptoContext: &pb.CryptoContext{
+			EllipticCurve: pb.EllipticCurve(easyecc.P256),
+			EcdhVersion:   2,
+			EcdsaVersion:  1,
+		},
+	}
+	stream := &testSubscribeServer{ctx: context.Background(), responses: make(chan *pb.ReceiveResponse, 10)}
+	done := make(chan error)
+	go func() {
+		done <- dumpServer.Subscribe(req, stream)
+	}()
+
+	dumpServer.Shutdown()
+	select {
+	case err = <-done:
+		assert.True(util.ErrEqualCode(err, codes.Unavailable))
+	case <-time.After(5 * time.Second):
+		assert.Fail("subscription is still open")
+	}
+}
+
+func Test_DumpServer_MailboxPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	dumpStore := store.NewMemory()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
+	ctx := context.Background()
+	dumpServer := NewDumpServer(dumpStore, bchain)
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	carolKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	daveKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+
+	bchain.EXPECT().PublicKeyByCurve(ctx, "alice", easyecc.P256).Return(aliceKey.PublicKey(), nil)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "bob", easyecc.P256).Return(bobKey.PublicKey(), nil)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "carol", easyecc.P256).Return(carolKey.PublicKey(), nil)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "dave", easyecc.P256).Return(daveKey.PublicKey(), nil)
+
+	cryptoContext := &pb.CryptoContext{
+		EllipticCurve: pb.EllipticCurve(easyecc.P256),
+		EcdhVersion:   2,
+		EcdsaVersion:  1,
+	}
+	setPolicy := func(key *easyecc.PrivateKey, policy *pb.Signed) error {
+		identityProof, err := protoutil.CreateIdentityProof(key, nil, "",
+			protoutil.IdentityOperationSetPolicy, time.Now())
+		assert.NoError(err)
+		_, err = dumpServer.SetMailboxPolicy(ctx, &pb.SetMailboxPolicyRequest{
+			IdentityProof: identityProof,
+			CryptoContext: cryptoContext,
+			Policy:        policy,
+		})
+		return err
+	}
+	send := func(sender string, senderKey *easyecc.PrivateKey, pow bool) error {
+		msg, err := protoutil.CreateMessage(senderKey, []byte("hi bob"), sender, "bob", bobKey.PublicKey())
+		assert.NoError(err)
+		req := &pb.SendRequest{Message: msg}
+		if pow {
+			req.Pow, err = protoutil.ComputeMessagePow(ctx, msg, 8)
+			assert.NoError(err)
+		}
+		_, err = dumpServer.Send(ctx, req)
+		return err
+	}
+
+	// Alice is allowed, Carol is blocked, strangers must provide proof of work.
+	now := time.Now()
+	policy, err := protoutil.CreateMailboxPolicy(bobKey, &pb.MailboxPolicy{
+		Allow:       []string{"alice"},
+		Block:       []string{"carol"},
+		Strangers:   pb.StrangerPolicy_SP_REQUIRE_POW,
+		PowStrength: 8,
+	}, now)
+	assert.NoError(err)
+	assert.NoError(setPolicy(bobKey, policy))
+
+	assert.NoError(send("alice", aliceKey, false))
+	err = send("carol", carolKey, true)
+	assert.True(util.ErrEqualCode(err, codes.PermissionDenied))
+	err = send("dave", daveKey, false)
+	strength, ok := protoutil.PowRequirementFromError(err)
+	assert.True(ok)
+	assert.Equal(8, strength)
+	assert.NoError(send("dave", daveKey, true))
+
+	stats, err := dumpStore.Stats(ctx, bobKey.PublicKey().CompressedBytes())
+	assert.NoError(err)
+	assert.Equal(2, stats.Count)
+
+	// Only Bob can change his policy.
+	err = setPolicy(aliceKey, policy)
+	assert.True(util.ErrEqualCode(err, codes.PermissionDenied))
+
+	// The old policy can't replace the newer one.
+	rejectStrangers, err := protoutil.CreateMailboxPolicy(bobKey, &pb.MailboxPolicy{
+		Allow:     []string{"alice"},
+		Strangers: pb.StrangerPolicy_SP_REJECT,
+	}, now.Add(time.Second))
+	assert.NoError(err)
+	assert.NoError(setPolicy(bobKey, rejectStrangers))
+	err = setPolicy(bobKey, policy)
+	assert.True(util.ErrEqualCode(err, codes.FailedPrecondition))
+
+	assert.NoError(send("alice", aliceKey, false))
+	err = send("dave", daveKey, true)
+	assert.True(util.ErrEqualCode(err, codes.PermissionDenied))
+
+	bchain.AssertExpectations(t)
+}
+
+func Test_DumpServer_Events(t *testing.T) {
+	assert := assert.New(t)
+
+	dumpStore := store.NewMemory()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
+	ctx := context.Background()
+	var buf bytes.Buffer
+	dumpServer := NewDumpServerWithOptions(dumpStore, bchain, DumpServerOptions{
+		Events: events.NewJSONSink(&buf),
+	})
+	var namesBuf bytes.Buffer
+	dumpServerWithNames := NewDumpServerWithOptions(store.NewMemory(), bchain, DumpServerOptions{
+		Events:     events.NewJSONSink(&namesBuf),
+		EventNames: true,
+	})
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+
+	bchain.EXPECT().PublicKeyByCurve(ctx, "alice",
+		easyecc.P256).Return(aliceKey.PublicKey(), nil)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "bob",
+		easyecc.P256).Return(bobKey.PublicKey(), nil)
+
+	msg, err := protoutil.CreateMessage(aliceKey, []byte("hi bob"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.NoError(err)
+
+	event := &pb.Event{}
+	assert.NoError(protojson.Unmarshal(bytes.TrimSpace(buf.Bytes()), event))
+	assert.Equal(pb.EventType_ET_DUMP_MESSAGE_STORED, event.GetEventType())
+	assert.Equal("dump", event.GetComponent())
+	assert.Empty(event.GetUser1())
+	assert.Empty(event.GetUser2())
+	assert.NotEmpty(event.GetId())
+
+	// The names are only logged if enabled.
+	_, err = dumpServerWithNames.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.NoError(err)
+	event = &pb.Event{}
+	assert.NoError(protojson.Unmarshal(bytes.TrimSpace(namesBuf.Bytes()), event))
+	assert.Equal("alice", event.GetUser1())
+	assert.Equal("bob", event.GetUser2())
+}
+
+func Test_DumpServer_SendEnvelope(t *testing.T) {
+	assert := assert.New(t)
+
+	dumpStore := store.NewMemory()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
+	ctx := context.Background()
+	dumpServer := NewDumpServer(dumpStore, bchain)
+
+	keys := make(map[string]*easyecc.PrivateKey)
+	for _, name := range []string{"alice", "bob", "charlie", "dave"} {
+		key, err := easyecc.NewPrivateKey(easyecc.P256)
+		assert.NoError(err)
+		keys[name] = key
+		bchain.EXPECT().PublicKeyByCurve(ctx, name, easyecc.P256).Return(key.PublicKey(), nil)
+	}
+
+	msg, err := protoutil.CreateEnvelope(keys["alice"], []byte("hi all"), "alice", []protoutil.Recipient{
+		{Name: "bob", Key: keys["bob"].PublicKey()},
+		{Name: "charlie", Key: keys["charlie"].PublicKey()},
+	})
+	assert.NoError(err)
+
+	// Dave is not in the envelope.
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg, Receiver: []string{"bob", "dave"}})
+	assert.True(util.ErrEqualCode(err, codes.InvalidArgument))
+
+	// Regular messages can't be sent to many.
+	regular, err := protoutil.CreateMessage(keys["alice"], []byte("hi"), "alice", "bob", keys["bob"].PublicKey())
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: regular, Receiver: []string{"bob", "charlie"}})
+	assert.True(util.ErrEqualCode(err, codes.InvalidArgument))
+
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg, Receiver: []string{"bob", "charlie"}})
+	assert.NoError(err)
+
+	for _, name := range []string{"bob", "charlie"} {
+		identityProof, err := protoutil.IdentityProof(keys[name], time.Now())
+		assert.NoError(err)
+		res, err := dumpServer.Receive(ctx, &pb.ReceiveRequest{
+			IdentityProof: identityProof,
+			CryptoContext: &pb.CryptoContext{
+				EllipticCurve: pb.EllipticCurve(easyecc.P256),
+				EcdhVersion:   2,
+				EcdsaVersion:  1,
+			},
+		})
+		assert.NoError(err)
+		assert.Equal(name, res.GetMessage().GetReceiver())
+		content, err := protoutil.DecryptMessage(ctx, bchain, keys[name], res.GetMessage())
+		assert.NoError(err)
+		assert.Equal("hi all", content)
+	}
+	stats, err := dumpStore.Stats(ctx, keys["dave"].PublicKey().CompressedBytes())
+	assert.NoError(err)
+	assert.Equal(0, stats.Count)
+}
+
+func Test_DumpServer_SendDisabledKey(t *testing.T) {
+	assert := assert.New(t)
+
+	dumpStore := store.NewMemory()
+	bchain := new(bcmocks.MockBlockchain)
+	ctx := context.Background()
+	dumpServer := NewDumpServer(dumpStore, bchain)
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	parentKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+
+	bchain.EXPECT().PublicKeyByCurve(ctx, "alice",
+		easyecc.P256).Return(aliceKey.PublicKey(), nil)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "bob",
+		easyecc.P256).Return(bobKey.PublicKey(), nil)
+	// Alice's key is fine, but its parent was revoked.
+	bchain.EXPECT().KeyStatus(ctx, aliceKey.PublicKey().CompressedBytes()).Return(
+		&pb.LookupKeyResponse{ParentKey: [][]byte{parentKey.PublicKey().CompressedBytes()}}, nil)
+	bchain.EXPECT().KeyStatus(ctx, parentKey.PublicKey().CompressedBytes()).Return(
+		&pb.LookupKeyResponse{Disabled: true}, nil)
+
+	msg, err := protoutil.CreateMessage(aliceKey, []byte("hi bob"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.True(util.ErrEqualCode(err, codes.PermissionDenied))
+
+	stats, err := dumpStore.Stats(ctx, bobKey.PublicKey().CompressedBytes())
+	assert.NoError(err)
+	assert.Equal(0, stats.Count)
+	bchain.AssertExpectations(t)
+}
+
+func Test_DumpServer_SendDuplicate(t *testing.T) {
+	assert := assert.New(t)
+
+	dumpStore := store.NewMemory()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
+	ctx := context.Background()
+	var buf bytes.Buffer
+	dumpServer := NewDumpServerWithOptions(dumpStore, bchain, DumpServerOptions{
+		Events: events.NewJSONSink(&buf),
+	})
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+
+	bchain.EXPECT().PublicKeyByCurve(ctx, "alice",
+		easyecc.P256).Return(aliceKey.PublicKey(), nil)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "bob",
+		easyecc.P256).Return(bobKey.PublicKey(), nil)
+
+	msg, err := protoutil.CreateMessage(aliceKey, []byte("hi bob"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.NoError(err)
+
+	// Bob gets the message before the retry arrives.
+	identityProof, err := protoutil.IdentityProof(bobKey, time.Now())
+	assert.NoError(err)
+	receiveReq := &pb.ReceiveRequest{
+		IdentityProof: identityProof,
+		CryptoContext: &pb.CryptoContext{
+			EllipticCurve: pb.EllipticCurve(easyecc.P256),
+			EcdhVersion:   2,
+			EcdsaVersion:  1,
+		},
+	}
+	res, err := dumpServer.Receive(ctx, receiveReq)
+	assert.NoError(err)
+	assert.True(proto.Equal(msg, res.GetMessage()))
+
+	// The retry succeeds, but the message is not stored again.
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.NoError(err)
+	stats, err := dumpStore.Stats(ctx, bobKey.PublicKey().CompressedBytes())
+	assert.NoError(err)
+	assert.Equal(0, stats.Count)
+	assert.Equal(1, bytes.Count(buf.Bytes(), []byte("\n")))
+
+	// The replay with the unsigned fields changed is still a duplicate.
+	replay := proto.Clone(msg).(*pb.DMSMessage)
+	replay.CryptoContext.EcdsaVersion = 0
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: replay})
+	assert.NoError(err)
+	stats, err = dumpStore.Stats(ctx, bobKey.PublicKey().CompressedBytes())
+	assert.NoError(err)
+	assert.Equal(0, stats.Count)
+
+	// Different content is a different message.
+	other, err := protoutil.CreateMessage(aliceKey, []byte("hi again"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: other})
+	assert.NoError(err)
+	stats, err = dumpStore.Stats(ctx, bobKey.PublicKey().CompressedBytes())
+	assert.NoError(err)
+	assert.Equal(1, stats.Count)
+}
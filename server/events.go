@@ -0,0 +1,35 @@
+package server
+
+import (
+	"github.com/regnull/ubikom/events"
+	"github.com/regnull/ubikom/pb"
+	"github.com/rs/zerolog/log"
+)
+
+// eventComponent identifies the events written by the dump server.
+const eventComponent = "dump"
+
+// emitEvent writes the event to the sink, if there is one. Failing to write the event
+// doesn't fail the request.
+func emitEvent(sink events.Sink, eventType pb.EventType, user1, user2, message string) {
+	if sink == nil {
+		return
+	}
+	event := events.New(eventType, eventComponent)
+	event.User1 = user1
+	event.User2 = user2
+	event.Message = message
+	err := sink.Write(event)
+	if err != nil {
+		log.Warn().Err(err).Str("type", eventType.String()).Msg("failed to write event")
+	}
+}
+
+// eventNames returns the sender and the receiver names for the message event, or
+// empty strings if the names must not be logged.
+func eventNames(enabled bool, msg *pb.DMSMessage) (string, string) {
+	if !enabled {
+		return "", ""
+	}
+	return msg.GetSender(), msg.GetReceiver()
+}
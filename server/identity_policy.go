@@ -0,0 +1,82 @@
+package server
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// IdentityPolicy defines which identity proofs are accepted by the dump server.
+type IdentityPolicy int
+
+const (
+	// IdentityPolicyLegacy accepts the legacy identity proofs, and falls back to
+	// signature-only verification if the legacy proof verification fails.
+	IdentityPolicyLegacy IdentityPolicy = iota
+
+	// IdentityPolicyLegacyWithMetrics is the same as IdentityPolicyLegacy, but
+	// also counts the legacy proofs and fallbacks, to track the migration progress.
+	IdentityPolicyLegacyWithMetrics
+
+	// IdentityPolicyStrict accepts only replay-protected identity proofs.
+	IdentityPolicyStrict
+)
+
+// ParseIdentityPolicy converts the policy name into IdentityPolicy.
+func ParseIdentityPolicy(s string) (IdentityPolicy, error) {
+	switch s {
+	case "legacy":
+		return IdentityPolicyLegacy, nil
+	case "legacy-metrics":
+		return IdentityPolicyLegacyWithMetrics, nil
+	case "strict":
+		return IdentityPolicyStrict, nil
+	}
+	return IdentityPolicyLegacy, fmt.Errorf("invalid identity policy: %s", s)
+}
+
+func (p IdentityPolicy) String() string {
+	switch p {
+	case IdentityPolicyLegacy:
+		return "legacy"
+	case IdentityPolicyLegacyWithMetrics:
+		return "legacy-metrics"
+	case IdentityPolicyStrict:
+		return "strict"
+	}
+	return fmt.Sprintf("IdentityPolicy(%d)", int(p))
+}
+
+// IdentityStats contains the legacy identity proof counters, which are collected
+// with IdentityPolicyLegacyWithMetrics.
+type IdentityStats struct {
+	// LegacyProofs is the number of accepted legacy identity proofs.
+	LegacyProofs int64
+
+	// Fallbacks is the number of identity proofs accepted by signature-only verification.
+	Fallbacks int64
+}
+
+type identityCounters struct {
+	legacyProofs atomic.Int64
+	fallbacks    atomic.Int64
+}
+
+// Audit events.
+const (
+	auditLegacyIdentityProof    = "legacy_identity_proof"
+	auditLegacyIdentityFallback = "legacy_identity_fallback"
+	auditIdentityRejected       = "identity_rejected"
+	auditMailboxPolicyChanged   = "mailbox_policy_changed"
+	auditAdminRequest           = "admin_request"
+	auditAdminRejected          = "admin_rejected"
+	auditMailboxPurged          = "mailbox_purged"
+	auditMessageDeleted         = "message_deleted"
+)
+
+// auditEvent starts a structured audit log event.
+func auditEvent(event string) *zerolog.Event {
+	return log.Info().Str("audit", event)
+}
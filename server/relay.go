@@ -0,0 +1,275 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/regnull/ubikom/bc"
+	"github.com/regnull/ubikom/events"
+	"github.com/regnull/ubikom/pb"
+	"github.com/regnull/ubikom/protoutil"
+	"github.com/regnull/ubikom/store"
+	"github.com/regnull/ubikom/util"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultRelayWorkers        = 4
+	defaultRelayMaxAttempts    = 20
+	defaultRelayInitialBackoff = 10 * time.Second
+	defaultRelayMaxBackoff     = time.Hour
+	defaultRelayPollInterval   = time.Minute
+	defaultRelayMaxMessages    = 10000
+	defaultRelayMaxBytes       = 256 * 1024 * 1024
+	relayLeaseTimeout          = 5 * time.Minute
+	// The relay forwards the messages straight to the receivers' dump servers, so
+	// one hop is always enough. More hops mean the servers relay to each other.
+	maxRelayHops = 1
+)
+
+// relayQueueKey is the store key under which the messages waiting to be relayed are kept.
+// The relay has its own store, so the queue doesn't show up among the mailboxes.
+var relayQueueKey = []byte("relay")
+
+var ErrRelayQueueFull = errors.New("relay queue is full")
+
+// RelayOptions control the relay behavior. Zero values result in the default behavior.
+type RelayOptions struct {
+	// Workers is the number of messages delivered concurrently.
+	Workers int
+
+	// MaxAttempts is the number of delivery attempts, after which the message is dropped.
+	MaxAttempts int
+
+	// InitialBackoff is the delay after the first failed delivery attempt. The delay is
+	// doubled after each consecutive failure, up to MaxBackoff.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the maximum delay between delivery attempts.
+	MaxBackoff time.Duration
+
+	// MaxMessages is the maximum number of messages waiting to be relayed.
+	MaxMessages int
+
+	// MaxBytes is the maximum total size of messages waiting to be relayed.
+	MaxBytes int64
+
+	// PollInterval is how often the queue is checked for the messages which are due
+	// to be retried.
+	PollInterval time.Duration
+
+	// Events, if not nil, receives an event for every relayed message.
+	Events events.Sink
+
+	// EventNames makes the events include the sender and the receiver names.
+	EventNames bool
+}
+
+// Relay forwards messages to the dump servers of their receivers. The messages are
+// queued in the store, so they survive the server restart. The store must not be
+// the one used for the mailboxes.
+type Relay struct {
+	store     store.Store
+	bchain    bc.Blockchain
+	dsFactory protoutil.DumpServiceClientFactory
+	opts      RelayOptions
+	wake      chan struct{}
+
+	mu       sync.Mutex
+	attempts map[string]int
+}
+
+// NewRelay creates a new Relay.
+func NewRelay(str store.Store, bchain bc.Blockchain, dsFactory protoutil.DumpServiceClientFactory,
+	opts RelayOptions) *Relay {
+	if opts.Workers == 0 {
+		opts.Workers = defaultRelayWorkers
+	}
+	if opts.MaxAttempts == 0 {
+		opts.MaxAttempts = defaultRelayMaxAttempts
+	}
+	if opts.InitialBackoff == 0 {
+		opts.InitialBackoff = defaultRelayInitialBackoff
+	}
+	if opts.MaxBackoff == 0 {
+		opts.MaxBackoff = defaultRelayMaxBackoff
+	}
+	if opts.MaxMessages == 0 {
+		opts.MaxMessages = defaultRelayMaxMessages
+	}
+	if opts.MaxBytes == 0 {
+		opts.MaxBytes = defaultRelayMaxBytes
+	}
+	if opts.PollInterval == 0 {
+		opts.PollInterval = defaultRelayPollInterval
+	}
+	return &Relay{
+		store:     str,
+		bchain:    bchain,
+		dsFactory: dsFactory,
+		opts:      opts,
+		wake:      make(chan struct{}, 1),
+		attempts:  make(map[string]int),
+	}
+}
+
+// Enqueue adds the message to the relay queue. It returns ErrRelayQueueFull if
+// the queue has no room for it.
+func (r *Relay) Enqueue(ctx context.Context, msg *pb.DMSMessage) error {
+	err := r.checkRoom(ctx, proto.Size(msg))
+	if err != nil {
+		return err
+	}
+	err = r.store.Save(ctx, msg, relayQueueKey)
+	if err != nil {
+		return err
+	}
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// checkRoom returns ErrRelayQueueFull if the queue has no room for another message
+// of the given size.
+func (r *Relay) checkRoom(ctx context.Context, msgSize int) error {
+	stats, err := r.store.Stats(ctx, relayQueueKey)
+	if err != nil {
+		return err
+	}
+	if stats.Count >= r.opts.MaxMessages || stats.Size+int64(msgSize) > r.opts.MaxBytes {
+		return ErrRelayQueueFull
+	}
+	return nil
+}
+
+// Run delivers the queued messages until the context is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < r.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (r *Relay) work(ctx context.Context) {
+	for {
+		delivered, err := r.deliverNext(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("relay queue error")
+		}
+		if delivered {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.wake:
+		case <-time.After(r.opts.PollInterval):
+		}
+	}
+}
+
+// deliverNext tries to deliver the next message from the queue. It returns false if
+// there was nothing to deliver.
+func (r *Relay) deliverNext(ctx context.Context) (bool, error) {
+	msg, deliveryID, err := r.store.Lease(ctx, relayQueueKey, relayLeaseTimeout)
+	if err != nil {
+		return false, err
+	}
+	if msg == nil {
+		return false, nil
+	}
+
+	err = r.forward(ctx, msg)
+	if err == nil {
+		log.Debug().Str("receiver", msg.GetReceiver()).Msg("message relayed")
+		r.resetAttempts(deliveryID)
+		return true, r.store.Ack(ctx, relayQueueKey, deliveryID)
+	}
+
+	attempts := r.incAttempts(deliveryID)
+	if attempts >= r.opts.MaxAttempts || util.ErrEqualCode(err, codes.InvalidArgument) {
+		log.Warn().Err(err).Str("receiver", msg.GetReceiver()).Int("attempts", attempts).
+			Msg("failed to relay message, dropping it")
+		r.resetAttempts(deliveryID)
+		return true, r.store.Ack(ctx, relayQueueKey, deliveryID)
+	}
+	delay := r.backoff(attempts)
+	log.Debug().Err(err).Str("receiver", msg.GetReceiver()).Int("attempts", attempts).
+		Dur("delay", delay).Msg("failed to relay message, will retry")
+	return true, r.store.Requeue(ctx, relayQueueKey, deliveryID, delay)
+}
+
+func (r *Relay) forward(ctx context.Context, msg *pb.DMSMessage) error {
+	endpoint, err := r.bchain.Endpoint(ctx, msg.GetReceiver())
+	if err != nil {
+		return err
+	}
+	client, cleanup, err := r.dsFactory.CreateDumpServiceClient(ctx, endpoint, 0)
+	if err != nil {
+		return err
+	}
+	if cleanup != nil {
+		defer cleanup()
+	}
+	err = protoutil.RelayToDumpServer(ctx, client, msg)
+	if err != nil {
+		return err
+	}
+	sender, receiver := eventNames(r.opts.EventNames, msg)
+	emitEvent(r.opts.Events, pb.EventType_ET_DUMP_MESSAGE_RELAYED, sender, receiver, endpoint)
+	return nil
+}
+
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.opts.InitialBackoff
+	for i := 1; i < attempts && delay < r.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.opts.MaxBackoff {
+		delay = r.opts.MaxBackoff
+	}
+	return delay
+}
+
+func (r *Relay) incAttempts(deliveryID string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts[deliveryID]++
+	return r.attempts[deliveryID]
+}
+
+func (r *Relay) resetAttempts(deliveryID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.attempts, deliveryID)
+}
+
+// relayQueueError converts the error returned by the relay queue to the gRPC status.
+func relayQueueError(err error) error {
+	if errors.Is(err, ErrRelayQueueFull) {
+		log.Warn().Msg("relay queue is full")
+		return status.Error(codes.ResourceExhausted, "relay queue is full")
+	}
+	log.Error().Err(err).Msg("failed to enqueue message for relay")
+	return status.Error(codes.Internal, "message store error")
+}
+
+// normalizeEndpoint removes the scheme, so that the endpoints can be compared.
+func normalizeEndpoint(endpoint string) string {
+	endpoint = strings.TrimPrefix(endpoint, protoutil.EndpointSchemeTLS)
+	endpoint = strings.TrimPrefix(endpoint, protoutil.EndpointSchemePlaintext)
+	return strings.ToLower(endpoint)
+}
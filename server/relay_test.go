@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/regnull/easyecc/v2"
+	bcmocks "github.com/regnull/ubikom/bc/mocks"
+	"github.com/regnull/ubikom/pb"
+	pbmocks "github.com/regnull/ubikom/pb/mocks"
+	"github.com/regnull/ubikom/protoutil"
+	pumocks "github.com/regnull/ubikom/protoutil/mocks"
+	"github.com/regnull/ubikom/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_DumpServer_Relay(t *testing.T) {
+	assert := assert.New(t)
+
+	dumpStore, err := store.NewBadger(t.TempDir(), time.Hour)
+	assert.NoError(err)
+	relayStore := store.NewMemory()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
+	dscFactory := new(pumocks.MockDumpServiceClientFactory)
+	dscClient := new(pbmocks.MockDMSDumpServiceClient)
+	ctx := context.Background()
+	relay := NewRelay(relayStore, bchain, dscFactory, RelayOptions{
+		InitialBackoff: 50 * time.Millisecond,
+	})
+	dumpServer := NewDumpServerWithOptions(dumpStore, bchain, DumpServerOptions{
+		Relay:          relay,
+		LocalEndpoints: []string{"tls://local:8826"},
+	})
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	carolKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+
+	bchain.EXPECT().PublicKeyByCurve(ctx, "alice", easyecc.P256).Return(aliceKey.PublicKey(), nil)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "bob", easyecc.P256).Return(bobKey.PublicKey(), nil)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "carol", easyecc.P256).Return(carolKey.PublicKey(), nil)
+	bchain.EXPECT().Endpoint(ctx, "bob").Return("remote:8826", nil)
+	bchain.EXPECT().Endpoint(ctx, "carol").Return("local:8826", nil)
+
+	// Bob is hosted elsewhere, his message goes to the relay queue.
+	msg, err := protoutil.CreateMessage(aliceKey, []byte("hi bob"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.NoError(err)
+	stats, err := dumpStore.Stats(ctx, bobKey.PublicKey().CompressedBytes())
+	assert.NoError(err)
+	assert.Equal(0, stats.Count)
+	stats, err = relayStore.Stats(ctx, relayQueueKey)
+	assert.NoError(err)
+	assert.Equal(1, stats.Count)
+	mailboxes, err := dumpStore.Mailboxes(ctx)
+	assert.NoError(err)
+	assert.Empty(mailboxes)
+
+	// Carol is local.
+	carolMsg, err := protoutil.CreateMessage(aliceKey, []byte("hi carol"), "alice", "carol", carolKey.PublicKey())
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: carolMsg})
+	assert.NoError(err)
+	stats, err = dumpStore.Stats(ctx, carolKey.PublicKey().CompressedBytes())
+	assert.NoError(err)
+	assert.Equal(1, stats.Count)
+
+	// The first attempt fails, the message is retried after the backoff.
+	dscFactory.EXPECT().CreateDumpServiceClient(ctx, "remote:8826", time.Duration(0)).Return(dscClient, nil, nil)
+	dscClient.EXPECT().Send(ctx, mock.Anything).Return(nil, status.Error(codes.Unavailable, "unavailable")).Once()
+	// The relayed message is marked, so that it's not relayed again.
+	dscClient.EXPECT().Send(ctx, mock.MatchedBy(func(req *pb.SendRequest) bool {
+		return req.GetRelayHops() == 1
+	})).Return(&pb.SendResponse{}, nil).Once()
+
+	delivered, err := relay.deliverNext(ctx)
+	assert.NoError(err)
+	assert.True(delivered)
+	delivered, err = relay.deliverNext(ctx)
+	assert.NoError(err)
+	assert.False(delivered)
+
+	time.Sleep(100 * time.Millisecond)
+	delivered, err = relay.deliverNext(ctx)
+	assert.NoError(err)
+	assert.True(delivered)
+	stats, err = relayStore.Stats(ctx, relayQueueKey)
+	assert.NoError(err)
+	assert.Equal(0, stats.Count)
+
+	bchain.AssertExpectations(t)
+	dscFactory.AssertExpectations(t)
+	dscClient.AssertExpectations(t)
+}
+
+func Test_Relay_DropsRejectedMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	relayStore := store.NewMemory()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
+	dscFactory := new(pumocks.MockDumpServiceClientFactory)
+	dscClient := new(pbmocks.MockDMSDumpServiceClient)
+	ctx := context.Background()
+	relay := NewRelay(relayStore, bchain, dscFactory, RelayOptions{})
+
+	assert.NoError(relay.Enqueue(context.Background(), &pb.DMSMessage{Sender: "alice", Receiver: "bob"}))
+
+	bchain.EXPECT().Endpoint(ctx, "bob").Return("remote:8826", nil)
+	dscFactory.EXPECT().CreateDumpServiceClient(ctx, "remote:8826", time.Duration(0)).Return(dscClient, nil, nil)
+	dscClient.EXPECT().Send(ctx, mock.Anything).Return(nil, status.Error(codes.InvalidArgument, "bad signature")).Once()
+
+	delivered, err := relay.deliverNext(ctx)
+	assert.NoError(err)
+	assert.True(delivered)
+	stats, err := relayStore.Stats(ctx, relayQueueKey)
+	assert.NoError(err)
+	assert.Equal(0, stats.Count)
+
+	bchain.AssertExpectations(t)
+	dscFactory.AssertExpectations(t)
+	dscClient.AssertExpectations(t)
+}
+
+func Test_DumpServer_RelayQueueFull(t *testing.T) {
+	assert := assert.New(t)
+
+	dumpStore := store.NewMemory()
+	relayStore := store.NewMemory()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
+	ctx := context.Background()
+	relay := NewRelay(relayStore, bchain, nil, RelayOptions{MaxMessages: 1})
+	dumpServer := NewDumpServerWithOptions(dumpStore, bchain, DumpServerOptions{
+		Relay:          relay,
+		LocalEndpoints: []string{"local:8826"},
+	})
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "alice", easyecc.P256).Return(aliceKey.PublicKey(), nil)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "bob", easyecc.P256).Return(bobKey.PublicKey(), nil)
+	bchain.EXPECT().Endpoint(ctx, "bob").Return("remote:8826", nil)
+
+	msg, err := protoutil.CreateMessage(aliceKey, []byte("hi bob"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.NoError(err)
+	msg, err = protoutil.CreateMessage(aliceKey, []byte("hi again"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg})
+	assert.Equal(codes.ResourceExhausted, status.Code(err))
+	assert.ErrorIs(relay.Enqueue(ctx, msg), ErrRelayQueueFull)
+
+	stats, err := relayStore.Stats(ctx, relayQueueKey)
+	assert.NoError(err)
+	assert.Equal(1, stats.Count)
+}
+
+func Test_DumpServer_RelayLoop(t *testing.T) {
+	assert := assert.New(t)
+
+	dumpStore := store.NewMemory()
+	relayStore := store.NewMemory()
+	bchain := new(bcmocks.MockBlockchain)
+	bchain.EXPECT().KeyStatus(mock.Anything, mock.Anything).Return(&pb.LookupKeyResponse{}, nil).Maybe()
+	ctx := context.Background()
+	relay := NewRelay(relayStore, bchain, nil, RelayOptions{})
+	dumpServer := NewDumpServerWithOptions(dumpStore, bchain, DumpServerOptions{
+		Relay:          relay,
+		LocalEndpoints: []string{"local:8826"},
+	})
+
+	aliceKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bobKey, err := easyecc.NewPrivateKey(easyecc.P256)
+	assert.NoError(err)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "alice", easyecc.P256).Return(aliceKey.PublicKey(), nil)
+	bchain.EXPECT().PublicKeyByCurve(ctx, "bob", easyecc.P256).Return(bobKey.PublicKey(), nil)
+	bchain.EXPECT().Endpoint(ctx, "bob").Return("remote:8826", nil)
+
+	// Another relay sent us the message for a receiver who is not hosted here.
+	msg, err := protoutil.CreateMessage(aliceKey, []byte("hi bob"), "alice", "bob", bobKey.PublicKey())
+	assert.NoError(err)
+	_, err = dumpServer.Send(ctx, &pb.SendRequest{Message: msg, RelayHops: 1})
+	assert.Equal(codes.InvalidArgument, status.Code(err))
+
+	stats, err := relayStore.Stats(ctx, relayQueueKey)
+	assert.NoError(err)
+	assert.Equal(0, stats.Count)
+}
+
+func Test_Relay_Backoff(t *testing.T) {
+	assert := assert.New(t)
+
+	relay := NewRelay(nil, nil, nil, RelayOptions{
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+	})
+	assert.Equal(time.Second, relay.backoff(1))
+	assert.Equal(2*time.Second, relay.backoff(2))
+	assert.Equal(4*time.Second, relay.backoff(3))
+	assert.Equal(5*time.Second, relay.backoff(4))
+	assert.Equal(5*time.Second, relay.backoff(100))
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+)
+
+// subscriptions keeps track of open Subscribe streams, and wakes them up
+// when a new message is saved for the corresponding receiver.
+type subscriptions struct {
+	mu   sync.Mutex
+	subs map[string]map[chan struct{}]struct{}
+}
+
+func newSubscriptions() *subscriptions {
+	return &subscriptions{
+		subs: make(map[string]map[chan struct{}]struct{}),
+	}
+}
+
+// subscribe registers a new subscriber for the given receiver key. It returns
+// the channel which is signaled when new messages arrive, and the function that
+// must be called to unsubscribe.
+func (s *subscriptions) subscribe(receiverKey []byte) (<-chan struct{}, func()) {
+	keyStr := fmt.Sprintf("%x", receiverKey)
+	// The channel is buffered, so that notifications are not lost while the subscriber
+	// is busy sending messages.
+	ch := make(chan struct{}, 1)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subs[keyStr] == nil {
+		s.subs[keyStr] = make(map[chan struct{}]struct{})
+	}
+	s.subs[keyStr][ch] = struct{}{}
+
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subs[keyStr], ch)
+		if len(s.subs[keyStr]) == 0 {
+			delete(s.subs, keyStr)
+		}
+	}
+}
+
+// notify wakes up all the subscribers for the given receiver key.
+func (s *subscriptions) notify(receiverKey []byte) {
+	keyStr := fmt.Sprintf("%x", receiverKey)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subs[keyStr] {
+		select {
+		case ch <- struct{}{}:
+		default:
+			// The subscriber already has a pending notification.
+		}
+	}
+}
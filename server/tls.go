@@ -0,0 +1,31 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/regnull/ubikom/protoutil"
+	"google.golang.org/grpc/credentials"
+)
+
+// NewTLSCredentials creates the server transport credentials from the certificate and key
+// files. If clientCAFile is not empty, the clients must present a certificate signed
+// by one of the CAs from this file.
+func NewTLSCredentials(certFile, keyFile, clientCAFile string) (credentials.TransportCredentials, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAFile != "" {
+		config.ClientCAs, err = protoutil.LoadCertPool(clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return credentials.NewTLS(config), nil
+}
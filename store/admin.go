@@ -0,0 +1,37 @@
+package store
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrNotSupported = errors.New("not supported by this store")
+
+// MailboxInfo contains the accounting information for one receiver.
+type MailboxInfo struct {
+	ReceiverKey []byte
+	MailboxStats
+}
+
+// AdminStore is implemented by the stores which support the operator tools.
+type AdminStore interface {
+	Store
+
+	// Mailboxes returns the stats for every receiver who has messages.
+	Mailboxes(ctx context.Context) ([]*MailboxInfo, error)
+
+	// Policies returns the keys of the receivers who have a mailbox policy.
+	Policies(ctx context.Context) ([][]byte, error)
+
+	// Purge removes all the receiver's messages, and returns the number of removed messages.
+	Purge(ctx context.Context, receiverKey []byte) (int, error)
+
+	// DiskSize returns the size of the data on disk, or zero if the store is not
+	// backed by disk.
+	DiskSize(ctx context.Context) (int64, error)
+
+	// CollectGarbage reclaims the disk space used by the removed messages, and returns
+	// the number of rewritten files. It returns ErrNotSupported if the store doesn't
+	// need garbage collection.
+	CollectGarbage(ctx context.Context, discardRatio float64) (int, error)
+}
@@ -1,8 +1,13 @@
 package store
 
 import (
+	"bytes"
+	"context"
 	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/dgraph-io/badger/v3"
@@ -10,54 +15,144 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+// badgerConflictRetries is how many times the transaction is retried if it conflicts
+// with a concurrent one.
+const badgerConflictRetries = 10
+
+// Badger stores the messages under msg_<receiver>_<sequence>_<hash>, so that they are
+// iterated in the arrival order. The index entry, idx_<receiver>_<hash>, points to
+// the message key, which lets us find the message by its hash. The metadata entry,
+// meta_<receiver>_<sequence>_<hash>, keeps the size and the sender, so that the
+// messages can be listed without reading them. The dedup entries,
+// dup_<receiver>_<dedup key>, expire at the end of the dedup window.
 type Badger struct {
-	db  *badger.DB
-	ttl time.Duration
+	db     *badger.DB
+	ttl    time.Duration
+	leases *leases
+	seq    sequence
 }
 
+// NewBadger opens the Badger store in the given directory. The messages expire after ttl,
+// or never if ttl is zero or negative.
 func NewBadger(dir string, ttl time.Duration) (*Badger, error) {
 	db, err := badger.Open(badger.DefaultOptions(dir))
 	if err != nil {
 		return nil, err
 	}
-	return &Badger{db: db, ttl: ttl}, nil
+	err = migrateBadger(db)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate message store: %w", err)
+	}
+	return &Badger{db: db, ttl: ttl, leases: newLeases()}, nil
+}
+
+func (b *Badger) Save(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte) error {
+	_, err := b.save(msg, receiverKey, "", 0)
+	return err
+}
+
+func (b *Badger) SaveOnce(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte, dedupKey string,
+	window time.Duration) (bool, error) {
+	return b.save(msg, receiverKey, dedupKey, window)
 }
 
-func (b *Badger) Save(msg *pb.DMSMessage, receiverKey []byte) error {
+// save saves the message. If dedupKey is not empty, the dedup entry is written along
+// with the message, and the message is not saved if the entry already exists.
+func (b *Badger) save(msg *pb.DMSMessage, receiverKey []byte, dedupKey string,
+	window time.Duration) (bool, error) {
 	bb, err := proto.Marshal(msg)
 	if err != nil {
-		return fmt.Errorf("failed to serialize message: %w", err)
+		return false, fmt.Errorf("failed to serialize message: %w", err)
 	}
 	msgID := fmt.Sprintf("%x", sha256.Sum256(bb))
 
-	dbKey := "msg_" + fmt.Sprintf("%x", receiverKey) + "_" + msgID
-	err = b.db.Update(func(txn *badger.Txn) error {
-		e := badger.NewEntry([]byte(dbKey), bb).WithTTL(b.ttl)
-		err := txn.SetEntry(e)
+	receiverKeyStr := fmt.Sprintf("%x", receiverKey)
+	ttl := b.ttl
+	if _, expires := deliveryWindow(msg); !expires.IsZero() {
+		untilExpires := time.Until(expires)
+		if untilExpires <= 0 {
+			// Already expired, nothing to save.
+			return true, nil
+		}
+		if ttl <= 0 || untilExpires < ttl {
+			ttl = untilExpires
+		}
+	}
+	indexKey := badgerIndexKey(receiverKeyStr, msgID)
+	var dedupEntryKey []byte
+	if dedupKey != "" {
+		dedupEntryKey = badgerDedupKey(receiverKeyStr, dedupKey)
+	}
+	var saved bool
+	save := func(txn *badger.Txn) error {
+		saved = true
+		if dedupEntryKey != nil {
+			_, err := txn.Get(dedupEntryKey)
+			if err == nil {
+				saved = false
+				return nil
+			}
+			if err != badger.ErrKeyNotFound {
+				return err
+			}
+			err = txn.SetEntry(badger.NewEntry(dedupEntryKey, nil).WithTTL(window))
+			if err != nil {
+				return err
+			}
+		}
+		_, err := txn.Get(indexKey)
+		if err == nil {
+			// Already saved.
+			return nil
+		}
+		if err != badger.ErrKeyNotFound {
+			return err
+		}
+		msgKey := badgerMessageKey(receiverKeyStr, b.seq.next(), msgID)
+		err = txn.SetEntry(badgerEntry(msgKey, bb, ttl))
 		if err != nil {
 			return err
 		}
-		return nil
-	})
-	return err
-
+		err = txn.SetEntry(badgerEntry(badgerMetaKey(msgKey), encodeBadgerMeta(len(bb), msg.GetSender()), ttl))
+		if err != nil {
+			return err
+		}
+		return txn.SetEntry(badgerEntry(indexKey, msgKey, ttl))
+	}
+	// The conflict means that the same message, or a message with the same dedup key,
+	// was saved concurrently. The retry sees it, and tells if this one is a duplicate.
+	for i := 0; i < badgerConflictRetries; i++ {
+		err = b.db.Update(save)
+		if err != badger.ErrConflict {
+			break
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	return saved, nil
 }
 
-func (b *Badger) GetNext(receiverKey []byte) (*pb.DMSMessage, error) {
-	prefix := []byte("msg_" + fmt.Sprintf("%x", receiverKey))
+func (b *Badger) GetNext(ctx context.Context, receiverKey []byte) (*pb.DMSMessage, error) {
+	prefix := badgerMessagePrefix(receiverKey)
 	var msg *pb.DMSMessage
+	now := time.Now()
 	err := b.db.View(func(txn *badger.Txn) error {
 		it := txn.NewIterator(badger.DefaultIteratorOptions)
 		defer it.Close()
 		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			m := &pb.DMSMessage{}
 			err := it.Item().Value(func(v []byte) error {
-				// TODO: How can this possibly work?
-				msg = &pb.DMSMessage{}
-				return proto.Unmarshal(v, msg)
+				return proto.Unmarshal(v, m)
 			})
 			if err != nil {
 				return err
 			}
+			if !isDue(m, now) {
+				continue
+			}
+			msg = m
 			return nil
 		}
 		return nil
@@ -68,9 +163,10 @@ func (b *Badger) GetNext(receiverKey []byte) (*pb.DMSMessage, error) {
 	return msg, nil
 }
 
-func (b *Badger) GetAll(receiverKey []byte) ([]*pb.DMSMessage, error) {
-	prefix := []byte("msg_" + fmt.Sprintf("%x", receiverKey))
+func (b *Badger) GetAll(ctx context.Context, receiverKey []byte) ([]*pb.DMSMessage, error) {
+	prefix := badgerMessagePrefix(receiverKey)
 	var msgs []*pb.DMSMessage
+	now := time.Now()
 	err := b.db.View(func(txn *badger.Txn) error {
 		it := txn.NewIterator(badger.DefaultIteratorOptions)
 		defer it.Close()
@@ -86,6 +182,9 @@ func (b *Badger) GetAll(receiverKey []byte) ([]*pb.DMSMessage, error) {
 			if err != nil {
 				return err
 			}
+			if !isDue(msg, now) {
+				continue
+			}
 			msgs = append(msgs, msg)
 		}
 		return nil
@@ -97,16 +196,398 @@ func (b *Badger) GetAll(receiverKey []byte) ([]*pb.DMSMessage, error) {
 
 }
 
-func (b *Badger) Remove(msg *pb.DMSMessage, receiverKey []byte) error {
+func (b *Badger) List(ctx context.Context, receiverKey []byte) ([]*MessageInfo, error) {
+	prefix := badgerMetaPrefix(receiverKey)
+	var infos []*MessageInfo
+	err := b.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: prefix})
+		defer it.Close()
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			item := it.Item()
+			parts := strings.Split(string(item.Key()), "_")
+			if len(parts) != 4 {
+				continue
+			}
+			info := &MessageInfo{ID: parts[3]}
+			// The migrated messages use the commit versions as sequence numbers,
+			// their arrival time is unknown.
+			if seq, ok := parseSequence(parts[2]); ok && seq > uint64(time.Second) {
+				info.Arrived = sequenceTime(seq)
+			}
+			// Only the metadata is read, not the message.
+			err := item.Value(func(v []byte) error {
+				info.Size, info.Sender = decodeBadgerMeta(v)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			infos = append(infos, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+func (b *Badger) Get(ctx context.Context, receiverKey []byte, msgID string) (*pb.DMSMessage, error) {
+	if err := validateDeliveryID(msgID); err != nil {
+		return nil, err
+	}
+	var msg *pb.DMSMessage
+	err := b.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(badgerIndexKey(fmt.Sprintf("%x", receiverKey), msgID))
+		if err == badger.ErrKeyNotFound {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		msgKey, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		item, err = txn.Get(msgKey)
+		if err == badger.ErrKeyNotFound {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(v []byte) error {
+			msg = &pb.DMSMessage{}
+			return proto.Unmarshal(v, msg)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (b *Badger) Delete(ctx context.Context, receiverKey []byte, msgID string) error {
+	if err := validateDeliveryID(msgID); err != nil {
+		return err
+	}
+	err := b.remove(receiverKey, msgID)
+	if err != nil {
+		return err
+	}
+	b.leases.release(receiverKey, msgID)
+	return nil
+}
+
+func (b *Badger) Remove(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte) error {
 	bb, err := proto.Marshal(msg)
 	if err != nil {
 		return fmt.Errorf("failed to serialize message: %w", err)
 	}
-	msgID := fmt.Sprintf("%x", sha256.Sum256(bb))
+	return b.remove(receiverKey, fmt.Sprintf("%x", sha256.Sum256(bb)))
+}
 
-	dbKey := "msg_" + fmt.Sprintf("%x", receiverKey) + "_" + msgID
-	err = b.db.Update(func(txn *badger.Txn) error {
-		return txn.Delete([]byte(dbKey))
+func (b *Badger) Lease(ctx context.Context, receiverKey []byte, timeout time.Duration) (*pb.DMSMessage, string, error) {
+	prefix := badgerMessagePrefix(receiverKey)
+	var msg *pb.DMSMessage
+	var msgID string
+	now := time.Now()
+	err := b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			key := string(it.Item().Key())
+			id := key[strings.LastIndex(key, "_")+1:]
+			if !b.leases.acquire(receiverKey, id, timeout, now) {
+				continue
+			}
+			m := &pb.DMSMessage{}
+			err := it.Item().Value(func(v []byte) error {
+				return proto.Unmarshal(v, m)
+			})
+			if err != nil {
+				b.leases.release(receiverKey, id)
+				return err
+			}
+			if !isDue(m, now) {
+				b.leases.release(receiverKey, id)
+				continue
+			}
+			msg, msgID = m, id
+			return nil
+		}
+		return nil
 	})
-	return err
+	if err != nil {
+		return nil, "", err
+	}
+	return msg, msgID, nil
+}
+
+func (b *Badger) Ack(ctx context.Context, receiverKey []byte, deliveryID string) error {
+	return b.Delete(ctx, receiverKey, deliveryID)
+}
+
+func (b *Badger) Requeue(ctx context.Context, receiverKey []byte, deliveryID string, delay time.Duration) error {
+	if err := validateDeliveryID(deliveryID); err != nil {
+		return err
+	}
+	b.leases.delay(receiverKey, deliveryID, delay, time.Now())
+	return nil
+}
+
+func (b *Badger) Stats(ctx context.Context, receiverKey []byte) (*MailboxStats, error) {
+	prefix := badgerMessagePrefix(receiverKey)
+	stats := &MailboxStats{}
+	err := b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			stats.Count++
+			stats.Size += int64(it.Item().ValueSize())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (b *Badger) SavePolicy(ctx context.Context, receiverKey []byte, policy *pb.Signed) error {
+	bb, err := proto.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("failed to serialize policy: %w", err)
+	}
+	dbKey := "policy_" + fmt.Sprintf("%x", receiverKey)
+	return b.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(dbKey), bb)
+	})
+}
+
+func (b *Badger) GetPolicy(ctx context.Context, receiverKey []byte) (*pb.Signed, error) {
+	dbKey := "policy_" + fmt.Sprintf("%x", receiverKey)
+	var policy *pb.Signed
+	err := b.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(dbKey))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(v []byte) error {
+			policy = &pb.Signed{}
+			return proto.Unmarshal(v, policy)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (b *Badger) Close() error {
+	return b.db.Close()
+}
+
+func (b *Badger) Mailboxes(ctx context.Context) ([]*MailboxInfo, error) {
+	var mailboxes []*MailboxInfo
+	err := b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		// The keys are sorted by receiver, so each mailbox is a contiguous range.
+		prefix := []byte("msg_")
+		var current *MailboxInfo
+		var currentKeyStr string
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			parts := strings.Split(string(it.Item().Key()), "_")
+			if len(parts) != 4 {
+				continue
+			}
+			if current == nil || parts[1] != currentKeyStr {
+				receiverKey, err := hex.DecodeString(parts[1])
+				if err != nil {
+					continue
+				}
+				current = &MailboxInfo{ReceiverKey: receiverKey}
+				currentKeyStr = parts[1]
+				mailboxes = append(mailboxes, current)
+			}
+			current.Count++
+			current.Size += int64(it.Item().ValueSize())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mailboxes, nil
+}
+
+func (b *Badger) Policies(ctx context.Context) ([][]byte, error) {
+	var keys [][]byte
+	err := b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		prefix := []byte("policy_")
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			receiverKey, err := hex.DecodeString(string(it.Item().Key()[len(prefix):]))
+			if err != nil {
+				continue
+			}
+			keys = append(keys, receiverKey)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (b *Badger) Purge(ctx context.Context, receiverKey []byte) (int, error) {
+	receiverKeyStr := fmt.Sprintf("%x", receiverKey)
+	prefix := badgerMessagePrefix(receiverKey)
+	wb := b.db.NewWriteBatch()
+	defer wb.Cancel()
+	count := 0
+	err := b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			key := it.Item().KeyCopy(nil)
+			msgID := string(key[bytes.LastIndexByte(key, '_')+1:])
+			err := wb.Delete(key)
+			if err != nil {
+				return err
+			}
+			err = wb.Delete(badgerMetaKey(key))
+			if err != nil {
+				return err
+			}
+			err = wb.Delete(badgerIndexKey(receiverKeyStr, msgID))
+			if err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	err = wb.Flush()
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (b *Badger) DiskSize(ctx context.Context) (int64, error) {
+	lsm, vlog := b.db.Size()
+	return lsm + vlog, nil
+}
+
+func (b *Badger) CollectGarbage(ctx context.Context, discardRatio float64) (int, error) {
+	// Each call rewrites at most one file, keep going until there is nothing to rewrite.
+	count := 0
+	for {
+		err := b.db.RunValueLogGC(discardRatio)
+		if err == badger.ErrNoRewrite {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+}
+
+// remove removes the message with the given hash, along with its index and metadata entries.
+func (b *Badger) remove(receiverKey []byte, msgID string) error {
+	indexKey := badgerIndexKey(fmt.Sprintf("%x", receiverKey), msgID)
+	return b.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(indexKey)
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		msgKey, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		err = txn.Delete(msgKey)
+		if err != nil {
+			return err
+		}
+		err = txn.Delete(badgerMetaKey(msgKey))
+		if err != nil {
+			return err
+		}
+		return txn.Delete(indexKey)
+	})
+}
+
+func badgerMessagePrefix(receiverKey []byte) []byte {
+	return []byte(fmt.Sprintf("msg_%x_", receiverKey))
+}
+
+func badgerMessageKey(receiverKeyStr string, seq uint64, msgID string) []byte {
+	return []byte("msg_" + receiverKeyStr + "_" + formatSequence(seq) + "_" + msgID)
+}
+
+// badgerEntry returns the entry which expires after ttl, or never if ttl is zero or negative.
+func badgerEntry(key []byte, value []byte, ttl time.Duration) *badger.Entry {
+	entry := badger.NewEntry(key, value)
+	if ttl > 0 {
+		entry = entry.WithTTL(ttl)
+	}
+	return entry
+}
+
+func badgerMetaPrefix(receiverKey []byte) []byte {
+	return []byte(fmt.Sprintf("meta_%x_", receiverKey))
+}
+
+// badgerMetaKey returns the key of the metadata entry for the given message key.
+func badgerMetaKey(msgKey []byte) []byte {
+	return append([]byte("meta_"), msgKey[len("msg_"):]...)
+}
+
+// encodeBadgerMeta encodes the metadata entry: the message size, followed by the sender.
+func encodeBadgerMeta(size int, sender string) []byte {
+	b := make([]byte, 8, 8+len(sender))
+	binary.BigEndian.PutUint64(b, uint64(size))
+	return append(b, sender...)
+}
+
+func decodeBadgerMeta(b []byte) (int64, string) {
+	if len(b) < 8 {
+		return 0, ""
+	}
+	return int64(binary.BigEndian.Uint64(b)), string(b[8:])
+}
+
+func badgerIndexKey(receiverKeyStr string, msgID string) []byte {
+	return []byte("idx_" + receiverKeyStr + "_" + msgID)
+}
+
+func badgerDedupKey(receiverKeyStr string, dedupKey string) []byte {
+	return []byte("dup_" + receiverKeyStr + "_" + dedupKey)
 }
@@ -0,0 +1,147 @@
+package store
+
+import (
+	"encoding/binary"
+	"strings"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// badgerLayoutVersion is the version of the key layout. Version 1, msg_<receiver>_<hash>,
+// didn't preserve the arrival order. Version 2 didn't have the metadata entries.
+const badgerLayoutVersion = 3
+
+var badgerLayoutVersionKey = []byte("layout_version")
+
+// migrateBadger converts the messages stored with the old key layouts.
+func migrateBadger(db *badger.DB) error {
+	version, err := getBadgerLayoutVersion(db)
+	if err != nil {
+		return err
+	}
+	if version >= badgerLayoutVersion {
+		return nil
+	}
+	if version < 2 {
+		err = migrateBadgerOrder(db)
+		if err != nil {
+			return err
+		}
+	}
+	err = migrateBadgerMeta(db)
+	if err != nil {
+		return err
+	}
+
+	return db.Update(func(txn *badger.Txn) error {
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, badgerLayoutVersion)
+		return txn.Set(badgerLayoutVersionKey, b)
+	})
+}
+
+// migrateBadgerOrder adds the sequence numbers to the message keys. Badger commit
+// versions are used as the sequence numbers, so the migrated messages keep their
+// relative order, and they are delivered before the new ones.
+func migrateBadgerOrder(db *badger.DB) error {
+	wb := db.NewWriteBatch()
+	defer wb.Cancel()
+	err := db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := []byte("msg_")
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			parts := strings.Split(string(item.Key()), "_")
+			if len(parts) != 3 {
+				// Already migrated.
+				continue
+			}
+			receiverKeyStr, msgID := parts[1], parts[2]
+			value, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			msgKey := badgerMessageKey(receiverKeyStr, item.Version(), msgID)
+			err = wb.SetEntry(&badger.Entry{Key: msgKey, Value: value, ExpiresAt: item.ExpiresAt()})
+			if err != nil {
+				return err
+			}
+			err = wb.SetEntry(&badger.Entry{Key: badgerIndexKey(receiverKeyStr, msgID), Value: msgKey,
+				ExpiresAt: item.ExpiresAt()})
+			if err != nil {
+				return err
+			}
+			err = wb.Delete(item.KeyCopy(nil))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return wb.Flush()
+}
+
+// migrateBadgerMeta adds the metadata entries for the messages which don't have them.
+func migrateBadgerMeta(db *badger.DB) error {
+	wb := db.NewWriteBatch()
+	defer wb.Cancel()
+	err := db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := []byte("msg_")
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			metaKey := badgerMetaKey(item.Key())
+			_, err := txn.Get(metaKey)
+			if err == nil {
+				continue
+			}
+			if err != badger.ErrKeyNotFound {
+				return err
+			}
+			var meta []byte
+			err = item.Value(func(v []byte) error {
+				meta = encodeBadgerMeta(len(v), messageSender(v))
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			err = wb.SetEntry(&badger.Entry{Key: metaKey, Value: meta, ExpiresAt: item.ExpiresAt()})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return wb.Flush()
+}
+
+// getBadgerLayoutVersion returns the key layout version. The version wasn't stored
+// before version 2.
+func getBadgerLayoutVersion(db *badger.DB) (uint64, error) {
+	version := uint64(1)
+	err := db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(badgerLayoutVersionKey)
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(v []byte) error {
+			if len(v) == 8 {
+				version = binary.BigEndian.Uint64(v)
+			}
+			return nil
+		})
+	})
+	return version, err
+}
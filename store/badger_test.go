@@ -1,11 +1,20 @@
 package store
 
 import (
+	"context"
+	"crypto/sha256"
+	"fmt"
 	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/dgraph-io/badger/v3"
+	"github.com/regnull/ubikom/pb"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
 )
 
 func Test_Badger_StoreGetRemove(t *testing.T) {
@@ -24,6 +33,218 @@ func Test_Badger_GetAll(t *testing.T) {
 	testGetAll(t, store)
 }
 
+func Test_Badger_LeaseAck(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestBadgerStore()
+	assert.NoError(err)
+	defer cleanup()
+	testLeaseAck(t, store)
+}
+
+func Test_Badger_Stats(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestBadgerStore()
+	assert.NoError(err)
+	defer cleanup()
+	testStats(t, store)
+}
+
+func Test_Badger_DeliveryWindow(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestBadgerStore()
+	assert.NoError(err)
+	defer cleanup()
+	testDeliveryWindow(t, store)
+}
+
+func Test_Badger_SaveOnce(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestBadgerStore()
+	assert.NoError(err)
+	defer cleanup()
+	testSaveOnce(t, store)
+}
+
+func Test_Badger_ListGetDelete(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestBadgerStore()
+	assert.NoError(err)
+	defer cleanup()
+	testListGetDelete(t, store)
+}
+
+func Test_Badger_NoExpiry(t *testing.T) {
+	assert := assert.New(t)
+	store, err := Open("badger", t.TempDir(), 0)
+	assert.NoError(err)
+	defer store.Close()
+	testNoExpiry(t, store)
+}
+
+func Test_Badger_Policy(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestBadgerStore()
+	assert.NoError(err)
+	defer cleanup()
+	testPolicy(t, store)
+}
+
+func Test_Badger_Order(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestBadgerStore()
+	assert.NoError(err)
+	defer cleanup()
+	testOrder(t, store)
+}
+
+func Test_Badger_Admin(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestBadgerStore()
+	assert.NoError(err)
+	defer cleanup()
+	testAdmin(t, store.(AdminStore))
+}
+
+func Test_Badger_Close(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	store, err := NewBadger(dir, time.Hour)
+	assert.NoError(err)
+	msg := &pb.DMSMessage{Sender: "foo", Receiver: "bar", Content: []byte("hello there")}
+	assert.NoError(store.Save(ctx, msg, []byte("123")))
+	assert.NoError(store.Close())
+
+	// The message survives the restart.
+	store, err = NewBadger(dir, time.Hour)
+	assert.NoError(err)
+	defer store.Close()
+	saved, err := store.GetNext(ctx, []byte("123"))
+	assert.NoError(err)
+	assert.True(proto.Equal(msg, saved))
+}
+
+func Test_Badger_Migration(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	// Save the messages with the old key layout, in the reverse hash order.
+	dir := t.TempDir()
+	db, err := badger.Open(badger.DefaultOptions(dir))
+	assert.NoError(err)
+	var messages []*pb.DMSMessage
+	for i := 0; i < 5; i++ {
+		messages = append(messages, &pb.DMSMessage{
+			Sender:   "foo",
+			Receiver: "bar",
+			Content:  []byte(fmt.Sprintf("message #%d", i)),
+		})
+	}
+	msgID := func(msg *pb.DMSMessage) string {
+		b, err := proto.Marshal(msg)
+		assert.NoError(err)
+		return fmt.Sprintf("%x", sha256.Sum256(b))
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		return msgID(messages[i]) > msgID(messages[j])
+	})
+	for _, msg := range messages {
+		b, err := proto.Marshal(msg)
+		assert.NoError(err)
+		assert.NoError(db.Update(func(txn *badger.Txn) error {
+			return txn.SetEntry(badger.NewEntry([]byte("msg_313233_"+msgID(msg)), b).WithTTL(time.Hour))
+		}))
+	}
+	assert.NoError(db.Close())
+
+	store, err := NewBadger(dir, time.Hour)
+	assert.NoError(err)
+	allMessages, err := store.GetAll(ctx, []byte("123"))
+	assert.NoError(err)
+	assert.Len(allMessages, len(messages))
+	for i, msg := range allMessages {
+		assert.True(proto.Equal(messages[i], msg))
+	}
+	assert.NoError(store.Remove(ctx, messages[0], []byte("123")))
+	_, deliveryID, err := store.Lease(ctx, []byte("123"), time.Minute)
+	assert.NoError(err)
+	assert.Equal(msgID(messages[1]), deliveryID)
+	assert.NoError(store.Ack(ctx, []byte("123"), deliveryID))
+	assert.NoError(store.Close())
+
+	// The migration runs only once.
+	store, err = NewBadger(dir, time.Hour)
+	assert.NoError(err)
+	defer store.Close()
+	stats, err := store.Stats(ctx, []byte("123"))
+	assert.NoError(err)
+	assert.Equal(len(messages)-2, stats.Count)
+
+	// The metadata is added for the migrated messages, and removed with them.
+	infos, err := store.List(ctx, []byte("123"))
+	assert.NoError(err)
+	if assert.Len(infos, len(messages)-2) {
+		for i, info := range infos {
+			b, err := proto.Marshal(messages[i+2])
+			assert.NoError(err)
+			assert.Equal(msgID(messages[i+2]), info.ID)
+			assert.Equal("foo", info.Sender)
+			assert.Equal(int64(len(b)), info.Size)
+		}
+	}
+}
+
+func Test_Badger_CollectGarbage(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	store, err := NewBadger(t.TempDir(), time.Hour)
+	assert.NoError(err)
+	defer store.Close()
+	msg := &pb.DMSMessage{Sender: "foo", Receiver: "bar", Content: []byte("hello there")}
+	assert.NoError(store.Save(ctx, msg, []byte("123")))
+	assert.NoError(store.Remove(ctx, msg, []byte("123")))
+	_, err = store.CollectGarbage(ctx, 0.5)
+	assert.NoError(err)
+}
+
+func Test_Badger_ConcurrentSave(t *testing.T) {
+	assert := assert.New(t)
+	str, cleanup, err := createTestBadgerStore()
+	assert.NoError(err)
+	defer cleanup()
+	ctx := context.Background()
+
+	// The concurrent saves of the messages with the same dedup key conflict, only one
+	// of them is saved and the rest are reported as duplicates.
+	const n = 20
+	var wg sync.WaitGroup
+	var savedCount atomic.Int32
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			msg := &pb.DMSMessage{Sender: "alice", Receiver: "bob", Content: []byte(fmt.Sprintf("message %d", i))}
+			saved, err := str.SaveOnce(ctx, msg, []byte("bob"), "dedup", time.Hour)
+			assert.NoError(err)
+			if saved {
+				savedCount.Add(1)
+			}
+			// The plain saves never get lost.
+			assert.NoError(str.Save(ctx, msg, []byte("carol")))
+		}(i)
+	}
+	wg.Wait()
+	assert.EqualValues(1, savedCount.Load())
+	stats, err := str.Stats(ctx, []byte("bob"))
+	assert.NoError(err)
+	assert.Equal(1, stats.Count)
+	stats, err = str.Stats(ctx, []byte("carol"))
+	assert.NoError(err)
+	assert.Equal(n, stats.Count)
+}
+
 func createTestBadgerStore() (Store, CleanupFunc, error) {
 	dir, err := os.MkdirTemp("", "ubikom_badgerstore_test")
 	if err != nil {
@@ -34,5 +255,8 @@ func createTestBadgerStore() (Store, CleanupFunc, error) {
 	if err != nil {
 		return nil, func() {}, err
 	}
-	return store, func() { os.RemoveAll(dir) }, nil
+	return store, func() {
+		store.Close()
+		os.RemoveAll(dir)
+	}, nil
 }
@@ -0,0 +1,40 @@
+package store
+
+import (
+	"time"
+
+	"github.com/regnull/ubikom/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// deliveryWindow returns the time range when the message can be delivered, as set
+// by the sender. Zero values mean no restriction. The options signature is verified
+// by the dump server before the message is saved.
+func deliveryWindow(msg *pb.DMSMessage) (notBefore time.Time, expires time.Time) {
+	if len(msg.GetDeliveryOptions()) == 0 {
+		return
+	}
+	opts := &pb.DeliveryOptions{}
+	if err := proto.Unmarshal(msg.GetDeliveryOptions(), opts); err != nil {
+		return
+	}
+	if opts.GetNotBefore() != 0 {
+		notBefore = time.Unix(opts.GetNotBefore(), 0)
+	}
+	if opts.GetExpires() != 0 {
+		expires = time.Unix(opts.GetExpires(), 0)
+	}
+	return
+}
+
+// isExpired returns true if the message has expired.
+func isExpired(msg *pb.DMSMessage, now time.Time) bool {
+	_, expires := deliveryWindow(msg)
+	return !expires.IsZero() && !now.Before(expires)
+}
+
+// isDue returns true if the message can be delivered.
+func isDue(msg *pb.DMSMessage, now time.Time) bool {
+	notBefore, _ := deliveryWindow(msg)
+	return notBefore.IsZero() || !now.Before(notBefore)
+}
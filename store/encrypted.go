@@ -0,0 +1,383 @@
+package store
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/regnull/ubikom/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// MasterKeySize is the size of the master key used by the encrypted store.
+const MasterKeySize = 32
+
+const encryptedFormatVersion = 1
+
+// mailboxIDTag starts the mailbox IDs returned by Mailboxes and Policies. The
+// compressed public keys start with 2 or 3, so the IDs can't be confused with them.
+const mailboxIDTag = 0
+
+var ErrDecryptionFailed = errors.New("failed to decrypt stored data")
+
+// Encrypted encrypts the messages and the policies before passing them to the
+// underlying store, and replaces the receiver keys with their HMACs, so that neither
+// the names nor the keys are stored in the clear. The mailboxes are listed by their
+// IDs, which are the tagged HMACs, and the IDs can be used instead of the receiver keys.
+//
+// Each message is encrypted with its own data key, and the data key is encrypted
+// with the key derived from the master key. The encryption is deterministic - the
+// data key and the nonce are the HMACs of the message - so that the same message
+// always gets the same message ID in the underlying store. This keeps Save idempotent and lets
+// Remove find the message. The delivery options are kept in the clear, since
+// the underlying store needs them for scheduling.
+type Encrypted struct {
+	store       Store
+	encryptKey  cipher.AEAD
+	dataKeyMac  []byte
+	nonceMac    []byte
+	receiverMac []byte
+}
+
+// NewEncrypted returns the store which encrypts everything it passes to str.
+func NewEncrypted(str Store, masterKey []byte) (*Encrypted, error) {
+	if len(masterKey) != MasterKeySize {
+		return nil, fmt.Errorf("invalid master key size")
+	}
+	encryptKey, err := newAEAD(deriveKey(masterKey, "ubikom store encryption"))
+	if err != nil {
+		return nil, err
+	}
+	return &Encrypted{
+		store:       str,
+		encryptKey:  encryptKey,
+		dataKeyMac:  deriveKey(masterKey, "ubikom store data key"),
+		nonceMac:    deriveKey(masterKey, "ubikom store nonce"),
+		receiverMac: deriveKey(masterKey, "ubikom store receiver"),
+	}, nil
+}
+
+// LoadMasterKey reads the hex-encoded master key from the file.
+func LoadMasterKey(fileName string) ([]byte, error) {
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read master key: %w", err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil || len(key) != MasterKeySize {
+		return nil, fmt.Errorf("master key must be %d hex-encoded bytes", MasterKeySize)
+	}
+	return key, nil
+}
+
+func (e *Encrypted) Save(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte) error {
+	receiverMac := e.receiverKey(receiverKey)
+	sealed, err := e.sealMessage(msg, receiverMac)
+	if err != nil {
+		return err
+	}
+	return e.store.Save(ctx, sealed, receiverMac)
+}
+
+func (e *Encrypted) SaveOnce(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte, dedupKey string,
+	window time.Duration) (bool, error) {
+	receiverMac := e.receiverKey(receiverKey)
+	sealed, err := e.sealMessage(msg, receiverMac)
+	if err != nil {
+		return false, err
+	}
+	return e.store.SaveOnce(ctx, sealed, receiverMac, hex.EncodeToString(e.mac(receiverMac, []byte(dedupKey))),
+		window)
+}
+
+func (e *Encrypted) GetNext(ctx context.Context, receiverKey []byte) (*pb.DMSMessage, error) {
+	receiverMac := e.receiverKey(receiverKey)
+	sealed, err := e.store.GetNext(ctx, receiverMac)
+	if err != nil || sealed == nil {
+		return nil, err
+	}
+	return e.openMessage(sealed, receiverMac)
+}
+
+func (e *Encrypted) GetAll(ctx context.Context, receiverKey []byte) ([]*pb.DMSMessage, error) {
+	receiverMac := e.receiverKey(receiverKey)
+	sealed, err := e.store.GetAll(ctx, receiverMac)
+	if err != nil {
+		return nil, err
+	}
+	var msgs []*pb.DMSMessage
+	for _, s := range sealed {
+		msg, err := e.openMessage(s, receiverMac)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// List returns the metadata from the underlying store. The sender is not known
+// without decrypting the message, and the sizes are the sizes of the encrypted messages.
+func (e *Encrypted) List(ctx context.Context, receiverKey []byte) ([]*MessageInfo, error) {
+	return e.store.List(ctx, e.receiverKey(receiverKey))
+}
+
+func (e *Encrypted) Get(ctx context.Context, receiverKey []byte, msgID string) (*pb.DMSMessage, error) {
+	receiverMac := e.receiverKey(receiverKey)
+	sealed, err := e.store.Get(ctx, receiverMac, msgID)
+	if err != nil {
+		return nil, err
+	}
+	return e.openMessage(sealed, receiverMac)
+}
+
+func (e *Encrypted) Delete(ctx context.Context, receiverKey []byte, msgID string) error {
+	return e.store.Delete(ctx, e.receiverKey(receiverKey), msgID)
+}
+
+func (e *Encrypted) Remove(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte) error {
+	receiverMac := e.receiverKey(receiverKey)
+	sealed, err := e.sealMessage(msg, receiverMac)
+	if err != nil {
+		return err
+	}
+	return e.store.Remove(ctx, sealed, receiverMac)
+}
+
+func (e *Encrypted) Lease(ctx context.Context, receiverKey []byte, timeout time.Duration) (*pb.DMSMessage, string, error) {
+	receiverMac := e.receiverKey(receiverKey)
+	sealed, deliveryID, err := e.store.Lease(ctx, receiverMac, timeout)
+	if err != nil || sealed == nil {
+		return nil, "", err
+	}
+	msg, err := e.openMessage(sealed, receiverMac)
+	if err != nil {
+		return nil, "", err
+	}
+	return msg, deliveryID, nil
+}
+
+func (e *Encrypted) Ack(ctx context.Context, receiverKey []byte, deliveryID string) error {
+	return e.store.Ack(ctx, e.receiverKey(receiverKey), deliveryID)
+}
+
+func (e *Encrypted) Requeue(ctx context.Context, receiverKey []byte, deliveryID string, delay time.Duration) error {
+	return e.store.Requeue(ctx, e.receiverKey(receiverKey), deliveryID, delay)
+}
+
+// Stats returns the stats from the underlying store, the size is the size of
+// the encrypted messages.
+func (e *Encrypted) Stats(ctx context.Context, receiverKey []byte) (*MailboxStats, error) {
+	return e.store.Stats(ctx, e.receiverKey(receiverKey))
+}
+
+func (e *Encrypted) SavePolicy(ctx context.Context, receiverKey []byte, policy *pb.Signed) error {
+	receiverMac := e.receiverKey(receiverKey)
+	b, err := proto.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("failed to serialize policy: %w", err)
+	}
+	sealed, err := e.seal(b, receiverMac)
+	if err != nil {
+		return err
+	}
+	return e.store.SavePolicy(ctx, receiverMac, &pb.Signed{Content: sealed})
+}
+
+func (e *Encrypted) GetPolicy(ctx context.Context, receiverKey []byte) (*pb.Signed, error) {
+	receiverMac := e.receiverKey(receiverKey)
+	sealed, err := e.store.GetPolicy(ctx, receiverMac)
+	if err != nil || sealed == nil {
+		return nil, err
+	}
+	b, err := e.open(sealed.GetContent(), receiverMac)
+	if err != nil {
+		return nil, err
+	}
+	policy := &pb.Signed{}
+	err = proto.Unmarshal(b, policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal policy: %w", err)
+	}
+	return policy, nil
+}
+
+func (e *Encrypted) Close() error {
+	return e.store.Close()
+}
+
+// Mailboxes returns the mailboxes of the underlying store. The real receiver keys are
+// not stored, so the mailboxes are identified by their IDs instead.
+func (e *Encrypted) Mailboxes(ctx context.Context) ([]*MailboxInfo, error) {
+	adminStore, ok := e.store.(AdminStore)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	mailboxes, err := adminStore.Mailboxes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, mailbox := range mailboxes {
+		mailbox.ReceiverKey = mailboxID(mailbox.ReceiverKey)
+	}
+	return mailboxes, nil
+}
+
+// Policies returns the mailbox IDs, like Mailboxes.
+func (e *Encrypted) Policies(ctx context.Context) ([][]byte, error) {
+	adminStore, ok := e.store.(AdminStore)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	receiverMacs, err := adminStore.Policies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ids [][]byte
+	for _, receiverMac := range receiverMacs {
+		ids = append(ids, mailboxID(receiverMac))
+	}
+	return ids, nil
+}
+
+func (e *Encrypted) Purge(ctx context.Context, receiverKey []byte) (int, error) {
+	adminStore, ok := e.store.(AdminStore)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+	return adminStore.Purge(ctx, e.receiverKey(receiverKey))
+}
+
+func (e *Encrypted) DiskSize(ctx context.Context) (int64, error) {
+	adminStore, ok := e.store.(AdminStore)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+	return adminStore.DiskSize(ctx)
+}
+
+func (e *Encrypted) CollectGarbage(ctx context.Context, discardRatio float64) (int, error) {
+	adminStore, ok := e.store.(AdminStore)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+	return adminStore.CollectGarbage(ctx, discardRatio)
+}
+
+// receiverKey returns the key under which the receiver's data is stored. The receiver
+// key can also be the mailbox ID.
+func (e *Encrypted) receiverKey(receiverKey []byte) []byte {
+	if len(receiverKey) == 1+sha256.Size && receiverKey[0] == mailboxIDTag {
+		return receiverKey[1:]
+	}
+	return e.mac(e.receiverMac, receiverKey)
+}
+
+// mailboxID returns the ID of the mailbox stored under the given key.
+func mailboxID(receiverMac []byte) []byte {
+	return append([]byte{mailboxIDTag}, receiverMac...)
+}
+
+func (e *Encrypted) sealMessage(msg *pb.DMSMessage, receiverMac []byte) (*pb.DMSMessage, error) {
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize message: %w", err)
+	}
+	sealed, err := e.seal(b, receiverMac)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DMSMessage{Content: sealed, DeliveryOptions: msg.GetDeliveryOptions()}, nil
+}
+
+func (e *Encrypted) openMessage(sealed *pb.DMSMessage, receiverMac []byte) (*pb.DMSMessage, error) {
+	b, err := e.open(sealed.GetContent(), receiverMac)
+	if err != nil {
+		return nil, err
+	}
+	msg := &pb.DMSMessage{}
+	err = proto.Unmarshal(b, msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+	return msg, nil
+}
+
+// seal encrypts the data, the result is the format version, the encrypted data key
+// with its nonce, and the encrypted data. The receiver key is authenticated, so
+// the data can't be moved to another mailbox.
+func (e *Encrypted) seal(data []byte, receiverMac []byte) ([]byte, error) {
+	// The data key and the nonce depend on the receiver, so that the same data sent
+	// to different receivers is never encrypted with the same key and nonce.
+	hash := sha256.Sum256(data)
+	dataKey := e.mac(e.dataKeyMac, receiverMac, hash[:])
+	dataCipher, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	// The nonces don't have to be random: each data key encrypts only this data for
+	// this receiver, and each data key is encrypted once. The key nonce is stored in the clear, so
+	// it's the HMAC, otherwise it would reveal the data hash.
+	keyNonce := e.mac(e.nonceMac, receiverMac, data)[:e.encryptKey.NonceSize()]
+	out := []byte{encryptedFormatVersion}
+	out = append(out, keyNonce...)
+	out = e.encryptKey.Seal(out, keyNonce, dataKey, receiverMac)
+	return dataCipher.Seal(out, make([]byte, dataCipher.NonceSize()), data, receiverMac), nil
+}
+
+func (e *Encrypted) open(sealed []byte, receiverMac []byte) ([]byte, error) {
+	nonceSize := e.encryptKey.NonceSize()
+	wrappedKeySize := MasterKeySize + e.encryptKey.Overhead()
+	if len(sealed) < 1+nonceSize+wrappedKeySize || sealed[0] != encryptedFormatVersion {
+		return nil, ErrDecryptionFailed
+	}
+	keyNonce := sealed[1 : 1+nonceSize]
+	wrappedKey := sealed[1+nonceSize : 1+nonceSize+wrappedKeySize]
+	dataKey, err := e.encryptKey.Open(nil, keyNonce, wrappedKey, receiverMac)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	dataCipher, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	data, err := dataCipher.Open(nil, make([]byte, dataCipher.NonceSize()),
+		sealed[1+nonceSize+wrappedKeySize:], receiverMac)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return data, nil
+}
+
+// mac returns the HMAC of the concatenated parts. All parts except the last one
+// must have a fixed size.
+func (e *Encrypted) mac(key []byte, parts ...[]byte) []byte {
+	h := hmac.New(sha256.New, key)
+	for _, part := range parts {
+		h.Write(part)
+	}
+	return h.Sum(nil)
+}
+
+// deriveKey derives the key for the given purpose from the master key.
+func deriveKey(masterKey []byte, purpose string) []byte {
+	h := hmac.New(sha256.New, masterKey)
+	h.Write([]byte(purpose))
+	return h.Sum(nil)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(c)
+}
@@ -0,0 +1,263 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/regnull/easyecc"
+	"github.com/regnull/ubikom/pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func Test_Encrypted_StoreGetRemove(t *testing.T) {
+	testGetRemove(t, createTestEncryptedStore(t, NewMemory()))
+}
+
+func Test_Encrypted_GetAll(t *testing.T) {
+	testGetAll(t, createTestEncryptedStore(t, NewMemory()))
+}
+
+func Test_Encrypted_LeaseAck(t *testing.T) {
+	testLeaseAck(t, createTestEncryptedStore(t, NewMemory()))
+}
+
+func Test_Encrypted_DeliveryWindow(t *testing.T) {
+	testDeliveryWindow(t, createTestEncryptedStore(t, NewMemory()))
+}
+
+func Test_Encrypted_SaveOnce(t *testing.T) {
+	testSaveOnce(t, createTestEncryptedStore(t, NewMemory()))
+}
+
+func Test_Encrypted_NoExpiry(t *testing.T) {
+	testNoExpiry(t, createTestEncryptedStore(t, NewFile(t.TempDir(), 0)))
+}
+
+func Test_Encrypted_Policy(t *testing.T) {
+	testPolicy(t, createTestEncryptedStore(t, NewMemory()))
+}
+
+func Test_Encrypted_Order(t *testing.T) {
+	testOrder(t, createTestEncryptedStore(t, NewMemory()))
+}
+
+func Test_Encrypted_File(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	store := createTestEncryptedStore(t, NewFile(dir, time.Hour))
+
+	pk, _ := easyecc.NewRandomPrivateKey()
+	key := pk.PublicKey().SerializeCompressed()
+	msg := &pb.DMSMessage{Sender: "alice", Receiver: "bob", Content: []byte("hello there")}
+	assert.NoError(store.Save(ctx, msg, key))
+	// Saving the same message again doesn't create a copy.
+	assert.NoError(store.Save(ctx, msg, key))
+	assert.NoError(store.SavePolicy(ctx, key, &pb.Signed{Content: []byte("policy"), Key: key}))
+
+	stats, err := store.Stats(ctx, key)
+	assert.NoError(err)
+	assert.Equal(1, stats.Count)
+
+	// Neither the names, nor the receiver key are stored in the clear.
+	var files int
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		assert.NotContains(path, hex.EncodeToString(key))
+		assert.NotContains(path, hex.EncodeToString(key)[:10])
+		if info.IsDir() {
+			return nil
+		}
+		files++
+		b, err := os.ReadFile(path)
+		assert.NoError(err)
+		for _, s := range [][]byte{[]byte("alice"), []byte("bob"), []byte("hello"), []byte("policy"), key} {
+			assert.False(bytes.Contains(b, s), "%s contains %q", path, s)
+		}
+		return nil
+	})
+	assert.NoError(err)
+	assert.Equal(2, files)
+
+	saved, err := store.GetNext(ctx, key)
+	assert.NoError(err)
+	assert.True(proto.Equal(msg, saved))
+	assert.NoError(store.Remove(ctx, saved, key))
+	stats, err = store.Stats(ctx, key)
+	assert.NoError(err)
+	assert.Equal(0, stats.Count)
+}
+
+func Test_Encrypted_ListGetDelete(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	store := createTestEncryptedStore(t, NewMemory())
+	pk, _ := easyecc.NewRandomPrivateKey()
+	key := pk.PublicKey().SerializeCompressed()
+	var msgs []*pb.DMSMessage
+	for i := 0; i < 3; i++ {
+		msg := &pb.DMSMessage{Sender: "foo", Receiver: "bar", Content: []byte(fmt.Sprintf("message #%d", i))}
+		assert.NoError(store.Save(ctx, msg, key))
+		msgs = append(msgs, msg)
+	}
+	infos, err := store.List(ctx, key)
+	assert.NoError(err)
+	if !assert.Len(infos, 3) {
+		return
+	}
+	msg, err := store.Get(ctx, key, infos[1].ID)
+	assert.NoError(err)
+	assert.True(proto.Equal(msgs[1], msg))
+	assert.NoError(store.Delete(ctx, key, infos[1].ID))
+	_, err = store.Get(ctx, key, infos[1].ID)
+	assert.ErrorIs(err, ErrNotFound)
+}
+
+func Test_Encrypted_MailboxID(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	store := createTestEncryptedStore(t, NewMemory())
+	pk, _ := easyecc.NewRandomPrivateKey()
+	key := pk.PublicKey().SerializeCompressed()
+	var msgs []*pb.DMSMessage
+	for i := 0; i < 3; i++ {
+		msg := &pb.DMSMessage{Sender: "foo", Receiver: "bar", Content: []byte(fmt.Sprintf("message #%d", i))}
+		assert.NoError(store.Save(ctx, msg, key))
+		msgs = append(msgs, msg)
+	}
+	signedPolicy := &pb.Signed{Content: []byte("policy"), Key: key}
+	assert.NoError(store.SavePolicy(ctx, key, signedPolicy))
+
+	// The mailbox is listed by its ID, which is neither the key nor its HMAC.
+	mailboxes, err := store.Mailboxes(ctx)
+	assert.NoError(err)
+	if !assert.Len(mailboxes, 1) {
+		return
+	}
+	id := mailboxes[0].ReceiverKey
+	assert.NotEqual(key, id)
+	assert.NotEqual(store.receiverKey(key), id)
+	policyIDs, err := store.Policies(ctx)
+	assert.NoError(err)
+	assert.Equal([][]byte{id}, policyIDs)
+	policy, err := store.GetPolicy(ctx, id)
+	assert.NoError(err)
+	assert.True(proto.Equal(signedPolicy, policy))
+
+	// The ID can be used instead of the key.
+	infos, err := store.List(ctx, id)
+	assert.NoError(err)
+	if !assert.Len(infos, 3) {
+		return
+	}
+	msg, err := store.Get(ctx, id, infos[1].ID)
+	assert.NoError(err)
+	assert.True(proto.Equal(msgs[1], msg))
+	assert.NoError(store.Delete(ctx, id, infos[1].ID))
+	infos, err = store.List(ctx, key)
+	assert.NoError(err)
+	assert.Len(infos, 2)
+
+	n, err := store.Purge(ctx, id)
+	assert.NoError(err)
+	assert.Equal(2, n)
+	infos, err = store.List(ctx, key)
+	assert.NoError(err)
+	assert.Empty(infos)
+	mailboxes, err = store.Mailboxes(ctx)
+	assert.NoError(err)
+	assert.Empty(mailboxes)
+}
+
+func Test_Encrypted_WrongKey(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	memory := NewMemory()
+	store := createTestEncryptedStore(t, memory)
+	pk, _ := easyecc.NewRandomPrivateKey()
+	key := pk.PublicKey().SerializeCompressed()
+	msg := &pb.DMSMessage{Sender: "foo", Receiver: "bar", Content: []byte("hello there")}
+	assert.NoError(store.Save(ctx, msg, key))
+
+	// The message can't be read with another key, or from another mailbox.
+	other := createTestEncryptedStore(t, memory)
+	saved, err := other.GetNext(ctx, key)
+	assert.NoError(err)
+	assert.Nil(saved)
+	sealed, err := memory.GetNext(ctx, store.receiverKey(key))
+	assert.NoError(err)
+	_, err = store.openMessage(sealed, store.receiverKey([]byte("123")))
+	assert.ErrorIs(err, ErrDecryptionFailed)
+}
+
+func Test_Encrypted_KeyPerReceiver(t *testing.T) {
+	assert := assert.New(t)
+
+	store := createTestEncryptedStore(t, NewMemory())
+	data := []byte("hello there")
+	receiverMac1 := store.receiverKey([]byte("123"))
+	receiverMac2 := store.receiverKey([]byte("456"))
+	sealed1, err := store.seal(data, receiverMac1)
+	assert.NoError(err)
+	sealed2, err := store.seal(data, receiverMac2)
+	assert.NoError(err)
+
+	// The same data sent to two receivers has different data keys and nonces.
+	nonceSize := store.encryptKey.NonceSize()
+	wrappedKeySize := MasterKeySize + store.encryptKey.Overhead()
+	nonce1, nonce2 := sealed1[1:1+nonceSize], sealed2[1:1+nonceSize]
+	assert.NotEqual(nonce1, nonce2)
+	wrappedKey1 := sealed1[1+nonceSize : 1+nonceSize+wrappedKeySize]
+	wrappedKey2 := sealed2[1+nonceSize : 1+nonceSize+wrappedKeySize]
+	assert.NotEqual(wrappedKey1, wrappedKey2)
+	dataKey1, err := store.encryptKey.Open(nil, nonce1, wrappedKey1, receiverMac1)
+	assert.NoError(err)
+	dataKey2, err := store.encryptKey.Open(nil, nonce2, wrappedKey2, receiverMac2)
+	assert.NoError(err)
+	assert.NotEqual(dataKey1, dataKey2)
+
+	opened, err := store.open(sealed2, receiverMac2)
+	assert.NoError(err)
+	assert.Equal(data, opened)
+}
+
+func Test_LoadMasterKey(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	key := make([]byte, MasterKeySize)
+	_, err := rand.Read(key)
+	assert.NoError(err)
+	assert.NoError(os.WriteFile(path.Join(dir, "key"), []byte(hex.EncodeToString(key)+"\n"), 0600))
+	loaded, err := LoadMasterKey(path.Join(dir, "key"))
+	assert.NoError(err)
+	assert.Equal(key, loaded)
+
+	assert.NoError(os.WriteFile(path.Join(dir, "short"), []byte("abcd"), 0600))
+	_, err = LoadMasterKey(path.Join(dir, "short"))
+	assert.Error(err)
+}
+
+func createTestEncryptedStore(t *testing.T, str Store) *Encrypted {
+	key := make([]byte, MasterKeySize)
+	_, err := rand.Read(key)
+	assert.NoError(t, err)
+	store, err := NewEncrypted(str, key)
+	assert.NoError(t, err)
+	return store
+}
@@ -0,0 +1,222 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/regnull/ubikom/pb"
+	"github.com/regnull/ubikom/protoio"
+	"google.golang.org/protobuf/proto"
+)
+
+// exportFormatVersion is the version of the export stream, written in the header.
+const exportFormatVersion = 1
+
+var ErrInvalidExport = errors.New("invalid export")
+
+// ExportStats contains the number of the exported, imported or copied records.
+type ExportStats struct {
+	Messages int
+	Policies int
+}
+
+// Export writes all the messages and the mailbox policies to w as a stream of
+// length-delimited StoreExportRecords. The stream starts with the header, and ends
+// with the trailer which contains the SHA-256 of everything before it.
+func Export(ctx context.Context, str AdminStore, w io.Writer) (*ExportStats, error) {
+	hashWriter := protoio.NewSha256Writer(w)
+	out := protoio.NewWriter(hashWriter)
+	err := out.Write(&pb.StoreExportRecord{Record: &pb.StoreExportRecord_Header{
+		Header: &pb.StoreExportHeader{
+			Version:   exportFormatVersion,
+			Timestamp: uint64(time.Now().Unix()),
+		}}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write header: %w", err)
+	}
+	stats := &ExportStats{}
+	err = walkStore(ctx, str, func(record *pb.StoreExportRecord) error {
+		countRecord(stats, record)
+		return out.Write(record)
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = out.Write(&pb.StoreExportRecord{Record: &pb.StoreExportRecord_Trailer{
+		Trailer: &pb.StoreExportTrailer{
+			MessageCount: uint64(stats.Messages),
+			PolicyCount:  uint64(stats.Policies),
+			Sha256:       hashWriter.Hash(),
+		}}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write trailer: %w", err)
+	}
+	return stats, nil
+}
+
+// Import saves the messages and the mailbox policies from the export stream. The
+// checksum is only known at the end of the stream, so if the stream is corrupted
+// some records might be imported before the error is returned. Use VerifyExport
+// first if the stream can be read twice.
+func Import(ctx context.Context, str Store, r io.Reader) (*ExportStats, error) {
+	return readExport(r, func(record *pb.StoreExportRecord) error {
+		return importRecord(ctx, str, record)
+	})
+}
+
+// VerifyExport reads the export stream and checks it against the trailer.
+func VerifyExport(r io.Reader) (*ExportStats, error) {
+	return readExport(r, func(*pb.StoreExportRecord) error { return nil })
+}
+
+// Copy copies all the messages and the mailbox policies from src to dst.
+func Copy(ctx context.Context, src AdminStore, dst Store) (*ExportStats, error) {
+	stats := &ExportStats{}
+	err := walkStore(ctx, src, func(record *pb.StoreExportRecord) error {
+		countRecord(stats, record)
+		return importRecord(ctx, dst, record)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// walkStore calls f for every message and every mailbox policy. The messages of
+// each mailbox are passed oldest first, so that they are saved in the same order.
+func walkStore(ctx context.Context, str AdminStore, f func(*pb.StoreExportRecord) error) error {
+	if _, ok := str.(*Encrypted); ok {
+		// Its mailboxes are listed by the IDs, not by the receiver keys, so the export
+		// couldn't be imported. The underlying store is exported as is instead.
+		return errors.New("encrypted store can't be exported, use the underlying store")
+	}
+	mailboxes, err := str.Mailboxes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get mailboxes: %w", err)
+	}
+	for _, mailbox := range mailboxes {
+		infos, err := str.List(ctx, mailbox.ReceiverKey)
+		if err != nil {
+			return fmt.Errorf("failed to list messages: %w", err)
+		}
+		for _, info := range infos {
+			msg, err := str.Get(ctx, mailbox.ReceiverKey, info.ID)
+			if err == ErrNotFound {
+				// Expired or removed after it was listed.
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get message: %w", err)
+			}
+			err = f(&pb.StoreExportRecord{Record: &pb.StoreExportRecord_Message{
+				Message: &pb.StoreExportMessage{ReceiverKey: mailbox.ReceiverKey, Message: msg}}})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	policyKeys, err := str.Policies(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get policies: %w", err)
+	}
+	for _, receiverKey := range policyKeys {
+		policy, err := str.GetPolicy(ctx, receiverKey)
+		if err != nil {
+			return fmt.Errorf("failed to get policy: %w", err)
+		}
+		if policy == nil {
+			continue
+		}
+		err = f(&pb.StoreExportRecord{Record: &pb.StoreExportRecord_Policy{
+			Policy: &pb.StoreExportPolicy{ReceiverKey: receiverKey, Policy: policy}}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readExport calls f for every message and policy record in the export stream, and
+// verifies the stream against the trailer.
+func readExport(r io.Reader, f func(*pb.StoreExportRecord) error) (*ExportStats, error) {
+	// The hash of the bytes read so far is checked against the trailer.
+	hashWriter := protoio.NewSha256Writer(io.Discard)
+	in := protoio.NewReader(io.TeeReader(r, hashWriter))
+	parse := func(b []byte) (proto.Message, error) {
+		record := &pb.StoreExportRecord{}
+		err := proto.Unmarshal(b, record)
+		return record, err
+	}
+
+	stats := &ExportStats{}
+	for i := 0; ; i++ {
+		hash := hashWriter.Hash()
+		msg, err := in.Read(parse)
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: no trailer, the export is truncated", ErrInvalidExport)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to read record: %v", ErrInvalidExport, err)
+		}
+		record := msg.(*pb.StoreExportRecord)
+		if i == 0 {
+			if record.GetHeader() == nil {
+				return nil, fmt.Errorf("%w: no header", ErrInvalidExport)
+			}
+			if record.GetHeader().GetVersion() != exportFormatVersion {
+				return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidExport,
+					record.GetHeader().GetVersion())
+			}
+			continue
+		}
+		switch {
+		case record.GetTrailer() != nil:
+			trailer := record.GetTrailer()
+			if !bytes.Equal(trailer.GetSha256(), hash) {
+				return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidExport)
+			}
+			if trailer.GetMessageCount() != uint64(stats.Messages) ||
+				trailer.GetPolicyCount() != uint64(stats.Policies) {
+				return nil, fmt.Errorf("%w: record count mismatch", ErrInvalidExport)
+			}
+			return stats, nil
+		case record.GetMessage().GetMessage() != nil || record.GetPolicy().GetPolicy() != nil:
+			err = f(record)
+			if err != nil {
+				return nil, err
+			}
+			countRecord(stats, record)
+		default:
+			return nil, fmt.Errorf("%w: unexpected record", ErrInvalidExport)
+		}
+	}
+}
+
+func importRecord(ctx context.Context, str Store, record *pb.StoreExportRecord) error {
+	if m := record.GetMessage(); m != nil {
+		err := str.Save(ctx, m.GetMessage(), m.GetReceiverKey())
+		if err != nil {
+			return fmt.Errorf("failed to save message: %w", err)
+		}
+	}
+	if p := record.GetPolicy(); p != nil {
+		err := str.SavePolicy(ctx, p.GetReceiverKey(), p.GetPolicy())
+		if err != nil {
+			return fmt.Errorf("failed to save policy: %w", err)
+		}
+	}
+	return nil
+}
+
+func countRecord(stats *ExportStats, record *pb.StoreExportRecord) {
+	if record.GetMessage() != nil {
+		stats.Messages++
+	}
+	if record.GetPolicy() != nil {
+		stats.Policies++
+	}
+}
@@ -0,0 +1,138 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/regnull/easyecc"
+	"github.com/regnull/ubikom/pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func Test_Export_ImportRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	src := NewMemory()
+	key1, key2 := fillExportTestStore(t, src)
+
+	var buf bytes.Buffer
+	stats, err := Export(ctx, src, &buf)
+	assert.NoError(err)
+	assert.Equal(&ExportStats{Messages: 4, Policies: 1}, stats)
+
+	stats, err = VerifyExport(bytes.NewReader(buf.Bytes()))
+	assert.NoError(err)
+	assert.Equal(&ExportStats{Messages: 4, Policies: 1}, stats)
+
+	dst, cleanup, err := createTestSQLiteStore()
+	assert.NoError(err)
+	defer cleanup()
+	stats, err = Import(ctx, dst, bytes.NewReader(buf.Bytes()))
+	assert.NoError(err)
+	assert.Equal(&ExportStats{Messages: 4, Policies: 1}, stats)
+	assertExportTestStore(t, dst, key1, key2)
+
+	// Importing again doesn't duplicate the messages.
+	_, err = Import(ctx, dst, bytes.NewReader(buf.Bytes()))
+	assert.NoError(err)
+	mailboxStats, err := dst.Stats(ctx, key1)
+	assert.NoError(err)
+	assert.Equal(3, mailboxStats.Count)
+}
+
+func Test_Export_Copy(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	src, srcCleanup, err := createTestFileStore()
+	assert.NoError(err)
+	defer srcCleanup()
+	key1, key2 := fillExportTestStore(t, src.(AdminStore))
+
+	dst, dstCleanup, err := createTestBadgerStore()
+	assert.NoError(err)
+	defer dstCleanup()
+	stats, err := Copy(ctx, src.(AdminStore), dst)
+	assert.NoError(err)
+	assert.Equal(&ExportStats{Messages: 4, Policies: 1}, stats)
+	assertExportTestStore(t, dst, key1, key2)
+}
+
+func Test_Export_Corrupted(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	src := NewMemory()
+	fillExportTestStore(t, src)
+	var buf bytes.Buffer
+	_, err := Export(ctx, src, &buf)
+	assert.NoError(err)
+	b := buf.Bytes()
+
+	// Flip a bit in the message content.
+	corrupted := append([]byte{}, b...)
+	i := bytes.Index(corrupted, []byte("message #1"))
+	assert.True(i > 0)
+	corrupted[i] ^= 1
+	_, err = VerifyExport(bytes.NewReader(corrupted))
+	assert.True(errors.Is(err, ErrInvalidExport))
+
+	// Cut off the trailer.
+	_, err = VerifyExport(bytes.NewReader(b[:len(b)-10]))
+	assert.True(errors.Is(err, ErrInvalidExport))
+
+	_, err = VerifyExport(bytes.NewReader(nil))
+	assert.True(errors.Is(err, ErrInvalidExport))
+}
+
+func Test_Export_Encrypted(t *testing.T) {
+	assert := assert.New(t)
+
+	str := createTestEncryptedStore(t, NewMemory())
+	_, err := Export(context.Background(), str, &bytes.Buffer{})
+	assert.Error(err)
+}
+
+func fillExportTestStore(t *testing.T, store AdminStore) ([]byte, []byte) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	pk1, _ := easyecc.NewRandomPrivateKey()
+	key1 := pk1.PublicKey().SerializeCompressed()
+	pk2, _ := easyecc.NewRandomPrivateKey()
+	key2 := pk2.PublicKey().SerializeCompressed()
+
+	for i := 0; i < 3; i++ {
+		msg := &pb.DMSMessage{Sender: "foo", Receiver: "bar", Content: []byte(fmt.Sprintf("message #%d", i))}
+		assert.NoError(store.Save(ctx, msg, key1))
+	}
+	assert.NoError(store.Save(ctx, &pb.DMSMessage{Sender: "foo", Receiver: "baz", Content: []byte("hello")}, key2))
+	assert.NoError(store.SavePolicy(ctx, key2, &pb.Signed{Content: []byte("policy"), Key: key2}))
+	return key1, key2
+}
+
+func assertExportTestStore(t *testing.T, store Store, key1 []byte, key2 []byte) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	msgs, err := store.GetAll(ctx, key1)
+	assert.NoError(err)
+	if assert.Len(msgs, 3) {
+		for i, msg := range msgs {
+			assert.Equal([]byte(fmt.Sprintf("message #%d", i)), msg.GetContent())
+		}
+	}
+	msgs, err = store.GetAll(ctx, key2)
+	assert.NoError(err)
+	if assert.Len(msgs, 1) {
+		assert.Equal([]byte("hello"), msgs[0].GetContent())
+	}
+	policy, err := store.GetPolicy(ctx, key2)
+	assert.NoError(err)
+	assert.True(proto.Equal(&pb.Signed{Content: []byte("policy"), Key: key2}, policy))
+}
@@ -1,26 +1,61 @@
 package store
 
 import (
+	"context"
 	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/regnull/ubikom/pb"
 	"google.golang.org/protobuf/proto"
 )
 
+// policyDirName is the directory for the mailbox policies. It can't collide with
+// the receiver directories, which are named with hex digits.
+const policyDirName = "policy"
+
+// shortKeyDirName is the directory for the receivers whose keys are too short to be
+// split into the usual subdirectories. Real public keys are never that short.
+const shortKeyDirName = "short"
+
+// dedupDirName is the directory for the dedup markers.
+const dedupDirName = "dedup"
+
+// messageHeaderSize is how much of the message file is read to get the sender.
+const messageHeaderSize = 512
+
+// File stores each message in its own file, named <sequence>_<hash>, so that the
+// directory listing is sorted in the arrival order.
 type File struct {
 	baseDir string
 	maxAge  time.Duration
+	leases  *leases
+	seq     sequence
+	// dedupPruned is when the expired dedup markers were last removed, in Unix nanoseconds.
+	dedupPruned atomic.Int64
 }
 
+// NewFile returns the file store in the given directory. The messages expire after
+// maxAge, or never if maxAge is zero or negative.
 func NewFile(baseDir string, maxAge time.Duration) *File {
-	return &File{baseDir: baseDir, maxAge: maxAge}
+	return &File{baseDir: baseDir, maxAge: maxAge, leases: newLeases()}
 }
 
-func (f *File) Save(msg *pb.DMSMessage, receiverKey []byte) error {
+// expired returns true if the message file is older than maxAge.
+func (f *File) expired(info fs.FileInfo, now time.Time) bool {
+	return f.maxAge > 0 && now.Sub(info.ModTime()) > f.maxAge
+}
+
+func (f *File) Save(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte) error {
 	receiverKeyStr := fmt.Sprintf("%x", receiverKey)
 	fileDir := getReceiverDir(f.baseDir, receiverKeyStr)
 
@@ -28,9 +63,17 @@ func (f *File) Save(msg *pb.DMSMessage, receiverKey []byte) error {
 	if err != nil {
 		return fmt.Errorf("failed to serialize message: %w", err)
 	}
-	fileName := fmt.Sprintf("%x", sha256.Sum256(b))
+	msgID := fmt.Sprintf("%x", sha256.Sum256(b))
+	existing, err := findMessageFile(fileDir, msgID)
+	if err != nil {
+		return err
+	}
+	if existing != "" {
+		// Already saved.
+		return nil
+	}
 
-	filePath := path.Join(fileDir, fileName)
+	filePath := path.Join(fileDir, formatSequence(f.seq.next())+"_"+msgID)
 	err = os.MkdirAll(fileDir, 0770)
 	if err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
@@ -42,11 +85,81 @@ func (f *File) Save(msg *pb.DMSMessage, receiverKey []byte) error {
 	return nil
 }
 
-func (f *File) GetNext(receiverKey []byte) (*pb.DMSMessage, error) {
+// SaveOnce creates the dedup marker file before saving the message. The marker's
+// modification time is when the dedup window starts.
+func (f *File) SaveOnce(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte, dedupKey string,
+	window time.Duration) (bool, error) {
+	dedupDir := path.Join(f.baseDir, dedupDirName)
+	err := os.MkdirAll(dedupDir, 0770)
+	if err != nil {
+		return false, fmt.Errorf("failed to create directory: %w", err)
+	}
+	f.pruneDedupMarkers(dedupDir, window, time.Now())
+	// The dedup key comes from the client, hash it to get a safe file name.
+	markerPath := path.Join(dedupDir, fmt.Sprintf("%x_%x", receiverKey, sha256.Sum256([]byte(dedupKey))))
+	for {
+		marker, err := os.OpenFile(markerPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			marker.Close()
+			break
+		}
+		if !os.IsExist(err) {
+			return false, fmt.Errorf("failed to create dedup marker: %w", err)
+		}
+		info, err := os.Stat(markerPath)
+		if os.IsNotExist(err) {
+			// Removed concurrently, try again.
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if time.Since(info.ModTime()) < window {
+			return false, nil
+		}
+		// The window has passed, start a new one.
+		err = os.Remove(markerPath)
+		if err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+	}
+	err = f.Save(ctx, msg, receiverKey)
+	if err != nil {
+		// Let the client retry.
+		os.Remove(markerPath)
+		return false, err
+	}
+	return true, nil
+}
+
+// pruneDedupMarkers removes the markers whose window has passed. It's done at most once
+// per window, so the markers are kept for no longer than two windows.
+func (f *File) pruneDedupMarkers(dedupDir string, window time.Duration, now time.Time) {
+	last := f.dedupPruned.Load()
+	if now.UnixNano()-last < int64(window) || !f.dedupPruned.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	files, err := os.ReadDir(dedupDir)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil {
+			// Removed concurrently.
+			continue
+		}
+		if now.Sub(info.ModTime()) >= window {
+			os.Remove(path.Join(dedupDir, file.Name()))
+		}
+	}
+}
+
+func (f *File) GetNext(ctx context.Context, receiverKey []byte) (*pb.DMSMessage, error) {
 	receiverKeyStr := fmt.Sprintf("%x", receiverKey)
 
 	fileDir := getReceiverDir(f.baseDir, receiverKeyStr)
-	files, err := os.ReadDir(fileDir)
+	files, err := readMessageDir(fileDir)
 
 	if err != nil || len(files) == 0 {
 		// Maybe directory doesn't exist, it's fine.
@@ -60,8 +173,7 @@ func (f *File) GetNext(receiverKey []byte) (*pb.DMSMessage, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to read file info: %w", err)
 		}
-		age := now.Sub(info.ModTime())
-		if age > f.maxAge {
+		if f.expired(info, now) {
 			// Delete file if it's too old.
 			os.Remove(filePath)
 			continue
@@ -76,17 +188,24 @@ func (f *File) GetNext(receiverKey []byte) (*pb.DMSMessage, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to unmarshal message: %w", err)
 		}
+		if isExpired(msg, now) {
+			os.Remove(filePath)
+			continue
+		}
+		if !isDue(msg, now) {
+			continue
+		}
 
 		return msg, nil
 	}
 	return nil, nil
 }
 
-func (f *File) GetAll(receiverKey []byte) ([]*pb.DMSMessage, error) {
+func (f *File) GetAll(ctx context.Context, receiverKey []byte) ([]*pb.DMSMessage, error) {
 	receiverKeyStr := fmt.Sprintf("%x", receiverKey)
 
 	fileDir := getReceiverDir(f.baseDir, receiverKeyStr)
-	files, err := os.ReadDir(fileDir)
+	files, err := readMessageDir(fileDir)
 
 	if err != nil || len(files) == 0 {
 		// Maybe directory doesn't exist, it's fine.
@@ -103,8 +222,7 @@ func (f *File) GetAll(receiverKey []byte) ([]*pb.DMSMessage, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to read file info: %w", err)
 		}
-		age := now.Sub(info.ModTime())
-		if age > f.maxAge {
+		if f.expired(info, now) {
 			// Delete file if it's too old.
 			os.Remove(filePath)
 			continue
@@ -120,13 +238,109 @@ func (f *File) GetAll(receiverKey []byte) ([]*pb.DMSMessage, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to unmarshal message: %w", err)
 		}
+		if isExpired(msg, now) {
+			os.Remove(filePath)
+			continue
+		}
+		if !isDue(msg, now) {
+			continue
+		}
 		ret = append(ret, msg)
 	}
 
 	return ret, nil
 }
 
-func (f *File) Remove(msg *pb.DMSMessage, receiverKey []byte) error {
+func (f *File) List(ctx context.Context, receiverKey []byte) ([]*MessageInfo, error) {
+	receiverKeyStr := fmt.Sprintf("%x", receiverKey)
+
+	fileDir := getReceiverDir(f.baseDir, receiverKeyStr)
+	files, err := readMessageDir(fileDir)
+	if err != nil || len(files) == 0 {
+		// Maybe directory doesn't exist, it's fine.
+		return nil, nil
+	}
+
+	var infos []*MessageInfo
+	now := time.Now()
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		fileInfo, err := file.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file info: %w", err)
+		}
+		if f.expired(fileInfo, now) {
+			// Expired, it will be deleted on the next read.
+			continue
+		}
+		info := &MessageInfo{
+			ID:      messageFileID(file.Name()),
+			Size:    fileInfo.Size(),
+			Arrived: fileInfo.ModTime(),
+		}
+		if seq, ok := parseSequence(strings.Split(file.Name(), "_")[0]); ok {
+			info.Arrived = sequenceTime(seq)
+		}
+		info.Sender, err = readMessageSender(path.Join(fileDir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (f *File) Get(ctx context.Context, receiverKey []byte, msgID string) (*pb.DMSMessage, error) {
+	if err := validateDeliveryID(msgID); err != nil {
+		return nil, err
+	}
+	fileDir := getReceiverDir(f.baseDir, fmt.Sprintf("%x", receiverKey))
+	filePath, err := findMessageFile(fileDir, msgID)
+	if err != nil {
+		return nil, err
+	}
+	if filePath == "" {
+		return nil, ErrNotFound
+	}
+	b, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	msg := &pb.DMSMessage{}
+	err = proto.Unmarshal(b, msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+	return msg, nil
+}
+
+func (f *File) Delete(ctx context.Context, receiverKey []byte, msgID string) error {
+	if err := validateDeliveryID(msgID); err != nil {
+		return err
+	}
+	receiverKeyStr := fmt.Sprintf("%x", receiverKey)
+
+	fileDir := getReceiverDir(f.baseDir, receiverKeyStr)
+	filePath, err := findMessageFile(fileDir, msgID)
+	if err != nil {
+		return err
+	}
+	if filePath != "" {
+		err = os.Remove(filePath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	f.leases.release(receiverKey, msgID)
+	return nil
+}
+
+func (f *File) Remove(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte) error {
 	receiverKeyStr := fmt.Sprintf("%x", receiverKey)
 
 	fileDir := getReceiverDir(f.baseDir, receiverKeyStr)
@@ -134,16 +348,329 @@ func (f *File) Remove(msg *pb.DMSMessage, receiverKey []byte) error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
-	fileName := fmt.Sprintf("%x", sha256.Sum256(b))
-	filePath := path.Join(fileDir, fileName)
-	err = os.Remove(filePath)
+	filePath, err := findMessageFile(fileDir, fmt.Sprintf("%x", sha256.Sum256(b)))
 	if err != nil {
 		return err
 	}
+	if filePath == "" {
+		return os.ErrNotExist
+	}
+	return os.Remove(filePath)
+}
+
+func (f *File) Lease(ctx context.Context, receiverKey []byte, timeout time.Duration) (*pb.DMSMessage, string, error) {
+	receiverKeyStr := fmt.Sprintf("%x", receiverKey)
+
+	fileDir := getReceiverDir(f.baseDir, receiverKeyStr)
+	files, err := readMessageDir(fileDir)
+
+	if err != nil || len(files) == 0 {
+		// Maybe directory doesn't exist, it's fine.
+		return nil, "", nil
+	}
+
+	now := time.Now()
+	for _, file := range files {
+		filePath := path.Join(fileDir, file.Name())
+		info, err := file.Info()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read file info: %w", err)
+		}
+		if f.expired(info, now) {
+			// Delete file if it's too old.
+			os.Remove(filePath)
+			continue
+		}
+		msgID := messageFileID(file.Name())
+		if !f.leases.acquire(receiverKey, msgID, timeout, now) {
+			continue
+		}
+		b, err := os.ReadFile(filePath)
+		if err != nil {
+			f.leases.release(receiverKey, msgID)
+			return nil, "", fmt.Errorf("failed to read file: %w", err)
+		}
+
+		msg := &pb.DMSMessage{}
+		err = proto.Unmarshal(b, msg)
+		if err != nil {
+			f.leases.release(receiverKey, msgID)
+			return nil, "", fmt.Errorf("failed to unmarshal message: %w", err)
+		}
+		if isExpired(msg, now) {
+			f.leases.release(receiverKey, msgID)
+			os.Remove(filePath)
+			continue
+		}
+		if !isDue(msg, now) {
+			f.leases.release(receiverKey, msgID)
+			continue
+		}
+
+		return msg, msgID, nil
+	}
+	return nil, "", nil
+}
+
+func (f *File) Ack(ctx context.Context, receiverKey []byte, deliveryID string) error {
+	return f.Delete(ctx, receiverKey, deliveryID)
+}
+
+func (f *File) Requeue(ctx context.Context, receiverKey []byte, deliveryID string, delay time.Duration) error {
+	if err := validateDeliveryID(deliveryID); err != nil {
+		return err
+	}
+	f.leases.delay(receiverKey, deliveryID, delay, time.Now())
 	return nil
 }
 
+func (f *File) Stats(ctx context.Context, receiverKey []byte) (*MailboxStats, error) {
+	receiverKeyStr := fmt.Sprintf("%x", receiverKey)
+
+	fileDir := getReceiverDir(f.baseDir, receiverKeyStr)
+	files, err := readMessageDir(fileDir)
+
+	stats := &MailboxStats{}
+	if err != nil || len(files) == 0 {
+		// Maybe directory doesn't exist, it's fine.
+		return stats, nil
+	}
+
+	now := time.Now()
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file info: %w", err)
+		}
+		if f.expired(info, now) {
+			// Expired, it will be deleted on the next read.
+			continue
+		}
+		stats.Count++
+		stats.Size += info.Size()
+	}
+	return stats, nil
+}
+
+func (f *File) SavePolicy(ctx context.Context, receiverKey []byte, policy *pb.Signed) error {
+	b, err := proto.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("failed to serialize policy: %w", err)
+	}
+	policyDir := path.Join(f.baseDir, policyDirName)
+	err = os.MkdirAll(policyDir, 0770)
+	if err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	// Write to a temporary file first, so that the policy is replaced atomically.
+	filePath := path.Join(policyDir, fmt.Sprintf("%x", receiverKey))
+	err = os.WriteFile(filePath+".tmp", b, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(filePath+".tmp", filePath)
+}
+
+func (f *File) GetPolicy(ctx context.Context, receiverKey []byte) (*pb.Signed, error) {
+	filePath := path.Join(f.baseDir, policyDirName, fmt.Sprintf("%x", receiverKey))
+	b, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	policy := &pb.Signed{}
+	err = proto.Unmarshal(b, policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal policy: %w", err)
+	}
+	return policy, nil
+}
+
+func (f *File) Close() error {
+	return nil
+}
+
+func (f *File) Mailboxes(ctx context.Context) ([]*MailboxInfo, error) {
+	dirs, err := filepath.Glob(path.Join(f.baseDir, "*", "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+	shortDirs, err := filepath.Glob(path.Join(f.baseDir, shortKeyDirName, "k*"))
+	if err != nil {
+		return nil, err
+	}
+	var mailboxes []*MailboxInfo
+	for _, dir := range append(dirs, shortDirs...) {
+		receiverKey, ok := f.receiverKeyFromDir(dir)
+		if !ok {
+			// Not a receiver directory.
+			continue
+		}
+		stats, err := f.Stats(ctx, receiverKey)
+		if err != nil {
+			return nil, err
+		}
+		if stats.Count == 0 {
+			continue
+		}
+		mailboxes = append(mailboxes, &MailboxInfo{ReceiverKey: receiverKey, MailboxStats: *stats})
+	}
+	return mailboxes, nil
+}
+
+// receiverKeyFromDir returns the receiver key for the directory returned by getReceiverDir.
+func (f *File) receiverKeyFromDir(dir string) ([]byte, bool) {
+	rel, err := filepath.Rel(f.baseDir, dir)
+	if err != nil {
+		return nil, false
+	}
+	rel = filepath.ToSlash(rel)
+	shortPrefix := shortKeyDirName + "/k"
+	var receiverKeyStr string
+	if strings.HasPrefix(rel, shortPrefix) {
+		receiverKeyStr = rel[len(shortPrefix):]
+	} else {
+		receiverKeyStr = strings.ReplaceAll(rel, "/", "")
+	}
+	receiverKey, err := hex.DecodeString(receiverKeyStr)
+	if err != nil || getReceiverDir(f.baseDir, receiverKeyStr) != path.Join(f.baseDir, rel) {
+		return nil, false
+	}
+	return receiverKey, true
+}
+
+func (f *File) Policies(ctx context.Context) ([][]byte, error) {
+	files, err := os.ReadDir(path.Join(f.baseDir, policyDirName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keys [][]byte
+	for _, file := range files {
+		receiverKey, err := hex.DecodeString(file.Name())
+		if err != nil {
+			// Temporary file left by an interrupted write.
+			continue
+		}
+		keys = append(keys, receiverKey)
+	}
+	return keys, nil
+}
+
+func (f *File) Purge(ctx context.Context, receiverKey []byte) (int, error) {
+	fileDir := getReceiverDir(f.baseDir, fmt.Sprintf("%x", receiverKey))
+	files, err := os.ReadDir(fileDir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	err = os.RemoveAll(fileDir)
+	if err != nil {
+		return 0, err
+	}
+	return len(files), nil
+}
+
+func (f *File) DiskSize(ctx context.Context) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(f.baseDir, func(_ string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	return size, err
+}
+
+func (f *File) CollectGarbage(ctx context.Context, discardRatio float64) (int, error) {
+	return 0, ErrNotSupported
+}
+
+// readMessageDir returns the message files, oldest first. The files saved before
+// the sequence was added to the name are considered to be the oldest.
+func readMessageDir(fileDir string) ([]os.DirEntry, error) {
+	files, err := os.ReadDir(fileDir)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return !strings.Contains(files[i].Name(), "_") && strings.Contains(files[j].Name(), "_")
+	})
+	return files, nil
+}
+
+// findMessageFile returns the path of the file which contains the message with the
+// given hash, or an empty string if there is no such file.
+func findMessageFile(fileDir string, msgID string) (string, error) {
+	files, err := os.ReadDir(fileDir)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		if messageFileID(file.Name()) == msgID {
+			return path.Join(fileDir, file.Name()), nil
+		}
+	}
+	return "", nil
+}
+
+// readMessageSender reads the sender's name from the beginning of the message file.
+func readMessageSender(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+	header := make([]byte, messageHeaderSize)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if sender := messageSender(header[:n]); sender != "" || n < messageHeaderSize {
+		return sender, nil
+	}
+	// The sender doesn't fit into the header, read the rest.
+	rest, err := io.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return messageSender(append(header, rest...)), nil
+}
+
+// messageFileID returns the message hash, which is the last part of the file name.
+func messageFileID(fileName string) string {
+	return fileName[strings.LastIndex(fileName, "_")+1:]
+}
+
+// getReceiverDir returns the directory where the receiver's messages are kept. The key
+// is split into subdirectories, so that there aren't too many entries in one directory.
 func getReceiverDir(baseDir string, receiverKey string) string {
+	if len(receiverKey) <= 10 {
+		// Otherwise the key would be the same as the parent directory of other receivers,
+		// or slicing it would panic. The prefix keeps the empty key from being the same
+		// as the short keys directory.
+		return path.Join(baseDir, shortKeyDirName, "k"+receiverKey)
+	}
 	subDir1 := receiverKey[0:6]
 	subDir2 := receiverKey[6:10]
 	rest := receiverKey[10:]
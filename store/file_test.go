@@ -2,7 +2,9 @@ package store
 
 import (
 	"bytes"
+	"context"
 	"os"
+	"path"
 	"testing"
 	"time"
 
@@ -26,6 +28,162 @@ func Test_File_GetAll(t *testing.T) {
 	testGetAll(t, store)
 }
 
+func Test_File_LeaseAck(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestFileStore()
+	assert.NoError(err)
+	defer cleanup()
+	testLeaseAck(t, store)
+}
+
+func Test_File_Stats(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestFileStore()
+	assert.NoError(err)
+	defer cleanup()
+	testStats(t, store)
+}
+
+func Test_File_DeliveryWindow(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestFileStore()
+	assert.NoError(err)
+	defer cleanup()
+	testDeliveryWindow(t, store)
+}
+
+func Test_File_SaveOnce(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestFileStore()
+	assert.NoError(err)
+	defer cleanup()
+	testSaveOnce(t, store)
+}
+
+func Test_File_ListGetDelete(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestFileStore()
+	assert.NoError(err)
+	defer cleanup()
+	testListGetDelete(t, store)
+}
+
+func Test_File_NoExpiry(t *testing.T) {
+	assert := assert.New(t)
+	store, err := Open("file", t.TempDir(), 0)
+	assert.NoError(err)
+	defer store.Close()
+	testNoExpiry(t, store)
+}
+
+func Test_File_Policy(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestFileStore()
+	assert.NoError(err)
+	defer cleanup()
+	testPolicy(t, store)
+}
+
+func Test_File_Order(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestFileStore()
+	assert.NoError(err)
+	defer cleanup()
+	testOrder(t, store)
+}
+
+func Test_File_Admin(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestFileStore()
+	assert.NoError(err)
+	defer cleanup()
+	testAdmin(t, store.(AdminStore))
+}
+
+func Test_File_ShortKey(t *testing.T) {
+	assert := assert.New(t)
+	str, cleanup, err := createTestFileStore()
+	assert.NoError(err)
+	defer cleanup()
+	ctx := context.Background()
+
+	receiverKey := bytes.Repeat([]byte{0xab}, 33)
+	assert.NoError(str.Save(ctx, &pb.DMSMessage{Content: []byte("message 1")}, receiverKey))
+	assert.NoError(str.Save(ctx, &pb.DMSMessage{Content: []byte("message 2")}, []byte("bob")))
+
+	// The short key which is the prefix of the real one doesn't affect its mailbox.
+	count, err := str.(AdminStore).Purge(ctx, receiverKey[:5])
+	assert.NoError(err)
+	assert.Equal(0, count)
+	count, err = str.(AdminStore).Purge(ctx, nil)
+	assert.NoError(err)
+	assert.Equal(0, count)
+	stats, err := str.Stats(ctx, receiverKey)
+	assert.NoError(err)
+	assert.Equal(1, stats.Count)
+
+	count, err = str.(AdminStore).Purge(ctx, []byte("bob"))
+	assert.NoError(err)
+	assert.Equal(1, count)
+}
+
+func Test_File_ShortKeyMailboxes(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	str := NewFile(t.TempDir(), time.Hour)
+	receiverKeys := [][]byte{bytes.Repeat([]byte{0xab}, 33), []byte("bob"), {}}
+	for _, receiverKey := range receiverKeys {
+		assert.NoError(str.Save(ctx, &pb.DMSMessage{Content: []byte("hello there")}, receiverKey))
+	}
+
+	// The mailboxes with the short keys are listed and copied, too.
+	mailboxes, err := str.Mailboxes(ctx)
+	assert.NoError(err)
+	var listed [][]byte
+	for _, mailbox := range mailboxes {
+		listed = append(listed, mailbox.ReceiverKey)
+	}
+	assert.ElementsMatch(receiverKeys, listed)
+
+	dst := NewMemory()
+	stats, err := Copy(ctx, str, dst)
+	assert.NoError(err)
+	assert.Equal(3, stats.Messages)
+	for _, receiverKey := range receiverKeys {
+		msg, err := dst.GetNext(ctx, receiverKey)
+		assert.NoError(err)
+		assert.NotNil(msg)
+	}
+}
+
+func Test_File_DedupMarkers(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	str := NewFile(dir, time.Hour)
+	for _, content := range []string{"message 1", "message 2"} {
+		saved, err := str.SaveOnce(ctx, &pb.DMSMessage{Content: []byte(content)}, []byte("bob"), content,
+			50*time.Millisecond)
+		assert.NoError(err)
+		assert.True(saved)
+	}
+	markers, err := os.ReadDir(path.Join(dir, dedupDirName))
+	assert.NoError(err)
+	assert.Len(markers, 2)
+
+	// The markers are removed once their window has passed.
+	time.Sleep(100 * time.Millisecond)
+	saved, err := str.SaveOnce(ctx, &pb.DMSMessage{Content: []byte("message 3")}, []byte("bob"), "message 3",
+		50*time.Millisecond)
+	assert.NoError(err)
+	assert.True(saved)
+	markers, err = os.ReadDir(path.Join(dir, dedupDirName))
+	assert.NoError(err)
+	assert.Len(markers, 1)
+}
+
 func containsMessage(messages []*pb.DMSMessage, message *pb.DMSMessage) bool {
 	for _, m := range messages {
 		if bytes.Equal(m.Content, message.GetContent()) {
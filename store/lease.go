@@ -0,0 +1,76 @@
+package store
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Expired leases are cleaned up once we have this many.
+const leasePruneThreshold = 1000
+
+// leases keeps track of the messages that were handed out, but not yet acknowledged.
+// Leases are kept in memory only - if the server restarts, all leased messages become
+// available again, which might result in duplicate delivery, but never in a lost message.
+type leases struct {
+	mu         sync.Mutex
+	expiration map[string]time.Time
+}
+
+func newLeases() *leases {
+	return &leases{expiration: make(map[string]time.Time)}
+}
+
+// acquire leases the message until now + timeout. It returns false if the message
+// is already leased.
+func (l *leases) acquire(receiverKey []byte, msgID string, timeout time.Duration, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.expiration) >= leasePruneThreshold {
+		l.prune(now)
+	}
+	key := leaseKey(receiverKey, msgID)
+	if exp, ok := l.expiration[key]; ok && now.Before(exp) {
+		return false
+	}
+	l.expiration[key] = now.Add(timeout)
+	return true
+}
+
+// release removes the lease.
+func (l *leases) release(receiverKey []byte, msgID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.expiration, leaseKey(receiverKey, msgID))
+}
+
+// delay makes the message unavailable until now + delay.
+func (l *leases) delay(receiverKey []byte, msgID string, delay time.Duration, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.expiration[leaseKey(receiverKey, msgID)] = now.Add(delay)
+}
+
+func (l *leases) prune(now time.Time) {
+	for key, exp := range l.expiration {
+		if !now.Before(exp) {
+			delete(l.expiration, key)
+		}
+	}
+}
+
+func leaseKey(receiverKey []byte, msgID string) string {
+	return fmt.Sprintf("%x_%s", receiverKey, msgID)
+}
+
+// validateDeliveryID makes sure the delivery ID looks like a message hash. This
+// is important, since the ID might end up being a part of the file path.
+func validateDeliveryID(deliveryID string) error {
+	b, err := hex.DecodeString(deliveryID)
+	if err != nil || len(b) != 32 {
+		return ErrInvalidDeliveryID
+	}
+	return nil
+}
@@ -1,71 +1,437 @@
 package store
 
 import (
+	"bytes"
+	"container/list"
+	"context"
 	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/regnull/ubikom/pb"
 	"google.golang.org/protobuf/proto"
 )
 
+// Expired dedup entries are cleaned up once we have this many.
+const dedupPruneThreshold = 1000
+
+// MemoryOptions configures the in-memory store. Zero means no limit.
+type MemoryOptions struct {
+	// TTL is how long the messages are kept.
+	TTL time.Duration
+
+	// MaxMailboxMessages is the max number of messages kept for each receiver. When
+	// it's reached, the receiver's oldest message is evicted to make room.
+	MaxMailboxMessages int
+
+	// MaxMessages is the max total number of messages. When it's reached, the oldest
+	// message is evicted to make room, no matter whose it is.
+	MaxMessages int
+
+	// MaxBytes is the max total size of messages, evicted the same way as above.
+	MaxBytes int64
+}
+
+// MemoryStore keeps the messages in memory. It is safe for concurrent use.
 type MemoryStore struct {
-	data map[string]map[string]*pb.DMSMessage
+	mu       sync.Mutex
+	opts     MemoryOptions
+	data     map[string]*memoryMailbox
+	policies map[string]*pb.Signed
+	dedup    map[string]time.Time
+	leases   *leases
+	seq      sequence
+	// byAge lists all the messages, oldest first.
+	byAge *list.List
+	size  int64
+}
+
+// memoryMailbox is the receiver's messages, by hash and in the order of arrival.
+type memoryMailbox struct {
+	entries map[string]*memoryEntry
+	// order lists the receiver's messages, oldest first.
+	order *list.List
+}
+
+// memoryEntry is the stored message along with its arrival sequence number.
+type memoryEntry struct {
+	receiverKeyStr string
+	msgID          string
+	msg            *pb.DMSMessage
+	seq            uint64
+	size           int64
+	// expires is when the TTL runs out, zero if there's no TTL.
+	expires time.Time
+	// elem is the entry in byAge, mailboxElem is the entry in the mailbox order.
+	elem        *list.Element
+	mailboxElem *list.Element
+}
+
+func (e *memoryEntry) isExpired(now time.Time) bool {
+	return isExpired(e.msg, now) || (!e.expires.IsZero() && !now.Before(e.expires))
+}
+
+func NewMemory() *MemoryStore {
+	return NewMemoryWithOptions(MemoryOptions{})
 }
 
-func NewMemory() Store {
+func NewMemoryWithOptions(opts MemoryOptions) *MemoryStore {
 	return &MemoryStore{
-		data: make(map[string]map[string]*pb.DMSMessage),
+		opts:     opts,
+		data:     make(map[string]*memoryMailbox),
+		policies: make(map[string]*pb.Signed),
+		dedup:    make(map[string]time.Time),
+		leases:   newLeases(),
+		byAge:    list.New(),
+	}
+}
+
+func (s *MemoryStore) Save(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.save(msg, fmt.Sprintf("%x", receiverKey), time.Now())
+	return nil
+}
+
+func (s *MemoryStore) save(msg *pb.DMSMessage, receiverKeyStr string, now time.Time) {
+	s.purgeTTL(now)
+	msgID := messageHash(msg)
+	if _, ok := s.entry(receiverKeyStr, msgID); ok {
+		// Already saved.
+		return
+	}
+	entry := &memoryEntry{
+		receiverKeyStr: receiverKeyStr,
+		msgID:          msgID,
+		msg:            msg,
+		seq:            s.seq.next(),
+		size:           int64(proto.Size(msg)),
+	}
+	if s.opts.TTL > 0 {
+		entry.expires = now.Add(s.opts.TTL)
+	}
+	s.evict(entry)
+	mailbox := s.data[receiverKeyStr]
+	if mailbox == nil {
+		mailbox = &memoryMailbox{entries: make(map[string]*memoryEntry), order: list.New()}
+		s.data[receiverKeyStr] = mailbox
+	}
+	mailbox.entries[msgID] = entry
+	entry.mailboxElem = mailbox.order.PushBack(entry)
+	entry.elem = s.byAge.PushBack(entry)
+	s.size += entry.size
+}
+
+func (s *MemoryStore) SaveOnce(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte, dedupKey string,
+	window time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	key := fmt.Sprintf("%x_%s", receiverKey, dedupKey)
+	if expires, ok := s.dedup[key]; ok && now.Before(expires) {
+		return false, nil
 	}
+	if len(s.dedup) >= dedupPruneThreshold {
+		for k, expires := range s.dedup {
+			if !now.Before(expires) {
+				delete(s.dedup, k)
+			}
+		}
+	}
+	s.dedup[key] = now.Add(window)
+	s.save(msg, fmt.Sprintf("%x", receiverKey), now)
+	return true, nil
 }
 
-func (s *MemoryStore) Save(msg *pb.DMSMessage, receiverKey []byte) error {
+func (s *MemoryStore) GetNext(ctx context.Context, receiverKey []byte) (*pb.DMSMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	receiverKeyStr := fmt.Sprintf("%x", receiverKey)
-	if s.data[receiverKeyStr] == nil {
-		s.data[receiverKeyStr] = make(map[string]*pb.DMSMessage)
+	now := time.Now()
+	s.purgeExpired(receiverKeyStr, now)
+	for _, entry := range s.orderedEntries(receiverKeyStr) {
+		if isDue(entry.msg, now) {
+			return entry.msg, nil
+		}
 	}
-	s.data[receiverKeyStr][messageHash(msg)] = msg
-	return nil
+	return nil, nil
 }
 
-func (s *MemoryStore) GetNext(receiverKey []byte) (*pb.DMSMessage, error) {
+func (s *MemoryStore) GetAll(ctx context.Context, receiverKey []byte) ([]*pb.DMSMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	receiverKeyStr := fmt.Sprintf("%x", receiverKey)
-	if s.data[receiverKeyStr] == nil {
-		return nil, nil
+	now := time.Now()
+	s.purgeExpired(receiverKeyStr, now)
+	var ret []*pb.DMSMessage
+	for _, entry := range s.orderedEntries(receiverKeyStr) {
+		if isDue(entry.msg, now) {
+			ret = append(ret, entry.msg)
+		}
 	}
-	if len(s.data[receiverKeyStr]) == 0 {
-		return nil, nil
+	return ret, nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, receiverKey []byte) ([]*MessageInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	receiverKeyStr := fmt.Sprintf("%x", receiverKey)
+	s.purgeExpired(receiverKeyStr, time.Now())
+	var infos []*MessageInfo
+	for _, entry := range s.orderedEntries(receiverKeyStr) {
+		infos = append(infos, &MessageInfo{
+			ID:      entry.msgID,
+			Size:    entry.size,
+			Arrived: sequenceTime(entry.seq),
+			Sender:  entry.msg.GetSender(),
+		})
 	}
-	for _, msg := range s.data[receiverKeyStr] {
-		return msg, nil
+	return infos, nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, receiverKey []byte, msgID string) (*pb.DMSMessage, error) {
+	if err := validateDeliveryID(msgID); err != nil {
+		return nil, err
 	}
-	return nil, nil
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entry(fmt.Sprintf("%x", receiverKey), msgID)
+	if !ok || entry.isExpired(time.Now()) {
+		return nil, ErrNotFound
+	}
+	return entry.msg, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, receiverKey []byte, msgID string) error {
+	if err := validateDeliveryID(msgID); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(fmt.Sprintf("%x", receiverKey), msgID)
+	return nil
 }
 
-func (s *MemoryStore) GetAll(receiverKey []byte) ([]*pb.DMSMessage, error) {
+func (s *MemoryStore) Remove(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(fmt.Sprintf("%x", receiverKey), messageHash(msg))
+	return nil
+}
+
+func (s *MemoryStore) Lease(ctx context.Context, receiverKey []byte, timeout time.Duration) (*pb.DMSMessage, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	receiverKeyStr := fmt.Sprintf("%x", receiverKey)
-	if s.data[receiverKeyStr] == nil {
-		return nil, nil
+	now := time.Now()
+	s.purgeExpired(receiverKeyStr, now)
+	for _, entry := range s.orderedEntries(receiverKeyStr) {
+		if isDue(entry.msg, now) && s.leases.acquire(receiverKey, entry.msgID, timeout, now) {
+			return entry.msg, entry.msgID, nil
+		}
 	}
-	if len(s.data[receiverKeyStr]) == 0 {
-		return nil, nil
+	return nil, "", nil
+}
+
+func (s *MemoryStore) Ack(ctx context.Context, receiverKey []byte, deliveryID string) error {
+	return s.Delete(ctx, receiverKey, deliveryID)
+}
+
+func (s *MemoryStore) Requeue(ctx context.Context, receiverKey []byte, deliveryID string, delay time.Duration) error {
+	if err := validateDeliveryID(deliveryID); err != nil {
+		return err
 	}
-	var ret []*pb.DMSMessage
-	for _, msg := range s.data[receiverKeyStr] {
-		ret = append(ret, msg)
+	s.leases.delay(receiverKey, deliveryID, delay, time.Now())
+	return nil
+}
+
+func (s *MemoryStore) Stats(ctx context.Context, receiverKey []byte) (*MailboxStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stats(fmt.Sprintf("%x", receiverKey), time.Now()), nil
+}
+
+func (s *MemoryStore) stats(receiverKeyStr string, now time.Time) *MailboxStats {
+	s.purgeExpired(receiverKeyStr, now)
+	stats := &MailboxStats{}
+	if mailbox := s.data[receiverKeyStr]; mailbox != nil {
+		for _, entry := range mailbox.entries {
+			stats.Count++
+			stats.Size += entry.size
+		}
 	}
-	return ret, nil
+	return stats
+}
+
+func (s *MemoryStore) SavePolicy(ctx context.Context, receiverKey []byte, policy *pb.Signed) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.policies[fmt.Sprintf("%x", receiverKey)] = policy
+	return nil
+}
+
+func (s *MemoryStore) GetPolicy(ctx context.Context, receiverKey []byte) (*pb.Signed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.policies[fmt.Sprintf("%x", receiverKey)], nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+func (s *MemoryStore) Mailboxes(ctx context.Context) ([]*MailboxInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var mailboxes []*MailboxInfo
+	for receiverKeyStr := range s.data {
+		receiverKey, err := hex.DecodeString(receiverKeyStr)
+		if err != nil {
+			continue
+		}
+		stats := s.stats(receiverKeyStr, now)
+		if stats.Count == 0 {
+			continue
+		}
+		mailboxes = append(mailboxes, &MailboxInfo{ReceiverKey: receiverKey, MailboxStats: *stats})
+	}
+	sort.Slice(mailboxes, func(i, j int) bool {
+		return bytes.Compare(mailboxes[i].ReceiverKey, mailboxes[j].ReceiverKey) < 0
+	})
+	return mailboxes, nil
+}
+
+func (s *MemoryStore) Policies(ctx context.Context) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys [][]byte
+	for receiverKeyStr := range s.policies {
+		receiverKey, err := hex.DecodeString(receiverKeyStr)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, receiverKey)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+	return keys, nil
 }
 
-func (s *MemoryStore) Remove(msg *pb.DMSMessage, receiverKey []byte) error {
+func (s *MemoryStore) Purge(ctx context.Context, receiverKey []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	receiverKeyStr := fmt.Sprintf("%x", receiverKey)
-	if s.data[receiverKeyStr] == nil {
-		return nil
+	entries := s.orderedEntries(receiverKeyStr)
+	for _, entry := range entries {
+		s.remove(receiverKeyStr, entry.msgID)
+	}
+	return len(entries), nil
+}
+
+func (s *MemoryStore) DiskSize(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (s *MemoryStore) CollectGarbage(ctx context.Context, discardRatio float64) (int, error) {
+	return 0, ErrNotSupported
+}
+
+// remove removes the message and its lease, if they exist.
+func (s *MemoryStore) remove(receiverKeyStr string, msgID string) {
+	receiverKey, _ := hex.DecodeString(receiverKeyStr)
+	s.leases.release(receiverKey, msgID)
+	entry, ok := s.entry(receiverKeyStr, msgID)
+	if !ok {
+		return
+	}
+	mailbox := s.data[receiverKeyStr]
+	delete(mailbox.entries, msgID)
+	mailbox.order.Remove(entry.mailboxElem)
+	if mailbox.order.Len() == 0 {
+		delete(s.data, receiverKeyStr)
+	}
+	s.byAge.Remove(entry.elem)
+	s.size -= entry.size
+}
+
+// evict removes the oldest messages until the new entry fits within the limits.
+func (s *MemoryStore) evict(entry *memoryEntry) {
+	if mailbox := s.data[entry.receiverKeyStr]; mailbox != nil && s.opts.MaxMailboxMessages > 0 {
+		for mailbox.order.Len() >= s.opts.MaxMailboxMessages {
+			oldest := mailbox.order.Front().Value.(*memoryEntry)
+			s.remove(oldest.receiverKeyStr, oldest.msgID)
+		}
+	}
+	for s.byAge.Len() > 0 &&
+		((s.opts.MaxMessages > 0 && s.byAge.Len() >= s.opts.MaxMessages) ||
+			(s.opts.MaxBytes > 0 && s.size+entry.size > s.opts.MaxBytes)) {
+		oldest := s.byAge.Front().Value.(*memoryEntry)
+		s.remove(oldest.receiverKeyStr, oldest.msgID)
 	}
-	if len(s.data[receiverKeyStr]) == 0 {
+}
+
+// purgeTTL removes the messages whose TTL has run out. The TTL is the same for
+// all the messages, so they expire in the order of arrival.
+func (s *MemoryStore) purgeTTL(now time.Time) {
+	for s.byAge.Len() > 0 {
+		oldest := s.byAge.Front().Value.(*memoryEntry)
+		if oldest.expires.IsZero() || now.Before(oldest.expires) {
+			return
+		}
+		s.remove(oldest.receiverKeyStr, oldest.msgID)
+	}
+}
+
+// purgeExpired removes the receiver's messages that have expired.
+func (s *MemoryStore) purgeExpired(receiverKeyStr string, now time.Time) {
+	for _, entry := range s.orderedEntries(receiverKeyStr) {
+		if entry.isExpired(now) {
+			s.remove(receiverKeyStr, entry.msgID)
+		}
+	}
+}
+
+// entry returns the receiver's message with the given hash.
+func (s *MemoryStore) entry(receiverKeyStr string, msgID string) (*memoryEntry, bool) {
+	mailbox := s.data[receiverKeyStr]
+	if mailbox == nil {
+		return nil, false
+	}
+	entry, ok := mailbox.entries[msgID]
+	return entry, ok
+}
+
+// orderedEntries returns the receiver's messages, oldest first.
+func (s *MemoryStore) orderedEntries(receiverKeyStr string) []*memoryEntry {
+	mailbox := s.data[receiverKeyStr]
+	if mailbox == nil {
 		return nil
 	}
-	delete(s.data[receiverKeyStr], messageHash(msg))
-	return nil
+	entries := make([]*memoryEntry, 0, mailbox.order.Len())
+	for e := mailbox.order.Front(); e != nil; e = e.Next() {
+		entries = append(entries, e.Value.(*memoryEntry))
+	}
+	return entries
 }
 
 func messageHash(msg *pb.DMSMessage) string {
@@ -1,15 +1,20 @@
 package store
 
 import (
+	"context"
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/regnull/ubikom/pb"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
 )
 
 func Test_Memory_SaveGetNext(t *testing.T) {
 	assert := assert.New(t)
+	ctx := context.Background()
 
 	msg := &pb.DMSMessage{
 		Sender:   "alice",
@@ -17,16 +22,17 @@ func Test_Memory_SaveGetNext(t *testing.T) {
 		Content:  []byte("the message"),
 	}
 	store := NewMemory()
-	err := store.Save(msg, []byte("123"))
+	err := store.Save(ctx, msg, []byte("123"))
 	assert.NoError(err)
 
-	msg1, err := store.GetNext([]byte("123"))
+	msg1, err := store.GetNext(ctx, []byte("123"))
 	assert.NoError(err)
 	assert.Equal(msg, msg1)
 }
 
 func Test_Memory_GetAll(t *testing.T) {
 	assert := assert.New(t)
+	ctx := context.Background()
 
 	store := NewMemory()
 	var expectedMsgs []*pb.DMSMessage
@@ -36,12 +42,12 @@ func Test_Memory_GetAll(t *testing.T) {
 			Receiver: "bob",
 			Content:  []byte(fmt.Sprintf("message%d", i)),
 		}
-		err := store.Save(msg, []byte("123"))
+		err := store.Save(ctx, msg, []byte("123"))
 		assert.NoError(err)
 		expectedMsgs = append(expectedMsgs, msg)
 	}
 
-	msgs, err := store.GetAll([]byte("123"))
+	msgs, err := store.GetAll(ctx, []byte("123"))
 	assert.NoError(err)
 	assert.True(len(msgs) == 10)
 	for _, msg := range msgs {
@@ -51,6 +57,7 @@ func Test_Memory_GetAll(t *testing.T) {
 
 func Test_Memory_Remove(t *testing.T) {
 	assert := assert.New(t)
+	ctx := context.Background()
 
 	store := NewMemory()
 	for i := 0; i < 10; i++ {
@@ -59,20 +66,211 @@ func Test_Memory_Remove(t *testing.T) {
 			Receiver: "bob",
 			Content:  []byte(fmt.Sprintf("message%d", i)),
 		}
-		err := store.Save(msg, []byte("123"))
+		err := store.Save(ctx, msg, []byte("123"))
 		assert.NoError(err)
 	}
 
-	msgs, err := store.GetAll([]byte("123"))
+	msgs, err := store.GetAll(ctx, []byte("123"))
 	assert.NoError(err)
 	assert.True(len(msgs) == 10)
 
 	for i := 0; i < 10; i++ {
-		err := store.Remove(msgs[0], []byte("123"))
+		err := store.Remove(ctx, msgs[0], []byte("123"))
 		msgs = msgs[1:]
 		assert.NoError(err)
-		msgs1, err := store.GetAll([]byte("123"))
+		msgs1, err := store.GetAll(ctx, []byte("123"))
 		assert.NoError(err)
 		assert.True(len(msgs1) == 9-i)
 	}
 }
+
+func Test_Memory_LeaseAck(t *testing.T) {
+	testLeaseAck(t, NewMemory())
+}
+
+func Test_Memory_Stats(t *testing.T) {
+	testStats(t, NewMemory())
+}
+
+func Test_Memory_DeliveryWindow(t *testing.T) {
+	testDeliveryWindow(t, NewMemory())
+}
+
+func Test_Memory_SaveOnce(t *testing.T) {
+	testSaveOnce(t, NewMemory())
+}
+
+func Test_Memory_ListGetDelete(t *testing.T) {
+	testListGetDelete(t, NewMemory())
+}
+
+func Test_Memory_NoExpiry(t *testing.T) {
+	testNoExpiry(t, NewMemory())
+}
+
+func Test_Memory_Policy(t *testing.T) {
+	testPolicy(t, NewMemory())
+}
+
+func Test_Memory_Order(t *testing.T) {
+	testOrder(t, NewMemory())
+}
+
+func Test_Memory_Admin(t *testing.T) {
+	testAdmin(t, NewMemory())
+}
+
+func Test_Memory_TTL(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	store := NewMemoryWithOptions(MemoryOptions{TTL: 50 * time.Millisecond})
+	msg := &pb.DMSMessage{Sender: "alice", Receiver: "bob", Content: []byte("the message")}
+	assert.NoError(store.Save(ctx, msg, []byte("123")))
+	msg1, err := store.GetNext(ctx, []byte("123"))
+	assert.NoError(err)
+	assert.Equal(msg, msg1)
+
+	time.Sleep(60 * time.Millisecond)
+	msg1, err = store.GetNext(ctx, []byte("123"))
+	assert.NoError(err)
+	assert.Nil(msg1)
+	mailboxes, err := store.Mailboxes(ctx)
+	assert.NoError(err)
+	assert.Empty(mailboxes)
+}
+
+func Test_Memory_MaxMailboxMessages(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	store := NewMemoryWithOptions(MemoryOptions{MaxMailboxMessages: 3})
+	for i := 0; i < 5; i++ {
+		msg := &pb.DMSMessage{Sender: "alice", Receiver: "bob", Content: []byte(fmt.Sprintf("message%d", i))}
+		assert.NoError(store.Save(ctx, msg, []byte("123")))
+	}
+	assert.NoError(store.Save(ctx, &pb.DMSMessage{Content: []byte("other")}, []byte("456")))
+
+	// The oldest messages are evicted.
+	msgs, err := store.GetAll(ctx, []byte("123"))
+	assert.NoError(err)
+	if assert.Len(msgs, 3) {
+		for i, msg := range msgs {
+			assert.Equal([]byte(fmt.Sprintf("message%d", i+2)), msg.GetContent())
+		}
+	}
+	stats, err := store.Stats(ctx, []byte("456"))
+	assert.NoError(err)
+	assert.Equal(1, stats.Count)
+}
+
+func Test_Memory_MaxMessages(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	store := NewMemoryWithOptions(MemoryOptions{MaxMessages: 3})
+	for i := 0; i < 4; i++ {
+		msg := &pb.DMSMessage{Sender: "alice", Receiver: "bob", Content: []byte(fmt.Sprintf("message%d", i))}
+		assert.NoError(store.Save(ctx, msg, []byte(fmt.Sprintf("%d", i%2))))
+	}
+
+	// The oldest message is evicted, no matter whose it is.
+	msgs, err := store.GetAll(ctx, []byte("0"))
+	assert.NoError(err)
+	if assert.Len(msgs, 1) {
+		assert.Equal([]byte("message2"), msgs[0].GetContent())
+	}
+	msgs, err = store.GetAll(ctx, []byte("1"))
+	assert.NoError(err)
+	assert.Len(msgs, 2)
+}
+
+func Test_Memory_MaxBytes(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	msg := &pb.DMSMessage{Content: []byte("message0")}
+	store := NewMemoryWithOptions(MemoryOptions{MaxBytes: int64(2 * proto.Size(msg))})
+	for i := 0; i < 3; i++ {
+		msg := &pb.DMSMessage{Content: []byte(fmt.Sprintf("message%d", i))}
+		assert.NoError(store.Save(ctx, msg, []byte("123")))
+	}
+
+	stats, err := store.Stats(ctx, []byte("123"))
+	assert.NoError(err)
+	assert.Equal(2, stats.Count)
+	assert.Equal(int64(2*proto.Size(msg)), stats.Size)
+	_, err = store.Get(ctx, []byte("123"), messageHash(msg))
+	assert.Equal(ErrNotFound, err)
+}
+
+func Test_Memory_RemoveReleasesLease(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	store := NewMemoryWithOptions(MemoryOptions{MaxMessages: 1})
+	key := []byte("123")
+	msg := &pb.DMSMessage{Sender: "alice", Receiver: "bob", Content: []byte("hello")}
+	other := &pb.DMSMessage{Sender: "alice", Receiver: "bob", Content: []byte("hello again")}
+
+	// Removed, purged or evicted messages can be leased again once they are saved again.
+	remove := map[string]func(){
+		"remove": func() { assert.NoError(store.Remove(ctx, msg, key)) },
+		"purge": func() {
+			_, err := store.Purge(ctx, key)
+			assert.NoError(err)
+		},
+		"evict": func() {
+			assert.NoError(store.Save(ctx, other, key))
+			assert.NoError(store.Remove(ctx, other, key))
+		},
+	}
+	for name, f := range remove {
+		assert.NoError(store.Save(ctx, msg, key))
+		leased, _, err := store.Lease(ctx, key, time.Hour)
+		assert.NoError(err)
+		assert.True(proto.Equal(msg, leased), name)
+
+		f()
+		assert.NoError(store.Save(ctx, msg, key))
+		leased, deliveryID, err := store.Lease(ctx, key, time.Hour)
+		assert.NoError(err)
+		assert.True(proto.Equal(msg, leased), name)
+		assert.NoError(store.Ack(ctx, key, deliveryID))
+	}
+}
+
+func Test_Memory_Concurrent(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	store := NewMemoryWithOptions(MemoryOptions{MaxMessages: 50})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			receiverKey := []byte(fmt.Sprintf("%d", i%3))
+			for j := 0; j < 100; j++ {
+				msg := &pb.DMSMessage{Content: []byte(fmt.Sprintf("message%d-%d", i, j))}
+				assert.NoError(store.Save(ctx, msg, receiverKey))
+				leased, deliveryID, err := store.Lease(ctx, receiverKey, time.Minute)
+				assert.NoError(err)
+				if leased != nil {
+					assert.NoError(store.Ack(ctx, receiverKey, deliveryID))
+				}
+				_, err = store.Mailboxes(ctx)
+				assert.NoError(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	mailboxes, err := store.Mailboxes(ctx)
+	assert.NoError(err)
+	count := 0
+	for _, mailbox := range mailboxes {
+		count += mailbox.Count
+	}
+	assert.True(count <= 50)
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// senderFieldNumber is the field number of DMSMessage.sender.
+const senderFieldNumber = 1
+
+// messageSender returns the sender's name from the serialized message, without
+// unmarshalling the rest of it. The fields are serialized in the field number order,
+// so the sender comes first. An empty string is returned if the message is truncated.
+func messageSender(b []byte) string {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return ""
+		}
+		b = b[n:]
+		if num == senderFieldNumber && typ == protowire.BytesType {
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return ""
+			}
+			return string(v)
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return ""
+		}
+		b = b[n:]
+	}
+	return ""
+}
+
+// sequenceTime returns the time when the message with this sequence number arrived.
+func sequenceTime(seq uint64) time.Time {
+	return time.Unix(0, int64(seq))
+}
+
+// parseSequence parses the sequence number formatted by formatSequence.
+func parseSequence(s string) (uint64, bool) {
+	if len(s) != 16 {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, false
+	}
+	return seq, true
+}
@@ -0,0 +1,34 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"time"
+)
+
+// Open opens the message store of the given kind, badger, sqlite or file, in the
+// data directory. The messages expire after ttl, or never if ttl is zero or negative.
+func Open(kind string, dataDir string, ttl time.Duration) (AdminStore, error) {
+	switch kind {
+	case "badger":
+		badgerStore, err := NewBadger(dataDir, ttl)
+		if err != nil {
+			return nil, err
+		}
+		return badgerStore, nil
+	case "sqlite":
+		err := os.MkdirAll(dataDir, 0770)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create data directory: %w", err)
+		}
+		sqliteStore, err := NewSQLite(path.Join(dataDir, "messages.db"), ttl)
+		if err != nil {
+			return nil, err
+		}
+		return sqliteStore, nil
+	case "file":
+		return NewFile(dataDir, ttl), nil
+	}
+	return nil, fmt.Errorf("unknown store: %s", kind)
+}
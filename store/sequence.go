@@ -0,0 +1,33 @@
+package store
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// sequence generates the arrival sequence numbers, which define the delivery order.
+// The numbers are derived from the current time, so they keep increasing across
+// restarts (unless the clock goes back), and they never repeat within the process.
+type sequence struct {
+	mu   sync.Mutex
+	last uint64
+}
+
+func (s *sequence) next() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := uint64(time.Now().UnixNano())
+	if n <= s.last {
+		n = s.last + 1
+	}
+	s.last = n
+	return n
+}
+
+// formatSequence formats the sequence number so that the strings sort in the same
+// order as the numbers.
+func formatSequence(seq uint64) string {
+	return fmt.Sprintf("%016x", seq)
+}
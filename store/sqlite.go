@@ -0,0 +1,462 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/regnull/ubikom/pb"
+	"google.golang.org/protobuf/proto"
+	_ "modernc.org/sqlite"
+)
+
+// sqlitePurgeInterval is how often the expired messages are deleted.
+const sqlitePurgeInterval = time.Minute
+
+// sqliteNoExpiry is the expiration time of the messages which never expire.
+var sqliteNoExpiry = time.Unix(0, math.MaxInt64)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS messages (
+	receiver   BLOB NOT NULL,
+	id         TEXT NOT NULL,
+	seq        INTEGER NOT NULL,
+	sender     TEXT NOT NULL,
+	size       INTEGER NOT NULL,
+	not_before INTEGER NOT NULL,
+	expires    INTEGER NOT NULL,
+	content    BLOB NOT NULL,
+	PRIMARY KEY (receiver, id)
+);
+CREATE INDEX IF NOT EXISTS messages_arrival ON messages (receiver, seq);
+CREATE INDEX IF NOT EXISTS messages_expires ON messages (expires);
+CREATE TABLE IF NOT EXISTS dedup (
+	receiver  BLOB NOT NULL,
+	dedup_key TEXT NOT NULL,
+	expires   INTEGER NOT NULL,
+	PRIMARY KEY (receiver, dedup_key)
+);
+CREATE TABLE IF NOT EXISTS policies (
+	receiver BLOB PRIMARY KEY,
+	policy   BLOB NOT NULL
+);
+`
+
+// SQLite stores the messages in a single SQLite database file. The times are stored
+// as Unix nanoseconds, the arrival sequence defines the delivery order. The expired
+// messages are skipped by the queries, and deleted periodically.
+type SQLite struct {
+	db        *sql.DB
+	path      string
+	ttl       time.Duration
+	leases    *leases
+	seq       sequence
+	mu        sync.Mutex
+	lastPurge time.Time
+}
+
+// NewSQLite opens the SQLite store at the given path. The messages expire after ttl,
+// or never if ttl is zero or negative.
+func NewSQLite(path string, ttl time.Duration) (*SQLite, error) {
+	// Write transactions take the lock right away, otherwise concurrent transactions
+	// which read first fail instead of waiting for each other.
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	_, err = db.Exec(sqliteSchema)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create database schema: %w", err)
+	}
+	s := &SQLite{db: db, path: path, ttl: ttl, leases: newLeases()}
+	err = s.purgeExpired(context.Background(), time.Now())
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *SQLite) Save(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte) error {
+	_, err := s.save(ctx, msg, receiverKey, "", 0)
+	return err
+}
+
+func (s *SQLite) SaveOnce(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte, dedupKey string,
+	window time.Duration) (bool, error) {
+	return s.save(ctx, msg, receiverKey, dedupKey, window)
+}
+
+// save saves the message. If dedupKey is not empty, the dedup entry is written in the
+// same transaction, and the message is not saved if the entry already exists.
+func (s *SQLite) save(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte, dedupKey string,
+	window time.Duration) (bool, error) {
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return false, fmt.Errorf("failed to serialize message: %w", err)
+	}
+	msgID := fmt.Sprintf("%x", sha256.Sum256(b))
+
+	now := time.Now()
+	s.maybePurge(ctx, now)
+	expires := sqliteNoExpiry
+	if s.ttl > 0 {
+		expires = now.Add(s.ttl)
+	}
+	notBefore, deliveryExpires := deliveryWindow(msg)
+	if !deliveryExpires.IsZero() {
+		if !deliveryExpires.After(now) {
+			// Already expired, nothing to save.
+			return true, nil
+		}
+		if deliveryExpires.Before(expires) {
+			expires = deliveryExpires
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	if dedupKey != "" {
+		var dedupExpires int64
+		err = tx.QueryRowContext(ctx, "SELECT expires FROM dedup WHERE receiver = ? AND dedup_key = ?",
+			receiverKey, dedupKey).Scan(&dedupExpires)
+		if err == nil && dedupExpires > now.UnixNano() {
+			return false, nil
+		}
+		if err != nil && err != sql.ErrNoRows {
+			return false, err
+		}
+		_, err = tx.ExecContext(ctx, "INSERT OR REPLACE INTO dedup (receiver, dedup_key, expires) VALUES (?, ?, ?)",
+			receiverKey, dedupKey, now.Add(window).UnixNano())
+		if err != nil {
+			return false, err
+		}
+	}
+	// The message which is already saved keeps its place in the queue.
+	_, err = tx.ExecContext(ctx, `INSERT OR IGNORE INTO messages
+		(receiver, id, seq, sender, size, not_before, expires, content) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		receiverKey, msgID, int64(s.seq.next()), msg.GetSender(), len(b), unixNano(notBefore),
+		expires.UnixNano(), b)
+	if err != nil {
+		return false, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *SQLite) GetNext(ctx context.Context, receiverKey []byte) (*pb.DMSMessage, error) {
+	now := time.Now().UnixNano()
+	var b []byte
+	err := s.db.QueryRowContext(ctx, `SELECT content FROM messages
+		WHERE receiver = ? AND expires > ? AND not_before <= ? ORDER BY seq LIMIT 1`,
+		receiverKey, now, now).Scan(&b)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalMessage(b)
+}
+
+func (s *SQLite) GetAll(ctx context.Context, receiverKey []byte) ([]*pb.DMSMessage, error) {
+	now := time.Now().UnixNano()
+	rows, err := s.db.QueryContext(ctx, `SELECT content FROM messages
+		WHERE receiver = ? AND expires > ? AND not_before <= ? ORDER BY seq`,
+		receiverKey, now, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var msgs []*pb.DMSMessage
+	for rows.Next() {
+		var b []byte
+		err = rows.Scan(&b)
+		if err != nil {
+			return nil, err
+		}
+		msg, err := unmarshalMessage(b)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, rows.Err()
+}
+
+func (s *SQLite) List(ctx context.Context, receiverKey []byte) ([]*MessageInfo, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, size, seq, sender FROM messages
+		WHERE receiver = ? AND expires > ? ORDER BY seq`,
+		receiverKey, time.Now().UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var infos []*MessageInfo
+	for rows.Next() {
+		info := &MessageInfo{}
+		var seq int64
+		err = rows.Scan(&info.ID, &info.Size, &seq, &info.Sender)
+		if err != nil {
+			return nil, err
+		}
+		info.Arrived = sequenceTime(uint64(seq))
+		infos = append(infos, info)
+	}
+	return infos, rows.Err()
+}
+
+func (s *SQLite) Get(ctx context.Context, receiverKey []byte, msgID string) (*pb.DMSMessage, error) {
+	if err := validateDeliveryID(msgID); err != nil {
+		return nil, err
+	}
+	var b []byte
+	err := s.db.QueryRowContext(ctx, "SELECT content FROM messages WHERE receiver = ? AND id = ? AND expires > ?",
+		receiverKey, msgID, time.Now().UnixNano()).Scan(&b)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalMessage(b)
+}
+
+func (s *SQLite) Delete(ctx context.Context, receiverKey []byte, msgID string) error {
+	if err := validateDeliveryID(msgID); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, "DELETE FROM messages WHERE receiver = ? AND id = ?", receiverKey, msgID)
+	if err != nil {
+		return err
+	}
+	s.leases.release(receiverKey, msgID)
+	return nil
+}
+
+func (s *SQLite) Remove(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte) error {
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to serialize message: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, "DELETE FROM messages WHERE receiver = ? AND id = ?",
+		receiverKey, fmt.Sprintf("%x", sha256.Sum256(b)))
+	return err
+}
+
+func (s *SQLite) Lease(ctx context.Context, receiverKey []byte, timeout time.Duration) (*pb.DMSMessage, string, error) {
+	now := time.Now()
+	rows, err := s.db.QueryContext(ctx, `SELECT id, content FROM messages
+		WHERE receiver = ? AND expires > ? AND not_before <= ? ORDER BY seq`,
+		receiverKey, now.UnixNano(), now.UnixNano())
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var msgID string
+		var b []byte
+		err = rows.Scan(&msgID, &b)
+		if err != nil {
+			return nil, "", err
+		}
+		if !s.leases.acquire(receiverKey, msgID, timeout, now) {
+			continue
+		}
+		msg, err := unmarshalMessage(b)
+		if err != nil {
+			s.leases.release(receiverKey, msgID)
+			return nil, "", err
+		}
+		return msg, msgID, nil
+	}
+	return nil, "", rows.Err()
+}
+
+func (s *SQLite) Ack(ctx context.Context, receiverKey []byte, deliveryID string) error {
+	return s.Delete(ctx, receiverKey, deliveryID)
+}
+
+func (s *SQLite) Requeue(ctx context.Context, receiverKey []byte, deliveryID string, delay time.Duration) error {
+	if err := validateDeliveryID(deliveryID); err != nil {
+		return err
+	}
+	s.leases.delay(receiverKey, deliveryID, delay, time.Now())
+	return nil
+}
+
+func (s *SQLite) Stats(ctx context.Context, receiverKey []byte) (*MailboxStats, error) {
+	stats := &MailboxStats{}
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*), COALESCE(SUM(size), 0) FROM messages WHERE receiver = ? AND expires > ?",
+		receiverKey, time.Now().UnixNano()).Scan(&stats.Count, &stats.Size)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (s *SQLite) SavePolicy(ctx context.Context, receiverKey []byte, policy *pb.Signed) error {
+	b, err := proto.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("failed to serialize policy: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, "INSERT OR REPLACE INTO policies (receiver, policy) VALUES (?, ?)",
+		receiverKey, b)
+	return err
+}
+
+func (s *SQLite) GetPolicy(ctx context.Context, receiverKey []byte) (*pb.Signed, error) {
+	var b []byte
+	err := s.db.QueryRowContext(ctx, "SELECT policy FROM policies WHERE receiver = ?", receiverKey).Scan(&b)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	policy := &pb.Signed{}
+	err = proto.Unmarshal(b, policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal policy: %w", err)
+	}
+	return policy, nil
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLite) Mailboxes(ctx context.Context) ([]*MailboxInfo, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT receiver, COUNT(*), SUM(size) FROM messages
+		WHERE expires > ? GROUP BY receiver ORDER BY receiver`, time.Now().UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var mailboxes []*MailboxInfo
+	for rows.Next() {
+		mailbox := &MailboxInfo{}
+		err = rows.Scan(&mailbox.ReceiverKey, &mailbox.Count, &mailbox.Size)
+		if err != nil {
+			return nil, err
+		}
+		mailboxes = append(mailboxes, mailbox)
+	}
+	return mailboxes, rows.Err()
+}
+
+func (s *SQLite) Policies(ctx context.Context) ([][]byte, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT receiver FROM policies ORDER BY receiver")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys [][]byte
+	for rows.Next() {
+		var receiverKey []byte
+		err = rows.Scan(&receiverKey)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, receiverKey)
+	}
+	return keys, rows.Err()
+}
+
+func (s *SQLite) Purge(ctx context.Context, receiverKey []byte) (int, error) {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM messages WHERE receiver = ? AND expires > ?",
+		receiverKey, time.Now().UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+func (s *SQLite) DiskSize(ctx context.Context) (int64, error) {
+	var size int64
+	for _, suffix := range []string{"", "-wal"} {
+		info, err := os.Stat(s.path + suffix)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		size += info.Size()
+	}
+	return size, nil
+}
+
+// CollectGarbage deletes the expired messages and rebuilds the database file. The
+// discard ratio is ignored, since the whole file is rewritten.
+func (s *SQLite) CollectGarbage(ctx context.Context, discardRatio float64) (int, error) {
+	err := s.purgeExpired(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	_, err = s.db.ExecContext(ctx, "VACUUM")
+	if err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// maybePurge deletes the expired messages, unless it was done recently.
+func (s *SQLite) maybePurge(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.lastPurge) < sqlitePurgeInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastPurge = now
+	s.mu.Unlock()
+	// The expired messages are not visible anyway, failing to delete them is not fatal.
+	_ = s.purgeExpired(ctx, now)
+}
+
+// purgeExpired deletes the expired messages and dedup entries.
+func (s *SQLite) purgeExpired(ctx context.Context, now time.Time) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM messages WHERE expires <= ?", now.UnixNano())
+	if err != nil {
+		return fmt.Errorf("failed to delete expired messages: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, "DELETE FROM dedup WHERE expires <= ?", now.UnixNano())
+	if err != nil {
+		return fmt.Errorf("failed to delete expired dedup entries: %w", err)
+	}
+	return nil
+}
+
+func unmarshalMessage(b []byte) (*pb.DMSMessage, error) {
+	msg := &pb.DMSMessage{}
+	err := proto.Unmarshal(b, msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+	return msg, nil
+}
+
+// unixNano returns the time as Unix nanoseconds, or zero for the zero time.
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
@@ -0,0 +1,179 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/regnull/ubikom/pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func Test_SQLite_StoreGetRemove(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestSQLiteStore()
+	assert.NoError(err)
+	defer cleanup()
+	testGetRemove(t, store)
+}
+
+func Test_SQLite_GetAll(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestSQLiteStore()
+	assert.NoError(err)
+	defer cleanup()
+	testGetAll(t, store)
+}
+
+func Test_SQLite_LeaseAck(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestSQLiteStore()
+	assert.NoError(err)
+	defer cleanup()
+	testLeaseAck(t, store)
+}
+
+func Test_SQLite_Stats(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestSQLiteStore()
+	assert.NoError(err)
+	defer cleanup()
+	testStats(t, store)
+}
+
+func Test_SQLite_DeliveryWindow(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestSQLiteStore()
+	assert.NoError(err)
+	defer cleanup()
+	testDeliveryWindow(t, store)
+}
+
+func Test_SQLite_SaveOnce(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestSQLiteStore()
+	assert.NoError(err)
+	defer cleanup()
+	testSaveOnce(t, store)
+}
+
+func Test_SQLite_ListGetDelete(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestSQLiteStore()
+	assert.NoError(err)
+	defer cleanup()
+	testListGetDelete(t, store)
+}
+
+func Test_SQLite_NoExpiry(t *testing.T) {
+	assert := assert.New(t)
+	store, err := Open("sqlite", t.TempDir(), 0)
+	assert.NoError(err)
+	defer store.Close()
+	testNoExpiry(t, store)
+}
+
+func Test_SQLite_Policy(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestSQLiteStore()
+	assert.NoError(err)
+	defer cleanup()
+	testPolicy(t, store)
+}
+
+func Test_SQLite_Order(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestSQLiteStore()
+	assert.NoError(err)
+	defer cleanup()
+	testOrder(t, store)
+}
+
+func Test_SQLite_Admin(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup, err := createTestSQLiteStore()
+	assert.NoError(err)
+	defer cleanup()
+	testAdmin(t, store.(AdminStore))
+}
+
+func Test_SQLite_Reopen(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	dbPath := path.Join(t.TempDir(), "messages.db")
+	store, err := NewSQLite(dbPath, time.Hour)
+	assert.NoError(err)
+	msg := &pb.DMSMessage{Sender: "foo", Receiver: "bar", Content: []byte("hello there")}
+	assert.NoError(store.Save(ctx, msg, []byte("123")))
+	assert.NoError(store.Close())
+
+	store, err = NewSQLite(dbPath, time.Hour)
+	assert.NoError(err)
+	defer store.Close()
+	saved, err := store.GetNext(ctx, []byte("123"))
+	assert.NoError(err)
+	assert.True(proto.Equal(msg, saved))
+}
+
+func Test_SQLite_TTL(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	store, err := NewSQLite(path.Join(t.TempDir(), "messages.db"), 100*time.Millisecond)
+	assert.NoError(err)
+	defer store.Close()
+	msg := &pb.DMSMessage{Sender: "foo", Receiver: "bar", Content: []byte("hello there")}
+	assert.NoError(store.Save(ctx, msg, []byte("123")))
+	stats, err := store.Stats(ctx, []byte("123"))
+	assert.NoError(err)
+	assert.Equal(1, stats.Count)
+
+	time.Sleep(200 * time.Millisecond)
+	saved, err := store.GetNext(ctx, []byte("123"))
+	assert.NoError(err)
+	assert.Nil(saved)
+
+	// The expired message is deleted by the garbage collection.
+	_, err = store.CollectGarbage(ctx, 0.5)
+	assert.NoError(err)
+	var count int
+	assert.NoError(store.db.QueryRow("SELECT COUNT(*) FROM messages").Scan(&count))
+	assert.Equal(0, count)
+}
+
+func Test_SQLite_NoTTL(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	store, err := NewSQLite(path.Join(t.TempDir(), "messages.db"), 0)
+	assert.NoError(err)
+	defer store.Close()
+	msg := &pb.DMSMessage{Sender: "foo", Receiver: "bar", Content: []byte("hello there")}
+	assert.NoError(store.Save(ctx, msg, []byte("123")))
+
+	_, err = store.CollectGarbage(ctx, 0.5)
+	assert.NoError(err)
+	saved, err := store.GetNext(ctx, []byte("123"))
+	assert.NoError(err)
+	assert.True(proto.Equal(msg, saved))
+}
+
+func createTestSQLiteStore() (Store, CleanupFunc, error) {
+	dir, err := os.MkdirTemp("", "ubikom_sqlitestore_test")
+	if err != nil {
+		return nil, func() {}, err
+	}
+
+	store, err := NewSQLite(path.Join(dir, "messages.db"), time.Hour)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	return store, func() {
+		store.Close()
+		os.RemoveAll(dir)
+	}, nil
+}
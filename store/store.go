@@ -1,18 +1,90 @@
 package store
 
-import "github.com/regnull/ubikom/pb"
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/regnull/ubikom/pb"
+)
+
+var (
+	ErrInvalidDeliveryID = errors.New("invalid delivery id")
+	ErrNotFound          = errors.New("message not found")
+)
+
+// MailboxStats contains the accounting information for the receiver's mailbox.
+type MailboxStats struct {
+	// Count is the number of stored messages.
+	Count int
+	// Size is the total size of the stored messages, in bytes.
+	Size int64
+}
+
+// MessageInfo is the message metadata, which is available without loading the message.
+type MessageInfo struct {
+	// ID is the message hash, which is also used as the delivery ID.
+	ID string
+	// Size is the size of the serialized message, in bytes.
+	Size int64
+	// Arrived is the time when the message was saved.
+	Arrived time.Time
+	// Sender is the sender's name.
+	Sender string
+}
 
 // Store represents local store for DMSMessages.
 type Store interface {
 	// Save saves a new message using the receiver key.
-	Save(msg *pb.DMSMessage, receiverKey []byte) error
+	Save(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte) error
+
+	// SaveOnce saves a new message, unless a message with the same dedup key was saved
+	// for this receiver within the window. It returns false if the message is a duplicate.
+	SaveOnce(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte, dedupKey string,
+		window time.Duration) (bool, error)
 
 	// Get next returns next message available for this receiver.
-	GetNext(receiver []byte) (*pb.DMSMessage, error)
+	GetNext(ctx context.Context, receiver []byte) (*pb.DMSMessage, error)
 
 	// Get all returns all messages available for this receiver.
-	GetAll(receiver []byte) ([]*pb.DMSMessage, error)
+	GetAll(ctx context.Context, receiver []byte) ([]*pb.DMSMessage, error)
+
+	// List returns the metadata of all messages stored for this receiver, oldest first,
+	// including the scheduled and the leased ones.
+	List(ctx context.Context, receiverKey []byte) ([]*MessageInfo, error)
+
+	// Get returns the message with the given ID, or ErrNotFound.
+	Get(ctx context.Context, receiverKey []byte, msgID string) (*pb.DMSMessage, error)
+
+	// Delete removes the message with the given ID. Deleting the message which doesn't
+	// exist is not an error.
+	Delete(ctx context.Context, receiverKey []byte, msgID string) error
 
 	// Remove removes the message from the local storage.
-	Remove(msg *pb.DMSMessage, receiverKey []byte) error
+	Remove(ctx context.Context, msg *pb.DMSMessage, receiverKey []byte) error
+
+	// Lease returns next message available for this receiver, along with its delivery ID.
+	// The message is not removed, but it is hidden from other Lease calls until the timeout
+	// expires, or until it's acknowledged.
+	Lease(ctx context.Context, receiverKey []byte, timeout time.Duration) (*pb.DMSMessage, string, error)
+
+	// Ack removes the message with the given delivery ID.
+	Ack(ctx context.Context, receiverKey []byte, deliveryID string) error
+
+	// Requeue hides the leased message for the given delay, after which it becomes
+	// available to Lease again.
+	Requeue(ctx context.Context, receiverKey []byte, deliveryID string, delay time.Duration) error
+
+	// Stats returns the number and the total size of messages stored for this receiver.
+	Stats(ctx context.Context, receiverKey []byte) (*MailboxStats, error)
+
+	// SavePolicy saves the receiver's signed mailbox policy, replacing the existing one.
+	SavePolicy(ctx context.Context, receiverKey []byte, policy *pb.Signed) error
+
+	// GetPolicy returns the receiver's signed mailbox policy, or nil if it's not set.
+	GetPolicy(ctx context.Context, receiverKey []byte) (*pb.Signed, error)
+
+	// Close releases the resources held by the store. The store can't be used after
+	// it's closed.
+	Close() error
 }
@@ -1,8 +1,11 @@
 package store
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/regnull/easyecc"
 	"github.com/regnull/ubikom/pb"
@@ -12,11 +15,12 @@ import (
 
 func testGetRemove(t *testing.T, store Store) {
 	assert := assert.New(t)
+	ctx := context.Background()
 
 	pk1, _ := easyecc.NewRandomPrivateKey()
 	key1 := pk1.PublicKey().SerializeCompressed()
 
-	msg, err := store.GetNext(key1)
+	msg, err := store.GetNext(ctx, key1)
 	assert.NoError(err)
 	assert.Nil(msg)
 
@@ -25,21 +29,22 @@ func testGetRemove(t *testing.T, store Store) {
 		Receiver: "bar",
 		Content:  []byte("hello there"),
 	}
-	err = store.Save(msg, key1)
+	err = store.Save(ctx, msg, key1)
 	assert.NoError(err)
 
-	msg1, err := store.GetNext(key1)
+	msg1, err := store.GetNext(ctx, key1)
 	assert.NoError(err)
 	assert.True(proto.Equal(msg, msg1))
 
-	assert.NoError(store.Remove(msg1, key1))
-	msg, err = store.GetNext(key1)
+	assert.NoError(store.Remove(ctx, msg1, key1))
+	msg, err = store.GetNext(ctx, key1)
 	assert.NoError(err)
 	assert.Nil(msg)
 }
 
 func testGetAll(t *testing.T, store Store) {
 	assert := assert.New(t)
+	ctx := context.Background()
 
 	privateKey, err := easyecc.NewRandomPrivateKey()
 	assert.NoError(err)
@@ -53,11 +58,11 @@ func testGetAll(t *testing.T, store Store) {
 			Content:  []byte(fmt.Sprintf("this is message #%d", i)),
 		}
 		messages[i] = msg
-		err = store.Save(msg, serializedPublicKey)
+		err = store.Save(ctx, msg, serializedPublicKey)
 		assert.NoError(err)
 	}
 
-	allMessages, err := store.GetAll(serializedPublicKey)
+	allMessages, err := store.GetAll(ctx, serializedPublicKey)
 	assert.NoError(err)
 	assert.True(len(allMessages) == 5)
 	for _, msg := range messages {
@@ -65,8 +70,8 @@ func testGetAll(t *testing.T, store Store) {
 	}
 
 	// Delete one of the messages.
-	err = store.Remove(messages[3], serializedPublicKey)
-	allMessages, err = store.GetAll(serializedPublicKey)
+	err = store.Remove(ctx, messages[3], serializedPublicKey)
+	allMessages, err = store.GetAll(ctx, serializedPublicKey)
 	assert.NoError(err)
 	assert.True(len(allMessages) == 4)
 	for i, msg := range messages {
@@ -79,10 +84,443 @@ func testGetAll(t *testing.T, store Store) {
 
 	// Delete all messages.
 	for _, msg := range allMessages {
-		assert.NoError(store.Remove(msg, serializedPublicKey))
+		assert.NoError(store.Remove(ctx, msg, serializedPublicKey))
 	}
 
-	allMessages, err = store.GetAll(serializedPublicKey)
+	allMessages, err = store.GetAll(ctx, serializedPublicKey)
 	assert.NoError(err)
 	assert.True(len(allMessages) == 0)
 }
+
+func testLeaseAck(t *testing.T, store Store) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	pk, _ := easyecc.NewRandomPrivateKey()
+	key := pk.PublicKey().SerializeCompressed()
+
+	msg, deliveryID, err := store.Lease(ctx, key, time.Minute)
+	assert.NoError(err)
+	assert.Nil(msg)
+	assert.Empty(deliveryID)
+
+	for i := 0; i < 2; i++ {
+		err = store.Save(ctx, &pb.DMSMessage{
+			Sender:   "foo",
+			Receiver: "bar",
+			Content:  []byte(fmt.Sprintf("message #%d", i)),
+		}, key)
+		assert.NoError(err)
+	}
+
+	// Lease both messages, the second one for a short time.
+	msg1, deliveryID1, err := store.Lease(ctx, key, time.Minute)
+	assert.NoError(err)
+	assert.NotNil(msg1)
+	assert.NotEmpty(deliveryID1)
+
+	msg2, deliveryID2, err := store.Lease(ctx, key, 50*time.Millisecond)
+	assert.NoError(err)
+	assert.NotNil(msg2)
+	assert.NotEqual(deliveryID1, deliveryID2)
+
+	// Nothing else is available until the lease expires.
+	msg, _, err = store.Lease(ctx, key, time.Minute)
+	assert.NoError(err)
+	assert.Nil(msg)
+
+	time.Sleep(100 * time.Millisecond)
+	msg, deliveryID, err = store.Lease(ctx, key, time.Minute)
+	assert.NoError(err)
+	assert.True(proto.Equal(msg2, msg))
+	assert.Equal(deliveryID2, deliveryID)
+
+	// Requeued message becomes available after the delay.
+	assert.NoError(store.Requeue(ctx, key, deliveryID2, 50*time.Millisecond))
+	msg, _, err = store.Lease(ctx, key, time.Minute)
+	assert.NoError(err)
+	assert.Nil(msg)
+	time.Sleep(100 * time.Millisecond)
+	msg, deliveryID, err = store.Lease(ctx, key, time.Minute)
+	assert.NoError(err)
+	assert.True(proto.Equal(msg2, msg))
+	assert.Equal(deliveryID2, deliveryID)
+
+	// Leased messages are still there until acknowledged.
+	allMessages, err := store.GetAll(ctx, key)
+	assert.NoError(err)
+	assert.Len(allMessages, 2)
+
+	assert.NoError(store.Ack(ctx, key, deliveryID1))
+	assert.NoError(store.Ack(ctx, key, deliveryID2))
+	allMessages, err = store.GetAll(ctx, key)
+	assert.NoError(err)
+	assert.Len(allMessages, 0)
+
+	assert.ErrorIs(store.Ack(ctx, key, "../../something"), ErrInvalidDeliveryID)
+	assert.ErrorIs(store.Requeue(ctx, key, "../../something", time.Minute), ErrInvalidDeliveryID)
+}
+
+func testStats(t *testing.T, store Store) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	pk, _ := easyecc.NewRandomPrivateKey()
+	key := pk.PublicKey().SerializeCompressed()
+
+	stats, err := store.Stats(ctx, key)
+	assert.NoError(err)
+	assert.Equal(0, stats.Count)
+	assert.EqualValues(0, stats.Size)
+
+	var expectedSize int64
+	var messages []*pb.DMSMessage
+	for i := 0; i < 3; i++ {
+		msg := &pb.DMSMessage{
+			Sender:   "foo",
+			Receiver: "bar",
+			Content:  []byte(fmt.Sprintf("message #%d", i)),
+		}
+		assert.NoError(store.Save(ctx, msg, key))
+		expectedSize += int64(proto.Size(msg))
+		messages = append(messages, msg)
+	}
+
+	stats, err = store.Stats(ctx, key)
+	assert.NoError(err)
+	assert.Equal(3, stats.Count)
+	assert.Equal(expectedSize, stats.Size)
+
+	assert.NoError(store.Remove(ctx, messages[0], key))
+	stats, err = store.Stats(ctx, key)
+	assert.NoError(err)
+	assert.Equal(2, stats.Count)
+	assert.Equal(expectedSize-int64(proto.Size(messages[0])), stats.Size)
+}
+
+func testDeliveryWindow(t *testing.T, store Store) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	pk, _ := easyecc.NewRandomPrivateKey()
+	key := pk.PublicKey().SerializeCompressed()
+
+	withOptions := func(content string, opts *pb.DeliveryOptions) *pb.DMSMessage {
+		b, err := proto.Marshal(opts)
+		assert.NoError(err)
+		return &pb.DMSMessage{
+			Sender:          "foo",
+			Receiver:        "bar",
+			Content:         []byte(content),
+			DeliveryOptions: b,
+		}
+	}
+	now := time.Now()
+	regular := &pb.DMSMessage{Sender: "foo", Receiver: "bar", Content: []byte("regular")}
+	scheduled := withOptions("scheduled", &pb.DeliveryOptions{NotBefore: now.Add(time.Hour).Unix()})
+	expired := withOptions("expired", &pb.DeliveryOptions{Expires: now.Add(-time.Second).Unix()})
+	for _, msg := range []*pb.DMSMessage{regular, scheduled, expired} {
+		assert.NoError(store.Save(ctx, msg, key))
+	}
+
+	// Only the regular message is available.
+	msgs, err := store.GetAll(ctx, key)
+	assert.NoError(err)
+	assert.Len(msgs, 1)
+	assert.True(proto.Equal(regular, msgs[0]))
+
+	msg, err := store.GetNext(ctx, key)
+	assert.NoError(err)
+	assert.True(proto.Equal(regular, msg))
+
+	msg, _, err = store.Lease(ctx, key, time.Minute)
+	assert.NoError(err)
+	assert.True(proto.Equal(regular, msg))
+	msg, _, err = store.Lease(ctx, key, time.Minute)
+	assert.NoError(err)
+	assert.Nil(msg)
+
+	// The scheduled message is still there.
+	stats, err := store.Stats(ctx, key)
+	assert.NoError(err)
+	assert.Equal(2, stats.Count)
+}
+
+// testNoExpiry checks the store opened with zero TTL, which keeps the messages
+// until they are removed, unless they set their own expiration.
+func testNoExpiry(t *testing.T, store Store) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	pk, _ := easyecc.NewRandomPrivateKey()
+	key := pk.PublicKey().SerializeCompressed()
+
+	opts, err := proto.Marshal(&pb.DeliveryOptions{Expires: time.Now().Add(time.Hour).Unix()})
+	assert.NoError(err)
+	messages := []*pb.DMSMessage{
+		{Sender: "foo", Receiver: "bar", Content: []byte("regular")},
+		{Sender: "foo", Receiver: "bar", Content: []byte("expires"), DeliveryOptions: opts},
+	}
+	for _, msg := range messages {
+		assert.NoError(store.Save(ctx, msg, key))
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	stats, err := store.Stats(ctx, key)
+	assert.NoError(err)
+	assert.Equal(2, stats.Count)
+	msgs, err := store.GetAll(ctx, key)
+	assert.NoError(err)
+	if assert.Len(msgs, 2) {
+		assert.True(proto.Equal(messages[0], msgs[0]))
+		assert.True(proto.Equal(messages[1], msgs[1]))
+	}
+	msg, _, err := store.Lease(ctx, key, time.Minute)
+	assert.NoError(err)
+	assert.True(proto.Equal(messages[0], msg))
+}
+
+func testSaveOnce(t *testing.T, store Store) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	pk1, _ := easyecc.NewRandomPrivateKey()
+	key1 := pk1.PublicKey().SerializeCompressed()
+	pk2, _ := easyecc.NewRandomPrivateKey()
+	key2 := pk2.PublicKey().SerializeCompressed()
+
+	msg := &pb.DMSMessage{Sender: "foo", Receiver: "bar", Content: []byte("hello")}
+	saved, err := store.SaveOnce(ctx, msg, key1, "abc", time.Second)
+	assert.NoError(err)
+	assert.True(saved)
+
+	// The retry is serialized differently, but has the same dedup key.
+	retry := &pb.DMSMessage{Sender: "foo", Receiver: "bar", Content: []byte("hello"),
+		CryptoContext: &pb.CryptoContext{EcdhVersion: 2}}
+	saved, err = store.SaveOnce(ctx, retry, key1, "abc", time.Second)
+	assert.NoError(err)
+	assert.False(saved)
+
+	// The duplicate is detected after the message was received.
+	msg1, id, err := store.Lease(ctx, key1, time.Minute)
+	assert.NoError(err)
+	assert.True(proto.Equal(msg, msg1))
+	assert.NoError(store.Ack(ctx, key1, id))
+	saved, err = store.SaveOnce(ctx, retry, key1, "abc", time.Second)
+	assert.NoError(err)
+	assert.False(saved)
+
+	// The dedup keys are per receiver.
+	saved, err = store.SaveOnce(ctx, msg, key2, "abc", time.Second)
+	assert.NoError(err)
+	assert.True(saved)
+
+	// The window has passed.
+	time.Sleep(1100 * time.Millisecond)
+	saved, err = store.SaveOnce(ctx, retry, key1, "abc", time.Second)
+	assert.NoError(err)
+	assert.True(saved)
+	msgs, err := store.GetAll(ctx, key1)
+	assert.NoError(err)
+	if assert.Len(msgs, 1) {
+		assert.True(proto.Equal(retry, msgs[0]))
+	}
+}
+
+func testListGetDelete(t *testing.T, store Store) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	pk, _ := easyecc.NewRandomPrivateKey()
+	key := pk.PublicKey().SerializeCompressed()
+
+	infos, err := store.List(ctx, key)
+	assert.NoError(err)
+	assert.Empty(infos)
+
+	start := time.Now().Add(-time.Second)
+	var msgs []*pb.DMSMessage
+	for i := 0; i < 3; i++ {
+		msg := &pb.DMSMessage{
+			Sender:   fmt.Sprintf("sender%d", i),
+			Receiver: "bar",
+			Content:  []byte(fmt.Sprintf("message #%d", i)),
+		}
+		assert.NoError(store.Save(ctx, msg, key))
+		msgs = append(msgs, msg)
+	}
+	// Leased messages are listed too.
+	_, deliveryID, err := store.Lease(ctx, key, time.Minute)
+	assert.NoError(err)
+
+	infos, err = store.List(ctx, key)
+	assert.NoError(err)
+	if !assert.Len(infos, 3) {
+		return
+	}
+	for i, info := range infos {
+		assert.Equal(fmt.Sprintf("sender%d", i), info.Sender)
+		assert.Equal(int64(proto.Size(msgs[i])), info.Size)
+		assert.True(info.Arrived.After(start))
+	}
+	assert.Equal(deliveryID, infos[0].ID)
+
+	msg, err := store.Get(ctx, key, infos[1].ID)
+	assert.NoError(err)
+	assert.True(proto.Equal(msgs[1], msg))
+
+	assert.NoError(store.Delete(ctx, key, infos[1].ID))
+	_, err = store.Get(ctx, key, infos[1].ID)
+	assert.ErrorIs(err, ErrNotFound)
+	assert.NoError(store.Delete(ctx, key, infos[1].ID))
+
+	_, err = store.Get(ctx, key, "../foo")
+	assert.ErrorIs(err, ErrInvalidDeliveryID)
+	assert.ErrorIs(store.Delete(ctx, key, "../foo"), ErrInvalidDeliveryID)
+
+	infos, err = store.List(ctx, key)
+	assert.NoError(err)
+	assert.Len(infos, 2)
+}
+
+func testPolicy(t *testing.T, store Store) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	pk, _ := easyecc.NewRandomPrivateKey()
+	key := pk.PublicKey().SerializeCompressed()
+
+	policy, err := store.GetPolicy(ctx, key)
+	assert.NoError(err)
+	assert.Nil(policy)
+
+	policy1 := &pb.Signed{Content: []byte("policy 1"), Key: key}
+	assert.NoError(store.SavePolicy(ctx, key, policy1))
+	policy, err = store.GetPolicy(ctx, key)
+	assert.NoError(err)
+	assert.True(proto.Equal(policy1, policy))
+
+	policy2 := &pb.Signed{Content: []byte("policy 2"), Key: key}
+	assert.NoError(store.SavePolicy(ctx, key, policy2))
+	policy, err = store.GetPolicy(ctx, key)
+	assert.NoError(err)
+	assert.True(proto.Equal(policy2, policy))
+
+	// The policy is not a message.
+	stats, err := store.Stats(ctx, key)
+	assert.NoError(err)
+	assert.Equal(0, stats.Count)
+}
+
+func testOrder(t *testing.T, store Store) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	pk, _ := easyecc.NewRandomPrivateKey()
+	key := pk.PublicKey().SerializeCompressed()
+
+	var messages []*pb.DMSMessage
+	for i := 0; i < 20; i++ {
+		msg := &pb.DMSMessage{
+			Sender:   "foo",
+			Receiver: "bar",
+			Content:  []byte(fmt.Sprintf("message #%d", i)),
+		}
+		messages = append(messages, msg)
+		assert.NoError(store.Save(ctx, msg, key))
+	}
+
+	// Saving the same message again doesn't change anything.
+	assert.NoError(store.Save(ctx, messages[0], key))
+	stats, err := store.Stats(ctx, key)
+	assert.NoError(err)
+	assert.Equal(len(messages), stats.Count)
+
+	allMessages, err := store.GetAll(ctx, key)
+	assert.NoError(err)
+	assert.Len(allMessages, len(messages))
+	for i, msg := range allMessages {
+		assert.True(proto.Equal(messages[i], msg))
+	}
+
+	msg, err := store.GetNext(ctx, key)
+	assert.NoError(err)
+	assert.True(proto.Equal(messages[0], msg))
+	assert.NoError(store.Remove(ctx, msg, key))
+	msg, err = store.GetNext(ctx, key)
+	assert.NoError(err)
+	assert.True(proto.Equal(messages[1], msg))
+
+	for i := 1; i < len(messages); i++ {
+		msg, deliveryID, err := store.Lease(ctx, key, time.Minute)
+		assert.NoError(err)
+		assert.True(proto.Equal(messages[i], msg))
+		if i%2 == 0 {
+			assert.NoError(store.Ack(ctx, key, deliveryID))
+		}
+	}
+	allMessages, err = store.GetAll(ctx, key)
+	assert.NoError(err)
+	assert.Len(allMessages, len(messages)/2)
+	for i, msg := range allMessages {
+		assert.True(proto.Equal(messages[2*i+1], msg))
+	}
+}
+
+func testAdmin(t *testing.T, store AdminStore) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	pk1, _ := easyecc.NewRandomPrivateKey()
+	key1 := pk1.PublicKey().SerializeCompressed()
+	pk2, _ := easyecc.NewRandomPrivateKey()
+	key2 := pk2.PublicKey().SerializeCompressed()
+
+	var size1 int64
+	for i := 0; i < 3; i++ {
+		msg := &pb.DMSMessage{
+			Sender:   "foo",
+			Receiver: "bar",
+			Content:  []byte(fmt.Sprintf("message #%d", i)),
+		}
+		size1 += int64(proto.Size(msg))
+		assert.NoError(store.Save(ctx, msg, key1))
+	}
+	msg := &pb.DMSMessage{Sender: "foo", Receiver: "baz", Content: []byte("hello")}
+	assert.NoError(store.Save(ctx, msg, key2))
+	assert.NoError(store.SavePolicy(ctx, key2, &pb.Signed{Content: []byte("policy"), Key: key2}))
+
+	mailboxes, err := store.Mailboxes(ctx)
+	assert.NoError(err)
+	assert.Len(mailboxes, 2)
+	for _, mailbox := range mailboxes {
+		if bytes.Equal(mailbox.ReceiverKey, key1) {
+			assert.Equal(3, mailbox.Count)
+			assert.Equal(size1, mailbox.Size)
+		} else {
+			assert.Equal(key2, mailbox.ReceiverKey)
+			assert.Equal(1, mailbox.Count)
+		}
+	}
+
+	policies, err := store.Policies(ctx)
+	assert.NoError(err)
+	assert.Equal([][]byte{key2}, policies)
+
+	count, err := store.Purge(ctx, key1)
+	assert.NoError(err)
+	assert.Equal(3, count)
+	stats, err := store.Stats(ctx, key1)
+	assert.NoError(err)
+	assert.Equal(0, stats.Count)
+	mailboxes, err = store.Mailboxes(ctx)
+	assert.NoError(err)
+	assert.Len(mailboxes, 1)
+
+	count, err = store.Purge(ctx, key1)
+	assert.NoError(err)
+	assert.Equal(0, count)
+
+	_, err = store.DiskSize(ctx)
+	assert.NoError(err)
+}